@@ -0,0 +1,151 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sohaha/zlsgo/zfile"
+	"github.com/sohaha/zlsgo/zstring"
+	"github.com/tdewolff/minify/v2"
+
+	"github.com/sohaha/zzz/util"
+)
+
+// DefaultAssetsIgnoreFile 静态资源忽略规则文件
+const DefaultAssetsIgnoreFile = ".zstaticignore"
+
+// PackOptions 静态资源打包选项
+type PackOptions struct {
+	// Ignore 目录分组中需要忽略的文件规则
+	Ignore []string
+	// Minify 打包前压缩 HTML/CSS/JS
+	Minify bool
+
+	minifier *minify.M
+}
+
+// AssetStat 单个资源的打包统计
+type AssetStat struct {
+	Group      string
+	File       string
+	Original   int64
+	Minified   int64
+	Compressed int64
+}
+
+// LoadAssetsIgnore 读取忽略规则，每行一条，支持 # 注释与 * 通配符，
+// 以 / 结尾的规则匹配目录
+func LoadAssetsIgnore(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	rules := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	return rules, scanner.Err()
+}
+
+// ignored 判断相对项目根目录的路径是否被忽略
+func (o *PackOptions) ignored(file string) bool {
+	file = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(file, "\\", "/")), "/")
+	for _, rule := range o.Ignore {
+		rule = strings.TrimPrefix(rule, "/")
+		if strings.HasSuffix(rule, "/") {
+			dir := strings.TrimSuffix(rule, "/")
+			if file == dir || strings.HasPrefix(file, dir+"/") ||
+				strings.Contains(file, "/"+dir+"/") {
+				return true
+			}
+			continue
+		}
+		if file == rule || zstring.Match(file, rule) || zstring.Match(path.Base(file), rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// packAsset 读取资源，按需压缩代码后进行 gzip 压缩
+func (o *PackOptions) packAsset(file string) ([]byte, AssetStat, error) {
+	stat := AssetStat{File: file}
+	data, err := zfile.ReadFile(file)
+	if err != nil {
+		return nil, stat, err
+	}
+	stat.Original = int64(len(data))
+	if o.Minify {
+		if o.minifier == nil {
+			o.minifier = util.NewMinifier()
+		}
+		data, _ = util.MinifyFile(o.minifier, file, data)
+	}
+	stat.Minified = int64(len(data))
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	stat.Compressed = int64(buf.Len())
+	return buf.Bytes(), stat, nil
+}
+
+// AssetsTotal 统计资源打包后的总大小
+func AssetsTotal(stats []AssetStat) (original, compressed int64) {
+	for _, s := range stats {
+		original += s.Original
+		compressed += s.Compressed
+	}
+	return
+}
+
+// PrintAssetsReport 按分组输出资源的原始大小与压缩后大小
+func PrintAssetsReport(w io.Writer, stats []AssetStat) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "分组\t文件\t原始大小\t压缩代码后\t打包后")
+	var group string
+	var groupOriginal, groupCompressed int64
+	flush := func() {
+		if group == "" {
+			return
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t小计\t%s\t\t%s\n", group,
+			zfile.SizeFormat(groupOriginal), zfile.SizeFormat(groupCompressed))
+	}
+	for _, s := range stats {
+		g := s.Group
+		if g == "" {
+			g = "."
+		}
+		if g != group {
+			flush()
+			group, groupOriginal, groupCompressed = g, 0, 0
+		}
+		groupOriginal += s.Original
+		groupCompressed += s.Compressed
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", g, s.File,
+			zfile.SizeFormat(s.Original), zfile.SizeFormat(s.Minified), zfile.SizeFormat(s.Compressed))
+	}
+	flush()
+	original, compressed := AssetsTotal(stats)
+	_, _ = fmt.Fprintf(tw, "总计\t%d 个文件\t%s\t\t%s\n", len(stats),
+		zfile.SizeFormat(original), zfile.SizeFormat(compressed))
+	_ = tw.Flush()
+}
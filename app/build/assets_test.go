@@ -0,0 +1,72 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssetsIgnore(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, DefaultAssetsIgnoreFile)
+	if err := os.WriteFile(file, []byte("# comment\n\n*.map\nnode_modules/\nstatic/secret.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadAssetsIgnore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("unexpected rules: %v", rules)
+	}
+
+	o := &PackOptions{Ignore: rules}
+	for name, want := range map[string]bool{
+		"static/app.js.map":            true,
+		"static/node_modules/a.js":     true,
+		"static/secret.txt":            true,
+		"static/app.js":                false,
+		"static/node_modules_old/a.js": false,
+	} {
+		if got := o.ignored(name); got != want {
+			t.Errorf("ignored(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	if rules, err = LoadAssetsIgnore(filepath.Join(dir, "none")); err != nil || rules != nil {
+		t.Fatalf("missing ignore file: %v %v", rules, err)
+	}
+}
+
+func TestPackAssetMinify(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.css")
+	css := "body  {\n  color : red ;\n}\n"
+	if err := os.WriteFile(file, []byte(css), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, plain, err := (&PackOptions{}).packAsset(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Original != int64(len(css)) || plain.Minified != plain.Original {
+		t.Fatalf("unexpected stat: %+v", plain)
+	}
+
+	_, minified, err := (&PackOptions{Minify: true}).packAsset(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minified.Minified >= minified.Original || minified.Compressed == 0 {
+		t.Fatalf("expected minified content: %+v", minified)
+	}
+
+	var buf bytes.Buffer
+	PrintAssetsReport(&buf, []AssetStat{plain, minified})
+	if !strings.Contains(buf.String(), "2 个文件") {
+		t.Fatalf("unexpected report:\n%s", buf.String())
+	}
+}
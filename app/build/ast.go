@@ -70,8 +70,13 @@ func GetReferencedAssets(filenames []string) ([]*ReferencedAssets, error) {
 		if thisAssetBundle == nil {
 			thisAssetBundle = &ReferencedAssets{Caller: filename, BaseDir: baseDir}
 			assetMap[baseDir] = thisAssetBundle
+			result = append(result, thisAssetBundle)
 		}
+		var inspectErr error
 		ast.Inspect(node, func(node ast.Node) bool {
+			if inspectErr != nil {
+				return false
+			}
 			switch x := node.(type) {
 			case *ast.File:
 				packageName = x.Name.Name
@@ -101,8 +106,10 @@ func GetReferencedAssets(filenames []string) ([]*ReferencedAssets, error) {
 							AssetPath := strings.TrimPrefix(zfile.RealPath(rootDir+"/"+thisAsset.RHS.Path), rootDir)
 							newAsset := &ReferencedAsset{Name: thisAsset.RHS.Path, Group: nil, AssetPath: AssetPath}
 							thisAssetBundle.Assets = append(thisAssetBundle.Assets, newAsset)
+						case "EmbedString", "EmbedBytes", "EmbedMustString", "EmbedMustBytes", "NewEmbedFileserver":
+							// 基于 embed.FS 的调用由 //go:embed 负责，无需打包
 						default:
-							err = fmt.Errorf("未知的 static 调用: %s", thisAsset.RHS.Method)
+							inspectErr = fmt.Errorf("未知的 static 调用: %s", thisAsset.RHS.Method)
 							return false
 						}
 					} else {
@@ -119,7 +126,9 @@ func GetReferencedAssets(filenames []string) ([]*ReferencedAssets, error) {
 			}
 			return true
 		})
-		result = append(result, thisAssetBundle)
+		if inspectErr != nil {
+			return nil, fmt.Errorf("%s: %w", filename, inspectErr)
+		}
 	}
 	return result, nil
 }
@@ -0,0 +1,154 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheFile 编译缓存清单，记录每个产物对应的输入哈希
+const CacheFile = ".zzz/build-cache.json"
+
+// Cache 编译缓存，输入哈希与产物均未变化时跳过该目标
+type Cache struct {
+	path    string
+	Entries map[string]CacheEntry `json:"entries"`
+}
+
+// CacheEntry 单个产物的缓存记录
+type CacheEntry struct {
+	Key    string `json:"key"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Time   string `json:"time"`
+}
+
+// LoadCache 读取缓存清单，不存在或损坏时返回空缓存
+func LoadCache(path string) *Cache {
+	c := &Cache{path: path, Entries: map[string]CacheEntry{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	if json.Unmarshal(data, c) != nil || c.Entries == nil {
+		c.Entries = map[string]CacheEntry{}
+	}
+	return c
+}
+
+// Hit 判断产物是否可以复用：输入哈希一致且产物未被修改
+func (c *Cache) Hit(output, key string) bool {
+	e, ok := c.Entries[filepath.ToSlash(output)]
+	if !ok || e.Key != key {
+		return false
+	}
+	sum, size, err := fileSHA256(output)
+	return err == nil && size == e.Size && sum == e.SHA256
+}
+
+// Set 记录产物的输入哈希
+func (c *Cache) Set(output, key string) error {
+	sum, size, err := fileSHA256(output)
+	if err != nil {
+		return err
+	}
+	c.Entries[filepath.ToSlash(output)] = CacheEntry{
+		Key:    key,
+		SHA256: sum,
+		Size:   size,
+		Time:   time.Now().Format(time.RFC3339),
+	}
+	return nil
+}
+
+// Save 写入缓存清单
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// SourceHash 计算模块目录下源码与资源文件的哈希，跳过隐藏目录、测试文件与 skip 中的路径
+func SourceHash(root string, skip []string) (string, error) {
+	skipped := map[string]bool{}
+	for _, dir := range skip {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			skipped[filepath.Clean(filepath.Join(root, dir))] = true
+		}
+	}
+
+	h := sha256.New()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" ||
+				skipped[filepath.Clean(path)]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(name, "_test.go") || skipped[filepath.Clean(path)] {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, _ = io.WriteString(h, filepath.ToSlash(rel)+"\x00")
+		if _, err = io.Copy(h, f); err != nil {
+			return err
+		}
+		_, _ = h.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// TargetKey 根据源码哈希、编译命令与环境变量计算目标的缓存键
+func TargetKey(parts ...[]string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		for _, v := range part {
+			_, _ = io.WriteString(h, v+"\x00")
+		}
+		_, _ = h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheEnv 返回影响编译结果的系统环境变量
+func CacheEnv(environ []string) []string {
+	env := make([]string, 0)
+	for _, e := range environ {
+		name := strings.SplitN(e, "=", 2)[0]
+		if strings.HasPrefix(name, "GO") || strings.HasPrefix(name, "CGO_") ||
+			name == "CC" || name == "CXX" {
+			env = append(env, e)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,90 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "app")
+	if err := os.WriteFile(out, []byte("binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, ".zzz", "build-cache.json")
+	c := LoadCache(path)
+	if c.Hit(out, "key") {
+		t.Fatal("empty cache should not hit")
+	}
+	if err := c.Set(out, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = LoadCache(path)
+	if !c.Hit(out, "key") {
+		t.Fatal("expected cache hit")
+	}
+	if c.Hit(out, "other") {
+		t.Fatal("different key should not hit")
+	}
+	if err := os.WriteFile(out, []byte("modified"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if c.Hit(out, "key") {
+		t.Fatal("modified output should not hit")
+	}
+}
+
+func TestSourceHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main")
+	write("go.sum", "")
+	write("static/index.html", "<html>")
+
+	hash := func() string {
+		t.Helper()
+		h, err := SourceHash(dir, []string{"dist", "app"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	first := hash()
+
+	write("main_test.go", "package main")
+	write(".git/HEAD", "ref")
+	write("dist/app_linux_amd64", "bin")
+	write("app", "bin")
+	if hash() != first {
+		t.Fatal("ignored files should not change the hash")
+	}
+
+	write("static/index.html", "<html></html>")
+	if hash() == first {
+		t.Fatal("asset change should change the hash")
+	}
+}
+
+func TestTargetKey(t *testing.T) {
+	a := TargetKey([]string{"src"}, []string{"GOOS=linux"})
+	b := TargetKey([]string{"src"}, []string{"GOOS=windows"})
+	c := TargetKey([]string{"src", "GOOS=linux"})
+	if a == b || a == c {
+		t.Fatal("keys should differ")
+	}
+}
@@ -11,10 +11,11 @@ import (
 )
 
 type OSData struct {
-	Goos   string
-	Goarch string
-	CXX    string
-	CC     string
+	Goos    string
+	Goarch  string
+	Variant string
+	CXX     string
+	CC      string
 }
 
 func CheckZig() error {
@@ -32,11 +33,11 @@ func CommadString(
 	os []OSData,
 	isVendor, isCGO, cShared bool, obfuscate int,
 	NoStatic bool, ldflags string,
-	packageName, outDir string,
-) (commads [][]string, envs [][]string, goos []string) {
+	outDir, nameTpl string, nameData OutputData,
+) (commads [][]string, envs [][]string, targets []OSData) {
 	vendor := ""
 	envs = make([][]string, 0)
-	goos = make([]string, 0)
+	targets = make([]OSData, 0)
 
 	if isVendor {
 		vendor = "-mod=vendor"
@@ -80,45 +81,18 @@ func CommadString(
 
 	for _, v := range os {
 		env := []string{"GOARCH=" + v.Goarch, "GOOS=" + v.Goos}
+		if e := v.VariantEnv(); e != "" {
+			env = append(env, e)
+		}
 		if isCGO {
 			env = append(env, "CGO_ENABLED=1")
 		}
 		if isCGO {
-			target := ""
-			switch v.Goos {
-			case "windows":
-				switch v.Goarch {
-				case "386":
-					target = "x86_64-windows"
-				case "arm64":
-					target = "aarch64-windows"
-				default:
-					target = "x86_64-windows"
-				}
-			case "darwin":
-				sysroot := ""
-				if appendSysroot {
-					if _, rootPath, _, err := zshell.Run("xcrun --show-sdk-path"); err == nil &&
-						rootPath != "" {
-						sysroot = " --sysroot=" + rootPath + " -F" + rootPath + "/System/Library/Frameworks -I/usr/include -L/usr/lib"
-					}
-				}
-				switch v.Goarch {
-				case "386":
-					target = "x86-macos" + sysroot
-				case "amd64":
-					target = "x86_64-macos" + sysroot
-				default:
-					target = "aarch64-macos" + sysroot
-				}
-			case "linux":
-				switch v.Goarch {
-				case "386":
-					target = "x86-linux-musl"
-				case "arm64":
-					target = "aarch64-linux-musl"
-				default:
-					target = "x86_64-linux-musl"
+			target := zigTarget(v.Goos, v.Goarch)
+			if target != "" && v.Goos == "darwin" && appendSysroot {
+				if _, rootPath, _, err := zshell.Run("xcrun --show-sdk-path"); err == nil &&
+					rootPath != "" {
+					target += " --sysroot=" + rootPath + " -F" + rootPath + "/System/Library/Frameworks -I/usr/include -L/usr/lib"
 				}
 			}
 
@@ -131,20 +105,32 @@ func CommadString(
 				env = append(env, `CC=zig cc`)
 			}
 		}
-		commad := baseCommand(outDir, packageName+"_"+v.Goos+"_"+v.Goarch, vendor, cShared, v.Goos)
+		data := nameData
+		data.OS, data.Arch, data.Variant = v.Goos, v.Goarch, v.Variant
+		name, err := OutputName(nameTpl, data.Name+"_"+v.Goos+"_"+v.ArchLabel(), data)
+		if err != nil {
+			util.Log.Fatal(err)
+		}
+		commad := baseCommand(outDir, name, vendor, cShared, v.Goos)
 		commads = append(commads, commad)
 		envs = append(envs, env)
-		goos = append(goos, v.Goos)
+		targets = append(targets, OSData{Goos: v.Goos, Goarch: v.Goarch, Variant: v.Variant})
 	}
 
 	if len(commads) == 0 {
-		commads = [][]string{baseCommand(outDir, packageName, vendor, cShared, zutil.GetOs())}
+		data := nameData
+		data.OS, data.Arch = zutil.GetOs(), runtime.GOARCH
+		name, err := OutputName(nameTpl, data.Name, data)
+		if err != nil {
+			util.Log.Fatal(err)
+		}
+		commads = [][]string{baseCommand(outDir, name, vendor, cShared, zutil.GetOs())}
 		env := []string{}
 		if isCGO {
 			env = append(env, "CGO_ENABLED=1")
 		}
 		envs = append(envs, env)
-		goos = append(goos, zutil.GetOs())
+		targets = append(targets, OSData{Goos: zutil.GetOs(), Goarch: runtime.GOARCH})
 	}
 
 	if obfuscate > 0 {
@@ -195,58 +181,38 @@ func filename(outDir, name string, cShared bool, goos string) string {
 	return outDir + zutil.IfVal(goos == "windows", name+".exe", name)
 }
 
-func TargetsCommad(target string) map[string][]string {
-	var (
-		goos   = target
-		goarch = ""
-		commad = map[string][]string{}
-	)
-	t := strings.Split(target, "/")
-	if len(t) > 1 {
-		goos = t[0]
-		goarch = t[1]
-	}
+// zigTarget 返回 CGO 交叉编译时 zig 使用的目标，不支持时返回空
+func zigTarget(goos, goarch string) string {
 	switch goos {
-	case "w", "win", "windows":
-		commad["windows"] = ParserArch(goarch, []string{"386", "amd64"})
-	case "l", "linux":
-		commad["linux"] = ParserArch(goarch, []string{"386", "amd64"})
-	case "d", "darwin", "mac", "m":
-		if goarch == "64" {
-			goarch = "arm64"
-		}
-		commad["darwin"] = ParserArch(goarch, []string{"arm64"})
-	case "android", "a":
-		commad["android"] = ParserArch(goarch, []string{"arm64"})
-	default:
-		if goos == "" {
-			break
-		}
-		if goarch == "*" || goarch == "" {
-			util.Log.Errorf(
-				"没有为 %s 预设 GOARCH，请补全，例如: linux/amd64,windows/386\n",
-				goos,
-			)
-			return nil
-		}
-		commad[goos] = ParserArch(goarch, []string{})
-	}
-	return commad
-}
-
-func ParserArch(arch string, lists []string) []string {
-	archs := make([]string, 0)
-	switch arch {
-	case "", "*":
-		archs = lists
-	case "32":
-		archs = append(archs, "386")
-	case "64":
-		archs = append(archs, "amd64")
-	default:
-		archs = append(archs, arch)
+	case "windows":
+		switch goarch {
+		case "386", "amd64":
+			return "x86_64-windows"
+		case "arm64":
+			return "aarch64-windows"
+		}
+	case "darwin":
+		switch goarch {
+		case "amd64":
+			return "x86_64-macos"
+		case "arm64":
+			return "aarch64-macos"
+		}
+	case "linux":
+		switch goarch {
+		case "386":
+			return "x86-linux-musl"
+		case "amd64":
+			return "x86_64-linux-musl"
+		case "arm64":
+			return "aarch64-linux-musl"
+		case "arm":
+			return "arm-linux-musleabihf"
+		case "riscv64":
+			return "riscv64-linux-musl"
+		}
 	}
-	return archs
+	return ""
 }
 
 func ParserTarget(cross string) []string {
@@ -0,0 +1,272 @@
+package build
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zlsgo/zfile"
+)
+
+// DefaultDistFiles 默认随编译产物打包的文件
+var DefaultDistFiles = []string{"LICENSE*", "README*"}
+
+// Artifact 发布产物信息
+type Artifact struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	Binary string `json:"binary"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest 发布产物清单
+type Manifest struct {
+	Name      string     `json:"name"`
+	Version   string     `json:"version"`
+	Commit    string     `json:"commit"`
+	Date      string     `json:"date"`
+	Checksums string     `json:"checksums"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Dist 打包配置
+type Dist struct {
+	Dir     string
+	Name    string
+	Version string
+	Commit  string
+	Files   []string
+}
+
+// Package 将编译成功的产物与附加文件打包为 name_version_os_arch.tar.gz/.zip，
+// 并生成校验文件与 manifest.json
+func (d *Dist) Package(results []Result) (*Manifest, error) {
+	extras, err := distFiles(d.Files)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(d.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Name:      d.Name,
+		Version:   d.Version,
+		Commit:    d.Commit,
+		Date:      time.Now().Format(time.RFC3339),
+		Artifacts: make([]Artifact, 0, len(results)),
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Output == "" {
+			continue
+		}
+		base := d.Name + "_" + d.Version + "_" + r.OS + "_" + r.Arch
+		archive := filepath.Join(d.Dir, base+".tar.gz")
+		if r.OS == "windows" {
+			archive = filepath.Join(d.Dir, base+".zip")
+		}
+		binary := d.Name + binaryExt(r.Output)
+		entries := append([]distEntry{{src: r.Output, name: binary, mode: 0o755}}, extras...)
+		if strings.HasSuffix(archive, ".zip") {
+			err = writeZip(archive, entries)
+		} else {
+			err = writeTarGz(archive, entries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("打包 %s 失败: %w", r.Target, err)
+		}
+
+		sum, size, err := fileSHA256(archive)
+		if err != nil {
+			return nil, err
+		}
+		m.Artifacts = append(m.Artifacts, Artifact{
+			Name:   filepath.Base(archive),
+			Path:   archive,
+			OS:     r.OS,
+			Arch:   r.Arch,
+			Binary: binary,
+			Size:   size,
+			SHA256: sum,
+		})
+	}
+
+	m.Checksums = filepath.Join(d.Dir, d.Name+"_"+d.Version+"_checksums.txt")
+	var sums strings.Builder
+	for _, a := range m.Artifacts {
+		sums.WriteString(a.SHA256 + "  " + a.Name + "\n")
+	}
+	if err = os.WriteFile(m.Checksums, []byte(sums.String()), 0o644); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return m, os.WriteFile(filepath.Join(d.Dir, "manifest.json"), append(data, '\n'), 0o644)
+}
+
+type distEntry struct {
+	src  string
+	name string
+	mode os.FileMode
+}
+
+// distFiles 解析附加文件，支持通配符，未匹配的通配符会被忽略
+func distFiles(patterns []string) ([]distEntry, error) {
+	entries := make([]distEntry, 0, len(patterns))
+	seen := map[string]struct{}{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("附加文件规则错误 %s: %w", pattern, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("附加文件不存在: %s", pattern)
+		}
+		sort.Strings(matches)
+		for _, file := range matches {
+			if !zfile.FileExist(file) {
+				continue
+			}
+			name := filepath.ToSlash(filepath.Clean(file))
+			if filepath.IsAbs(file) || strings.HasPrefix(name, "../") {
+				name = filepath.Base(file)
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			entries = append(entries, distEntry{src: file, name: name, mode: 0o644})
+		}
+	}
+	return entries, nil
+}
+
+func binaryExt(output string) string {
+	switch ext := strings.ToLower(filepath.Ext(output)); ext {
+	case ".exe", ".dll", ".so", ".dylib":
+		return ext
+	}
+	return ""
+}
+
+func writeTarGz(path string, entries []distEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		if err = addTar(tw, e); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addTar(tw *tar.Writer, e distEntry) error {
+	src, err := os.Open(e.src)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    e.name,
+		Mode:    int64(e.mode),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+func writeZip(path string, entries []distEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		if err = addZip(zw, e); err != nil {
+			return err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addZip(zw *zip.Writer, e distEntry) error {
+	src, err := os.Open(e.src)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	h, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	h.Name = e.name
+	h.Method = zip.Deflate
+	h.SetMode(e.mode)
+	w, err := zw.CreateHeader(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func fileSHA256(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDistPackage(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "app_linux_amd64")
+	exe := filepath.Join(dir, "app_windows_amd64.exe")
+	license := filepath.Join(dir, "LICENSE")
+	for _, f := range []string{bin, exe, license} {
+		if err := os.WriteFile(f, []byte(filepath.Base(f)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := &Dist{
+		Dir:     filepath.Join(dir, "dist"),
+		Name:    "app",
+		Version: "v1.2.3",
+		Files:   []string{license, filepath.Join(dir, "README*")},
+	}
+	m, err := d.Package([]Result{
+		{Target: "linux/amd64", OS: "linux", Arch: "amd64", Output: bin},
+		{Target: "windows/amd64", OS: "windows", Arch: "amd64", Output: exe},
+		{Target: "linux/386", OS: "linux", Arch: "386", Err: errors.New("failed")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Artifacts) != 2 {
+		t.Fatalf("expected 2 artifacts, got %d", len(m.Artifacts))
+	}
+	if m.Artifacts[0].Name != "app_v1.2.3_linux_amd64.tar.gz" || m.Artifacts[1].Name != "app_v1.2.3_windows_amd64.zip" {
+		t.Fatalf("unexpected artifacts: %+v", m.Artifacts)
+	}
+	if m.Artifacts[1].Binary != "app.exe" {
+		t.Fatalf("unexpected binary name: %s", m.Artifacts[1].Binary)
+	}
+
+	sums, err := os.ReadFile(m.Checksums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sums), m.Artifacts[0].SHA256+"  "+m.Artifacts[0].Name) {
+		t.Fatalf("unexpected checksums:\n%s", sums)
+	}
+
+	data, err := os.ReadFile(filepath.Join(d.Dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err = json.Unmarshal(data, &manifest); err != nil || manifest.Version != "v1.2.3" {
+		t.Fatalf("unexpected manifest: %s %v", data, err)
+	}
+
+	f, err := os.Open(m.Artifacts[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	names := make([]string, 0)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, h.Name)
+	}
+	if len(names) != 2 || names[0] != "app" || names[1] != "LICENSE" {
+		t.Fatalf("unexpected archive entries: %v", names)
+	}
+}
+
+func TestDistFilesMissing(t *testing.T) {
+	if _, err := distFiles([]string{"not-exist-file"}); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if entries, err := distFiles([]string{"not-exist-*"}); err != nil || len(entries) != 0 {
+		t.Fatalf("unexpected result: %v %v", entries, err)
+	}
+}
@@ -0,0 +1,212 @@
+package build
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Embed 一条 //go:embed 指令
+type Embed struct {
+	Var      string
+	Pos      string
+	Patterns []string
+	Files    []string
+}
+
+// PackageEmbeds 单个包内通过 //go:embed 嵌入的文件
+type PackageEmbeds struct {
+	Dir         string
+	PackageName string
+	Embeds      []*Embed
+	Files       []string
+}
+
+// GetEmbeddedFiles 分析 Go 文件中的 //go:embed 指令，按包汇总嵌入的文件
+func GetEmbeddedFiles(filenames []string) ([]*PackageEmbeds, error) {
+	pkgs := make(map[string]*PackageEmbeds)
+	for _, filename := range filenames {
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		embeds, err := parseEmbeds(fset, node)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeds) == 0 {
+			continue
+		}
+
+		dir := filepath.ToSlash(filepath.Dir(filename))
+		pkg := pkgs[dir]
+		if pkg == nil {
+			pkg = &PackageEmbeds{Dir: dir, PackageName: node.Name.Name}
+			pkgs[dir] = pkg
+		}
+		for _, e := range embeds {
+			e.Files, err = expandEmbed(filepath.Dir(filename), e.Patterns)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", e.Pos, err)
+			}
+			pkg.Embeds = append(pkg.Embeds, e)
+		}
+	}
+
+	result := make([]*PackageEmbeds, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		seen := map[string]struct{}{}
+		for _, e := range pkg.Embeds {
+			for _, f := range e.Files {
+				if _, ok := seen[f]; !ok {
+					seen[f] = struct{}{}
+					pkg.Files = append(pkg.Files, f)
+				}
+			}
+		}
+		sort.Strings(pkg.Files)
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Dir < result[j].Dir
+	})
+	return result, nil
+}
+
+// parseEmbeds 读取文件中所有变量声明上的 //go:embed 指令
+func parseEmbeds(fset *token.FileSet, file *ast.File) ([]*Embed, error) {
+	embeds := make([]*Embed, 0)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			doc := vs.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if doc == nil {
+				continue
+			}
+			var patterns []string
+			for _, c := range doc.List {
+				if !strings.HasPrefix(c.Text, "//go:embed") {
+					continue
+				}
+				args := strings.TrimPrefix(c.Text, "//go:embed")
+				if args != "" && !unicode.IsSpace(rune(args[0])) {
+					continue
+				}
+				p, err := parseEmbedPatterns(args)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fset.Position(c.Pos()), err)
+				}
+				patterns = append(patterns, p...)
+			}
+			if len(patterns) == 0 || len(vs.Names) == 0 {
+				continue
+			}
+			embeds = append(embeds, &Embed{
+				Var:      vs.Names[0].Name,
+				Pos:      fset.Position(vs.Pos()).String(),
+				Patterns: patterns,
+			})
+		}
+	}
+	return embeds, nil
+}
+
+// parseEmbedPatterns 解析指令参数，支持双引号与反引号包裹的路径
+func parseEmbedPatterns(args string) ([]string, error) {
+	var patterns []string
+	args = strings.TrimSpace(args)
+	for args != "" {
+		var p string
+		switch args[0] {
+		case '"', '`':
+			end := strings.IndexByte(args[1:], args[0])
+			if end < 0 {
+				return nil, fmt.Errorf("go:embed 参数引号不匹配: %s", args)
+			}
+			quoted := args[:end+2]
+			var err error
+			if p, err = strconv.Unquote(quoted); err != nil {
+				return nil, fmt.Errorf("go:embed 参数错误 %s: %w", quoted, err)
+			}
+			args = args[end+2:]
+		default:
+			end := strings.IndexFunc(args, unicode.IsSpace)
+			if end < 0 {
+				end = len(args)
+			}
+			p, args = args[:end], args[end:]
+		}
+		patterns = append(patterns, p)
+		args = strings.TrimSpace(args)
+	}
+	return patterns, nil
+}
+
+// expandEmbed 按 go:embed 规则展开文件，目录会递归包含，
+// 未使用 all: 前缀时忽略以 . 或 _ 开头的文件
+func expandEmbed(dir string, patterns []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, pattern := range patterns {
+		all := strings.HasPrefix(pattern, "all:")
+		pattern = strings.TrimPrefix(pattern, "all:")
+		if pattern == "" || path.IsAbs(pattern) || strings.HasPrefix(path.Clean(pattern), "..") {
+			return nil, fmt.Errorf("go:embed 路径无效: %s", pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("go:embed 路径无效 %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("go:embed 没有匹配的文件: %s", pattern)
+		}
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			rel, _ := filepath.Rel(dir, match)
+			if !fi.IsDir() {
+				files = append(files, filepath.ToSlash(rel))
+				continue
+			}
+			err = filepath.WalkDir(match, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				name := d.Name()
+				if p != match && !all && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.Type().IsRegular() {
+					rel, _ := filepath.Rel(dir, p)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
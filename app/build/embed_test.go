@@ -0,0 +1,132 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetEmbeddedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"assets/index.html": "<html>",
+		"assets/.keep":      "",
+		"assets/_tmp/a.txt": "a",
+		"hidden/_a":         "a",
+		"x y.txt":           "x",
+		"main.go": "package main\n\nimport \"embed\"\n\n" +
+			"//go:embed assets\nvar assets embed.FS\n\n" +
+			"var (\n\t//go:embed all:hidden \"x y.txt\"\n\tall embed.FS\n)\n",
+	})
+
+	pkgs, err := GetEmbeddedFiles([]string{filepath.Join(dir, "main.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 || len(pkgs[0].Embeds) != 2 {
+		t.Fatalf("unexpected result: %+v", pkgs)
+	}
+	got := strings.Join(pkgs[0].Files, ",")
+	if got != "assets/index.html,hidden/_a,x y.txt" {
+		t.Fatalf("unexpected files: %s", got)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"bad/bad.go": "package bad\n\nimport \"embed\"\n\n//go:embed missing\nvar fs embed.FS\n",
+	})
+	if _, err = GetEmbeddedFiles([]string{filepath.Join(dir, "bad", "bad.go")}); err == nil {
+		t.Fatal("expected error for missing pattern")
+	}
+}
+
+func TestGetReferencedAssetsMixed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\n\nimport (\n\t\"embed\"\n\n\t\"github.com/sohaha/zstatic\"\n)\n\n" +
+			"//go:embed static\nvar fs embed.FS\n\n" +
+			"func main() {\n\ta := zstatic.EmbedString(fs, \"static/a\")\n\tb := zstatic.String(\"static/b\")\n" +
+			"\tc := zstatic.NewEmbedFileserver(fs, \"static\")\n\t_, _, _ = a, b, c\n}\n",
+	})
+	assets, err := GetReferencedAssets([]string{filepath.Join(dir, "main.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || len(assets[0].Assets) != 1 || assets[0].Assets[0].Name != "static/b" {
+		t.Fatalf("unexpected assets: %+v", assets[0])
+	}
+}
+
+func TestGetReferencedAssetsUnknownCall(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\n\nimport \"github.com/sohaha/zstatic\"\n\n" +
+			"func main() {\n\ta := zstatic.Strnig(\"static/a\")\n\t_ = a\n}\n",
+		"util.go": "package main\n\nimport \"github.com/sohaha/zstatic\"\n\n" +
+			"func b() string {\n\tb := zstatic.String(\"static/b\")\n\treturn b\n}\n",
+	})
+	if _, err := GetReferencedAssets([]string{filepath.Join(dir, "main.go")}); err == nil || !strings.Contains(err.Error(), "Strnig") {
+		t.Fatalf("expected unknown static call error, got %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\n\nimport \"github.com/sohaha/zstatic\"\n\n" +
+			"func main() {\n\ta := zstatic.String(\"static/a\")\n\t_ = a\n}\n",
+	})
+	// 同一目录的多个文件合并为一组，避免重复打包与统计
+	assets, err := GetReferencedAssets([]string{filepath.Join(dir, "main.go"), filepath.Join(dir, "util.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || len(assets[0].Assets) != 2 {
+		t.Fatalf("unexpected assets: %+v", assets)
+	}
+}
+
+func TestMigrateToEmbed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"static/index.html": "<html>",
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/sohaha/zstatic\"\n)\n\n" +
+			"func main() {\n\tfmt.Println(zstatic.String(\"static/index.html\"))\n" +
+			"\t_ = zstatic.NewFileserver(\"static\")\n\t_, _ = zstatic.Group(\"static\")\n" +
+			"\t_ = zstatic.String(\"missing.txt\")\n}\n",
+	})
+	file := filepath.Join(dir, "main.go")
+	results, err := MigrateToEmbed([]string{file}, dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Rewrites != 2 || len(results[0].Manual) != 2 {
+		t.Fatalf("unexpected result: %+v", results)
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\t\"embed\"\n",
+		"//go:embed static static/index.html\nvar zstaticFS embed.FS",
+		`zstatic.EmbedString(zstaticFS, "static/index.html")`,
+		`zstatic.NewEmbedFileserver(zstaticFS, "static")`,
+		`zstatic.Group("static")`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("missing %q in:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package build
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const zstaticImport = `"github.com/sohaha/zstatic"`
+
+// embedEquivalents zstatic 调用对应的 embed.FS 版本
+var embedEquivalents = map[string]string{
+	"String":        "EmbedString",
+	"MustString":    "EmbedMustString",
+	"Bytes":         "EmbedBytes",
+	"MustBytes":     "EmbedMustBytes",
+	"NewFileserver": "NewEmbedFileserver",
+}
+
+// MigrateResult 单个文件的迁移结果
+type MigrateResult struct {
+	File     string
+	Var      string
+	Rewrites int
+	Patterns []string
+	Manual   []string
+}
+
+type sourceEdit struct {
+	start, end int
+	text       string
+}
+
+// MigrateToEmbed 将 zstatic 的资源调用改写为基于 //go:embed 的 embed.FS 调用，
+// 同一目录下的文件使用不同的变量名，无法自动改写的调用记录在 Manual 中
+func MigrateToEmbed(filenames []string, rootDir string, write bool) ([]*MigrateResult, error) {
+	results := make([]*MigrateResult, 0, len(filenames))
+	vars := map[string]int{}
+	for _, filename := range filenames {
+		dir := filepath.Dir(filename)
+		name := "zstaticFS"
+		if n := vars[dir]; n > 0 {
+			name += strconv.Itoa(n + 1)
+		}
+		r, err := migrateFile(filename, rootDir, name, write)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			continue
+		}
+		if r.Rewrites > 0 {
+			vars[dir]++
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func migrateFile(filename, rootDir, varName string, write bool) (*MigrateResult, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, embedImport := "", (*ast.ImportSpec)(nil)
+	for _, imp := range file.Imports {
+		switch imp.Path.Value {
+		case zstaticImport:
+			obj = "zstatic"
+			if imp.Name != nil {
+				obj = imp.Name.Name
+			}
+		case `"embed"`:
+			embedImport = imp
+		}
+	}
+	if obj == "" || obj == "_" || obj == "." {
+		return nil, nil
+	}
+	if file.Scope != nil && file.Scope.Lookup(varName) != nil {
+		return nil, fmt.Errorf("%s: 变量 %s 已存在", filename, varName)
+	}
+
+	pkgDir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrateResult{File: filename, Var: varName}
+	edits := make([]sourceEdit, 0)
+	patterns := map[string]struct{}{}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != obj {
+			return true
+		}
+		method := sel.Sel.Name
+		pos := fset.Position(call.Pos()).String()
+		manual := func(reason string) {
+			result.Manual = append(result.Manual, fmt.Sprintf("%s: %s.%s %s", pos, obj, method, reason))
+		}
+
+		equivalent, ok := embedEquivalents[method]
+		if !ok {
+			switch method {
+			case "Group", "NewFS", "LoadTemplate", "NewFileserverAndGroup":
+				manual("没有对应的 embed.FS 调用")
+			}
+			return true
+		}
+		if len(call.Args) == 0 {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			manual("参数不是字符串常量")
+			return true
+		}
+		if method == "NewFileserver" && len(call.Args) > 1 {
+			manual("自定义处理函数的签名不同")
+			return true
+		}
+		assetPath, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			manual("参数无法解析")
+			return true
+		}
+		rel, err := filepath.Rel(pkgDir, filepath.Join(rootDir, assetPath))
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			manual("资源不在包目录内，go:embed 无法引用")
+			return true
+		}
+		if _, err = os.Stat(filepath.Join(pkgDir, rel)); err != nil {
+			manual("资源不存在")
+			return true
+		}
+		rel = filepath.ToSlash(rel)
+		patterns[rel] = struct{}{}
+		edits = append(edits, sourceEdit{
+			start: offset(sel.Sel.Pos()),
+			end:   offset(lit.End()),
+			text:  equivalent + "(" + varName + ", " + strconv.Quote(rel),
+		})
+		return true
+	})
+
+	if len(edits) == 0 {
+		return result, nil
+	}
+	for p := range patterns {
+		result.Patterns = append(result.Patterns, p)
+	}
+	sort.Strings(result.Patterns)
+	result.Rewrites = len(edits)
+
+	directive := "\n\n"
+	insert, grouped := offset(file.Name.End()), false
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			insert = offset(gd.End())
+			if embedImport == nil && !grouped && gd.Lparen.IsValid() {
+				grouped = true
+				at := offset(gd.Lparen) + 1
+				edits = append(edits, sourceEdit{start: at, end: at, text: "\n\"embed\""})
+			}
+		}
+	}
+	if embedImport == nil && !grouped {
+		directive += "import \"embed\"\n\n"
+	} else if embedImport != nil && embedImport.Name != nil {
+		// import _ "embed" 无法引用 embed.FS
+		edits = append(edits, sourceEdit{start: offset(embedImport.Pos()), end: offset(embedImport.End()), text: `"embed"`})
+	}
+	directive += "//go:embed " + embedPatterns(result.Patterns) + "\nvar " + varName + " embed.FS\n"
+	edits = append(edits, sourceEdit{start: insert, end: insert, text: directive})
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	out := append([]byte{}, src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 格式化迁移结果失败: %w", filename, err)
+	}
+	if write {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(filename, formatted, fi.Mode()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func embedPatterns(patterns []string) string {
+	quoted := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if strings.ContainsAny(p, " \t\"`") {
+			p = strconv.Quote(p)
+		}
+		quoted = append(quoted, p)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/sohaha/zlsgo/zfile"
 	"github.com/sohaha/zlsgo/zstring"
-	zstatic "github.com/sohaha/zstatic/build"
 )
 
 func ReadMod(pwd string) string {
@@ -71,8 +70,12 @@ func getAllFilesInDirectory(dir string) (result []string, err error) {
 }
 
 // GeneratePackFileString creates the contents of a pack file
-func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool) (string, error) {
+func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool, opts *PackOptions) (string, []AssetStat, error) {
+	if opts == nil {
+		opts = &PackOptions{}
+	}
 	var filesProcessed = make(map[string]bool)
+	stats := make([]AssetStat, 0)
 	result := fmt.Sprintf("package %s\n\n", assetBundle.PackageName)
 	content := zstring.Buffer()
 	if len(assetBundle.Groups) > 0 || len(assetBundle.Assets) > 0 {
@@ -82,7 +85,7 @@ func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool) (s
 			rootDir := zfile.RealPath(".", true)
 			groupPrefix := clearRoot(rootDir, zfile.RealPath(group.FullPath)) + "/"
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 
 			if len(files) == 0 {
@@ -90,11 +93,16 @@ func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool) (s
 			}
 
 			for _, file := range files {
+				if opts.ignored(file) {
+					continue
+				}
 				// Read in File
-				packedData, err := zstatic.CompressFile(file)
+				packedData, stat, err := opts.packAsset(file)
 				if err != nil && !ignoreErrors {
-					return "", err
+					return "", nil, err
 				}
+				stat.Group = group.LocalPath
+				stats = append(stats, stat)
 				localPath := clearRoot(groupPrefix, file)
 				content.WriteString(fmt.Sprintf("  zstatic.AddByteAsset(\"%s\", \"%s\",%#v)\n", group.LocalPath, localPath, packedData))
 				// result += fmt.Sprintf("  zstatic.AddAsset(\"%s\", \"%s\", \"%s\")\n", groupPrefix, localPath, packedData)
@@ -109,15 +117,17 @@ func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool) (s
 			}
 			fullPath, err := filepath.Abs(filepath.Join(groupPath, asset.AssetPath))
 			if err != nil {
-				return "", err
+				return "", nil, err
 			}
 			// if _, exists := filesProcessed[fullPath]; exists == true {
 			// 	continue
 			// }
-			packedData, err := zstatic.CompressFile(fullPath)
+			packedData, stat, err := opts.packAsset(fullPath)
 			if err != nil && !ignoreErrors {
-				return "", err
+				return "", nil, err
 			}
+			stat.File = asset.Name
+			stats = append(stats, stat)
 			content.WriteString(fmt.Sprintf("  zstatic.AddByteAsset(\".\", \"%s\", %#v)\n", asset.Name, packedData))
 			filesProcessed[fullPath] = true
 		}
@@ -130,5 +140,5 @@ func GeneratePackFileString(assetBundle *ReferencedAssets, ignoreErrors bool) (s
 
 	}
 
-	return result, nil
+	return result, stats, nil
 }
@@ -0,0 +1,105 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sohaha/zlsgo/zfile"
+)
+
+// Result 单个目标的编译结果
+type Result struct {
+	Target   string
+	OS       string
+	Arch     string
+	Output   string
+	Size     int64
+	Duration time.Duration
+	Cached   bool
+	Err      error
+}
+
+// Jobs 返回实际使用的并发数，未指定时使用 CPU 核数
+func Jobs(jobs, total int) int {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > total {
+		jobs = total
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	return jobs
+}
+
+// Parallel 以 jobs 个并发执行 total 个任务，结果顺序与任务顺序一致
+func Parallel(total, jobs int, fn func(i int) Result) []Result {
+	results := make([]Result, total)
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < Jobs(jobs, total); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				start := time.Now()
+				r := fn(i)
+				r.Duration = time.Since(start)
+				results[i] = r
+			}
+		}()
+	}
+	for i := 0; i < total; i++ {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// Stat 更新编译产物的大小，UPX 压缩后需要重新统计
+func (r *Result) Stat() {
+	if r.Err != nil || r.Output == "" {
+		return
+	}
+	if fi, err := os.Stat(zfile.RealPath(r.Output)); err == nil {
+		r.Size = fi.Size()
+	}
+}
+
+// Failed 统计失败的目标数量
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// PrintResults 输出编译结果汇总表
+func PrintResults(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "目标\t状态\t大小\t耗时\t输出")
+	for _, r := range results {
+		status, size, output := "成功", zfile.SizeFormat(r.Size), r.Output
+		if r.Cached {
+			status = "缓存"
+		}
+		if r.Err != nil {
+			status, size = "失败", "-"
+			output = strings.SplitN(strings.TrimSpace(r.Err.Error()), "\n", 2)[0]
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			r.Target, status, size, r.Duration.Round(time.Millisecond), output)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,53 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	var running, peak int32
+	results := Parallel(6, 2, func(i int) Result {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		r := Result{Target: string(rune('a' + i))}
+		if i%3 == 0 {
+			r.Err = errors.New("failed")
+		}
+		return r
+	})
+	if peak > 2 {
+		t.Fatalf("expected at most 2 workers, got %d", peak)
+	}
+	for i, r := range results {
+		if r.Target != string(rune('a'+i)) {
+			t.Fatalf("results out of order: %v", results)
+		}
+	}
+	if n := Failed(results); n != 2 {
+		t.Fatalf("expected 2 failures, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	PrintResults(&buf, results)
+	if lines := strings.Count(buf.String(), "\n"); lines != 7 {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestJobs(t *testing.T) {
+	if Jobs(8, 3) != 3 || Jobs(2, 3) != 2 || Jobs(0, 0) != 1 {
+		t.Fatal("unexpected jobs")
+	}
+}
@@ -0,0 +1,146 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/sohaha/zlsgo/zfile"
+	"github.com/sohaha/zlsgo/zshell"
+	"github.com/sohaha/zlsgo/zutil"
+	"github.com/spf13/viper"
+
+	"github.com/sohaha/zzz/util"
+)
+
+// DefaultProfileFile 默认的编译配置文件
+const DefaultProfileFile = "./zzz-build.yaml"
+
+// Profile 编译配置，字段与 build 命令行参数对应，命令行参数优先
+type Profile struct {
+	OS             string   `mapstructure:"os"`
+	Out            string   `mapstructure:"out"`
+	Output         string   `mapstructure:"output"`
+	SkipDirs       string   `mapstructure:"skipDirs"`
+	Ldflags        string   `mapstructure:"ldflags"`
+	Upx            string   `mapstructure:"upx"`
+	Garble         int      `mapstructure:"garble"`
+	Jobs           int      `mapstructure:"jobs"`
+	CGO            bool     `mapstructure:"cgo"`
+	Pack           bool     `mapstructure:"pack"`
+	Trimpath       bool     `mapstructure:"trimpath"`
+	NoStatic       bool     `mapstructure:"noStatic"`
+	HideWinConsole bool     `mapstructure:"hideWinConsole"`
+	Dist           bool     `mapstructure:"dist"`
+	Version        string   `mapstructure:"version"`
+	DistFiles      []string `mapstructure:"distFiles"`
+	Minify         bool     `mapstructure:"minify"`
+	SBOM           string   `mapstructure:"sbom"`
+	Reproducible   bool     `mapstructure:"reproducible"`
+	Tags           []string `mapstructure:"tags"`
+	Vars           []string `mapstructure:"vars"`
+	Env            []string `mapstructure:"env"`
+	Pre            []string `mapstructure:"pre"`
+	Post           []string `mapstructure:"post"`
+}
+
+// OutputData 输出文件名模板可用的变量
+type OutputData struct {
+	Name    string
+	OS      string
+	Arch    string
+	Variant string
+	Profile string
+}
+
+// LoadProfile 从配置文件读取指定名称的编译配置
+func LoadProfile(path, name string) (*Profile, error) {
+	if !zfile.FileExist(path) {
+		return nil, fmt.Errorf("编译配置文件不存在: %s", path)
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	key := "profiles." + name
+	if !v.IsSet(key) {
+		return nil, fmt.Errorf("编译配置 %s 不存在，可选: %s", name, strings.Join(profileNames(v), ", "))
+	}
+	p := &Profile{}
+	if err := v.UnmarshalKey(key, p); err != nil {
+		return nil, fmt.Errorf("编译配置 %s 错误: %w", name, err)
+	}
+	for _, x := range p.Vars {
+		if !strings.Contains(x, "=") {
+			return nil, fmt.Errorf("编译配置 %s 错误: vars 格式应为 包名.变量=值，实际为 %s", name, x)
+		}
+	}
+	for _, e := range p.Env {
+		if !strings.Contains(e, "=") {
+			return nil, fmt.Errorf("编译配置 %s 错误: env 格式应为 KEY=VALUE，实际为 %s", name, e)
+		}
+	}
+	return p, nil
+}
+
+func profileNames(v *viper.Viper) []string {
+	names := make([]string, 0)
+	for name := range v.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	return names
+}
+
+// OutputName 根据模板生成输出文件名（不含扩展名），模板为空时使用 def
+func OutputName(tpl, def string, data OutputData) (string, error) {
+	if tpl == "" {
+		return def, nil
+	}
+	t, err := template.New("output").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("输出文件名模板错误: %w", err)
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("输出文件名模板错误: %w", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", errors.New("输出文件名模板结果为空")
+	}
+	return name, nil
+}
+
+// RunHooks 依次执行钩子命令，任一失败即返回
+func RunHooks(hooks []string, env []string) error {
+	for _, hook := range hooks {
+		hook = util.OSCommand(hook)
+		if strings.TrimSpace(hook) == "" {
+			continue
+		}
+		util.Log.Printf("钩子: %s\n", hook)
+		code, _, _, err := zshell.ExecCommand(context.Background(), shellCommand(hook), nil, os.Stdout, os.Stderr, func(o *zshell.Options) {
+			o.Env = env
+		})
+		if err != nil {
+			return fmt.Errorf("钩子执行失败 %s: %w", hook, err)
+		}
+		if code != 0 {
+			return fmt.Errorf("钩子执行失败 %s: 退出码 %d", hook, code)
+		}
+	}
+	return nil
+}
+
+func shellCommand(command string) []string {
+	if zutil.IsWin() {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
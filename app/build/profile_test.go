@@ -0,0 +1,64 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "zzz-build.yaml")
+	content := `profiles:
+  release:
+    os: linux/amd64
+    pack: true
+    tags: [prod, netgo]
+    vars:
+      - main.Env=production
+    env:
+      - GOFLAGS=-mod=mod
+  bad:
+    vars:
+      - main.Env
+`
+	if err := os.WriteFile(cfg, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadProfile(cfg, "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.OS != "linux/amd64" || !p.Pack || len(p.Tags) != 2 {
+		t.Fatalf("unexpected profile: %+v", p)
+	}
+	if len(p.Vars) != 1 || p.Vars[0] != "main.Env=production" {
+		t.Fatalf("unexpected vars: %v", p.Vars)
+	}
+
+	if _, err = LoadProfile(cfg, "bad"); err == nil {
+		t.Fatal("expected error for invalid vars")
+	}
+	if _, err = LoadProfile(cfg, "missing"); err == nil {
+		t.Fatal("expected error for missing profile")
+	}
+	if _, err = LoadProfile(filepath.Join(dir, "none.yaml"), "release"); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	data := OutputData{Name: "app", OS: "linux", Arch: "arm64", Profile: "arm"}
+	name, err := OutputName("", "app_linux_arm64", data)
+	if err != nil || name != "app_linux_arm64" {
+		t.Fatalf("default name: %q %v", name, err)
+	}
+	name, err = OutputName("{{.Name}}-{{.Profile}}-{{.OS}}-{{.Arch}}", "", data)
+	if err != nil || name != "app-arm-linux-arm64" {
+		t.Fatalf("template name: %q %v", name, err)
+	}
+	if _, err = OutputName("{{.Unknown}}", "", data); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
@@ -0,0 +1,339 @@
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SourceDateEpoch 返回可复现编译使用的时间，优先读取 SOURCE_DATE_EPOCH，
+// 其次使用最后一次提交的时间
+func SourceDateEpoch() (time.Time, string, error) {
+	if v := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH")); v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("SOURCE_DATE_EPOCH 格式错误: %s", v)
+		}
+		return time.Unix(sec, 0).UTC(), "SOURCE_DATE_EPOCH", nil
+	}
+	if v := gitOutput("log", "-1", "--format=%ct"); v != "" {
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC(), "最后一次提交", nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("无法确定编译时间，请设置 SOURCE_DATE_EPOCH")
+}
+
+// FormatBuildTime 按 BUILD_TIME 的格式输出 UTC 时间，不受本地时区影响
+func FormatBuildTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// wallClockTolerance -X 变量中的时间与当前时间相差在该范围内时，视为编译时写入的时间
+const wallClockTolerance = time.Hour
+
+var (
+	dateTimePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}`)
+	digitsPattern   = regexp.MustCompile(`\d+`)
+)
+
+// StripLdflags 移除 ldflags 中包含当前时间的 -X 变量，
+// 如通过 $(date) 传入的编译时间，返回处理后的参数与被移除的变量，
+// epoch 为可复现编译使用的时间，与其相同的值会保留
+func StripLdflags(ldflags string, now, epoch time.Time) (string, []string) {
+	quoted := strings.HasPrefix(ldflags, `"`) && strings.HasSuffix(ldflags, `"`) && len(ldflags) > 1
+	if quoted {
+		ldflags = ldflags[1 : len(ldflags)-1]
+	}
+	tokens := splitLdflags(ldflags)
+	kept, stripped := make([]string, 0, len(tokens)), make([]string, 0)
+	for i := 0; i < len(tokens); i++ {
+		value, skip := "", 0
+		switch {
+		case tokens[i] == "-X" && i+1 < len(tokens):
+			value, skip = tokens[i+1], 1
+		case strings.HasPrefix(tokens[i], "-X="):
+			value = tokens[i][3:]
+		default:
+			kept = append(kept, tokens[i])
+			continue
+		}
+		if hasWallClock(strings.Trim(value, `'"`), now, epoch) {
+			stripped = append(stripped, strings.Trim(value, `'"`))
+		} else {
+			kept = append(kept, tokens[i:i+skip+1]...)
+		}
+		i += skip
+	}
+	if len(stripped) == 0 {
+		if quoted {
+			return `"` + ldflags + `"`, nil
+		}
+		return ldflags, nil
+	}
+	result := strings.Join(kept, " ")
+	if quoted {
+		result = `"` + result + `"`
+	}
+	return result, stripped
+}
+
+// hasWallClock 判断值中是否包含接近当前时间的时间：2006-01-02 15:04、
+// 2006-01-02T15:04、200601021504[05] 以及 unix 秒，不带时区的格式按本地时间与 UTC 分别解析
+func hasWallClock(value string, now, epoch time.Time) bool {
+	near := func(t time.Time) bool {
+		d := now.Sub(t)
+		return d <= wallClockTolerance && d >= -wallClockTolerance
+	}
+	wall := func(layout, s string, precision time.Duration) bool {
+		for _, loc := range []*time.Location{now.Location(), time.UTC} {
+			t, err := time.ParseInLocation(layout, s, loc)
+			if err == nil && near(t) && !t.Equal(epoch.Truncate(precision)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, m := range dateTimePattern.FindAllString(value, -1) {
+		if wall("2006-01-02 15:04", strings.Replace(m, "T", " ", 1), time.Minute) {
+			return true
+		}
+	}
+	for _, m := range digitsPattern.FindAllString(value, -1) {
+		switch len(m) {
+		case 10:
+			sec, err := strconv.ParseInt(m, 10, 64)
+			if t := time.Unix(sec, 0); err == nil && near(t) && !t.Equal(epoch) {
+				return true
+			}
+		case 12:
+			if wall("200601021504", m, time.Minute) {
+				return true
+			}
+		case 14:
+			if wall("20060102150405", m, time.Second) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitLdflags 按空白拆分 ldflags，保留引号包裹的整体
+func splitLdflags(s string) []string {
+	tokens := make([]string, 0)
+	var (
+		cur   strings.Builder
+		quote rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			cur.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// VerifyResult 可复现校验的结果
+type VerifyResult struct {
+	Target string
+	First  string
+	Second string
+	Err    error
+	// Offset 第一个不同字节的位置，一致时为 -1
+	Offset int64
+	Size   [2]int64
+}
+
+// Same 两次编译的产物是否一致
+func (v *VerifyResult) Same() bool {
+	return v.Err == nil && v.First == v.Second
+}
+
+// CompareBuilds 比较两次编译的产物
+func CompareBuilds(target, a, b string) VerifyResult {
+	r := VerifyResult{Target: target, Offset: -1}
+	if r.First, r.Size[0], r.Err = fileSHA256(a); r.Err != nil {
+		return r
+	}
+	if r.Second, r.Size[1], r.Err = fileSHA256(b); r.Err != nil {
+		return r
+	}
+	if r.First != r.Second {
+		r.Offset, r.Err = firstDiff(a, b)
+	}
+	return r
+}
+
+func firstDiff(a, b string) (int64, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return -1, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return -1, err
+	}
+	defer fb.Close()
+	ra, rb := bufio.NewReader(fa), bufio.NewReader(fb)
+	for offset := int64(0); ; offset++ {
+		ca, errA := ra.ReadByte()
+		cb, errB := rb.ReadByte()
+		if errA == io.EOF || errB == io.EOF || ca != cb {
+			return offset, nil
+		}
+		if errA != nil {
+			return -1, errA
+		}
+		if errB != nil {
+			return -1, errB
+		}
+	}
+}
+
+// VerifyOutput 将编译命令中的输出文件改到 dir 目录下
+func VerifyOutput(cmds []string, dir string) ([]string, string) {
+	result := make([]string, len(cmds))
+	copy(result, cmds)
+	out := ""
+	for i, v := range result {
+		switch {
+		case strings.HasPrefix(v, "-o="):
+			out = filepath.Join(dir, filepath.Base(v[3:]))
+			result[i] = "-o=" + filepath.ToSlash(out)
+		case v == "-o" && i+1 < len(result):
+			out = filepath.Join(dir, filepath.Base(result[i+1]))
+			result[i+1] = filepath.ToSlash(out)
+		}
+	}
+	return result, out
+}
+
+// CopySource 将 dir 所在模块（向上查找 go.mod）的源码复制到 dst，跳过 .git，
+// 返回 dir 在副本中对应的目录，用于在不同路径下重新编译
+func CopySource(dir, dst string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	root := dir
+	for p := dir; ; {
+		if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+			root = p
+			break
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || path == dst) {
+			return filepath.SkipDir
+		}
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, name)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			return os.MkdirAll(target, mode.Perm()|0o700)
+		case mode&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case mode.IsRegular():
+			return copySourceFile(path, target, mode.Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("复制源码失败: %w", err)
+	}
+	return filepath.Join(dst, rel), nil
+}
+
+func copySourceFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// PrintVerify 输出可复现校验的结果
+func PrintVerify(w io.Writer, results []VerifyResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "目标\t状态\tSHA256\t差异")
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			_, _ = fmt.Fprintf(tw, "%s\t失败\t\t%v\n", r.Target, r.Err)
+		case r.Same():
+			_, _ = fmt.Fprintf(tw, "%s\t一致\t%s\t\n", r.Target, r.First)
+		default:
+			_, _ = fmt.Fprintf(tw, "%s\t不一致\t%s / %s\t第 %d 字节起不同，大小 %d / %d\n",
+				r.Target, short(r.First), short(r.Second), r.Offset, r.Size[0], r.Size[1])
+		}
+	}
+	_ = tw.Flush()
+}
+
+func short(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
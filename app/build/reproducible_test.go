@@ -0,0 +1,119 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	epoch, source, err := SourceDateEpoch()
+	if err != nil || source != "SOURCE_DATE_EPOCH" || epoch.Unix() != 1700000000 {
+		t.Fatalf("unexpected epoch: %v %s %v", epoch, source, err)
+	}
+	if FormatBuildTime(epoch) != "2023-11-14 22:13:20" {
+		t.Fatalf("unexpected build time: %s", FormatBuildTime(epoch))
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "abc")
+	if _, _, err = SourceDateEpoch(); err == nil {
+		t.Fatal("expected error for invalid SOURCE_DATE_EPOCH")
+	}
+}
+
+func TestStripLdflags(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	epoch := time.Unix(1700000000, 0)
+	ldflags := `" -s -w -X 'main.BUILD_TIME=2023-11-14 22:13:20' -X 'main.Date=2024-05-06 07:08:30' -X=main.Stamp=1714979289 -X main.Version=v1"`
+	got, stripped := StripLdflags(ldflags, now, epoch)
+	want := `"-s -w -X 'main.BUILD_TIME=2023-11-14 22:13:20' -X main.Version=v1"`
+	if got != want {
+		t.Fatalf("StripLdflags() = %s, want %s", got, want)
+	}
+	if len(stripped) != 2 || stripped[0] != "main.Date=2024-05-06 07:08:30" || stripped[1] != "main.Stamp=1714979289" {
+		t.Fatalf("unexpected stripped: %v", stripped)
+	}
+	if got, stripped = StripLdflags("-X main.Version=v1", now, epoch); got != "-X main.Version=v1" || len(stripped) != 0 {
+		t.Fatalf("unexpected result: %s %v", got, stripped)
+	}
+
+	// 几分钟前生成的时间也会被移除，只是前缀相同的编号和较早的时间保留
+	ldflags = `-X main.Stamp=1714978989 -X main.Compact=202405060703 -X main.Build=17149792 -X main.Old=1600000000`
+	got, stripped = StripLdflags(ldflags, now, epoch)
+	if got != "-X main.Build=17149792 -X main.Old=1600000000" {
+		t.Fatalf("unexpected result: %s", got)
+	}
+	if len(stripped) != 2 || stripped[0] != "main.Stamp=1714978989" || stripped[1] != "main.Compact=202405060703" {
+		t.Fatalf("unexpected stripped: %v", stripped)
+	}
+}
+
+func TestVerifyOutput(t *testing.T) {
+	cmds, out := VerifyOutput([]string{"go", "build", "-o=dist/app_linux_amd64"}, "/tmp/a")
+	if out != filepath.Join("/tmp/a", "app_linux_amd64") || cmds[2] != "-o="+filepath.ToSlash(out) {
+		t.Fatalf("unexpected command: %v %s", cmds, out)
+	}
+	cmds, out = VerifyOutput([]string{"go", "build", "-o", "app"}, "/tmp/b")
+	if out != filepath.Join("/tmp/b", "app") || cmds[3] != filepath.ToSlash(out) {
+		t.Fatalf("unexpected command: %v %s", cmds, out)
+	}
+}
+
+func TestCompareBuilds(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a": "hello world", "b": "hello world", "c": "hello there"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r := CompareBuilds("linux/amd64", filepath.Join(dir, "a"), filepath.Join(dir, "b")); !r.Same() || r.Offset != -1 {
+		t.Fatalf("expected same result: %+v", r)
+	}
+	r := CompareBuilds("linux/amd64", filepath.Join(dir, "a"), filepath.Join(dir, "c"))
+	if r.Same() || r.Offset != 6 || r.Err != nil {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if r = CompareBuilds("linux/amd64", filepath.Join(dir, "a"), filepath.Join(dir, "missing")); r.Err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestCopySource(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":           "module example.com/app\n",
+		"cmd/app/main.go":  "package main\n",
+		"assets/site.css":  "body{}",
+		".git/HEAD":        "ref: refs/heads/main\n",
+		"cmd/app/.git/bad": "x",
+	} {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(t.TempDir(), "src")
+	dir, err := CopySource(filepath.Join(root, "cmd", "app"), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(dst, "cmd", "app") {
+		t.Fatalf("unexpected work dir: %s", dir)
+	}
+	for _, name := range []string{"go.mod", "cmd/app/main.go", "assets/site.css"} {
+		if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("%s not copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{".git", "cmd/app/.git"} {
+		if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Fatalf("%s should be skipped: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package build
+
+import (
+	"crypto/rand"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zzz/util"
+)
+
+// SBOM 格式
+const (
+	SBOMCycloneDX = "cyclonedx"
+	SBOMSPDX      = "spdx"
+)
+
+// Provenance 编译来源信息
+type Provenance struct {
+	Artifact  string            `json:"artifact"`
+	SHA256    string            `json:"sha256"`
+	Size      int64             `json:"size"`
+	Target    ProvenanceTarget  `json:"target"`
+	Commit    string            `json:"commit"`
+	GoVersion string            `json:"goVersion"`
+	Module    string            `json:"module"`
+	Flags     []string          `json:"flags"`
+	Env       []string          `json:"env"`
+	Settings  map[string]string `json:"settings"`
+	Builder   string            `json:"builder"`
+	BuiltAt   string            `json:"builtAt"`
+}
+
+// ProvenanceTarget 编译目标
+type ProvenanceTarget struct {
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	Variant string `json:"variant,omitempty"`
+}
+
+// CheckSBOMFormat 校验 SBOM 格式
+func CheckSBOMFormat(format string) error {
+	switch format {
+	case SBOMCycloneDX, SBOMSPDX:
+		return nil
+	}
+	return fmt.Errorf("不支持的 SBOM 格式 %s，可选: %s, %s", format, SBOMCycloneDX, SBOMSPDX)
+}
+
+// ReadBuildInfo 读取产物内嵌的模块信息（与 go version -m 一致），
+// UPX 压缩后无法读取，需要在压缩前调用
+func ReadBuildInfo(binary string) (*buildinfo.BuildInfo, error) {
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 的模块信息失败: %w", binary, err)
+	}
+	return info, nil
+}
+
+// WriteSBOM 根据模块信息在产物旁生成 .cdx.json 或 .spdx.json
+func WriteSBOM(binary string, info *buildinfo.BuildInfo, format string, now time.Time) (string, error) {
+	var (
+		doc  interface{}
+		file string
+	)
+	switch format {
+	case SBOMCycloneDX:
+		doc, file = cycloneDX(info, now), binary+".cdx.json"
+	case SBOMSPDX:
+		doc, file = spdx(info, binary, now), binary+".spdx.json"
+	default:
+		return "", CheckSBOMFormat(format)
+	}
+	return file, writeJSON(file, doc)
+}
+
+// WriteProvenance 在产物旁生成 .provenance.json，摘要按最终产物计算，info 为 nil 时不包含模块信息
+func WriteProvenance(binary string, info *buildinfo.BuildInfo, p *Provenance) (string, error) {
+	sum, size, err := fileSHA256(binary)
+	if err != nil {
+		return "", err
+	}
+	p.SHA256, p.Size = sum, size
+	if info != nil {
+		p.Module = info.Main.Path
+		p.Settings = make(map[string]string, len(info.Settings))
+		for _, s := range info.Settings {
+			p.Settings[s.Key] = s.Value
+		}
+	}
+	if p.Builder == "" {
+		p.Builder = "zzz " + util.Version
+	}
+	file := binary + ".provenance.json"
+	return file, writeJSON(file, p)
+}
+
+func writeJSON(file string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+type sbomModule struct {
+	path, version, sum string
+}
+
+func (m sbomModule) purl() string {
+	if m.version == "" || m.version == "(devel)" {
+		return "pkg:golang/" + m.path
+	}
+	return "pkg:golang/" + m.path + "@" + m.version
+}
+
+func sbomModules(info *buildinfo.BuildInfo) (main sbomModule, deps []sbomModule) {
+	main = sbomModule{path: info.Main.Path, version: info.Main.Version}
+	if main.path == "" {
+		main.path = info.Path
+	}
+	for _, d := range info.Deps {
+		if d.Replace != nil {
+			d = d.Replace
+		}
+		deps = append(deps, sbomModule{path: d.Path, version: d.Version, sum: d.Sum})
+	}
+	return
+}
+
+func cycloneDX(info *buildinfo.BuildInfo, now time.Time) map[string]interface{} {
+	main, deps := sbomModules(info)
+	components := make([]map[string]interface{}, 0, len(deps))
+	refs := make([]string, 0, len(deps))
+	for _, d := range deps {
+		c := map[string]interface{}{
+			"type":    "library",
+			"bom-ref": d.purl(),
+			"name":    d.path,
+			"version": d.version,
+			"purl":    d.purl(),
+		}
+		if d.sum != "" {
+			c["properties"] = []map[string]string{{"name": "go:sum", "value": d.sum}}
+		}
+		components = append(components, c)
+		refs = append(refs, d.purl())
+	}
+	return map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": now.UTC().Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []map[string]string{{"type": "application", "name": "zzz", "version": util.Version}},
+			},
+			"component": map[string]interface{}{
+				"type":       "application",
+				"bom-ref":    main.purl(),
+				"name":       main.path,
+				"version":    main.version,
+				"purl":       main.purl(),
+				"properties": buildProperties(info),
+			},
+		},
+		"components":   components,
+		"dependencies": []map[string]interface{}{{"ref": main.purl(), "dependsOn": refs}},
+	}
+}
+
+func spdx(info *buildinfo.BuildInfo, binary string, now time.Time) map[string]interface{} {
+	main, deps := sbomModules(info)
+	pkg := func(id string, m sbomModule) map[string]interface{} {
+		return map[string]interface{}{
+			"name":             m.path,
+			"SPDXID":           id,
+			"versionInfo":      m.version,
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed":    false,
+			"externalRefs": []map[string]string{{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  m.purl(),
+			}},
+		}
+	}
+	packages := []map[string]interface{}{pkg("SPDXRef-Package-main", main)}
+	relationships := []map[string]string{{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-Package-main",
+	}}
+	for i, d := range deps {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		packages = append(packages, pkg(id, d))
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-Package-main",
+			"relationshipType":   "DEPENDS_ON",
+			"relatedSpdxElement": id,
+		})
+	}
+	return map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              binary,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + strings.ReplaceAll(main.path, "/", "-") + "-" + newUUID(),
+		"creationInfo": map[string]interface{}{
+			"created":  now.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: zzz-" + util.Version},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+func buildProperties(info *buildinfo.BuildInfo) []map[string]string {
+	props := []map[string]string{{"name": "go:version", "value": info.GoVersion}}
+	for _, s := range info.Settings {
+		props = append(props, map[string]string{"name": "go:build:" + s.Key, "value": s.Value})
+	}
+	return props
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,134 @@
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func copyTestBinary(t *testing.T) string {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(t.TempDir(), "app")
+	if err = os.WriteFile(bin, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestWriteSBOM(t *testing.T) {
+	bin := copyTestBinary(t)
+	info, err := ReadBuildInfo(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for format, ext := range map[string]string{SBOMCycloneDX: ".cdx.json", SBOMSPDX: ".spdx.json"} {
+		file, err := WriteSBOM(bin, info, format, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file != bin+ext {
+			t.Fatalf("unexpected sbom file: %s", file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		if err = json.Unmarshal(data, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if format == SBOMCycloneDX && doc["bomFormat"] != "CycloneDX" {
+			t.Fatalf("unexpected cyclonedx document: %s", data)
+		}
+		if format == SBOMSPDX && doc["spdxVersion"] != "SPDX-2.3" {
+			t.Fatalf("unexpected spdx document: %s", data)
+		}
+	}
+	if _, err := WriteSBOM(bin, info, "xml", time.Now()); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestWriteProvenance(t *testing.T) {
+	bin := copyTestBinary(t)
+	info, err := ReadBuildInfo(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := WriteProvenance(bin, info, &Provenance{
+		Artifact: "app",
+		Target:   ProvenanceTarget{OS: "linux", Arch: "amd64"},
+		Commit:   "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Provenance
+	if err = json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.SHA256 == "" || p.Size == 0 || p.Commit != "abc" || p.Module == "" || p.Builder == "" {
+		t.Fatalf("unexpected provenance: %s", data)
+	}
+}
+
+func TestSBOMAfterUPX(t *testing.T) {
+	bin := copyTestBinary(t)
+	info, err := ReadBuildInfo(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exec.Command("upx", "--version").Run() == nil {
+		if err = RunUPX(bin, "1"); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		// 没有安装 upx 时用不含模块信息的内容模拟压缩后的产物
+		if err = os.WriteFile(bin, []byte("UPX!packed"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = ReadBuildInfo(bin); err == nil {
+		t.Fatal("expected packed binary to hide build info")
+	}
+
+	if _, err = WriteSBOM(bin, info, SBOMCycloneDX, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	file, err := WriteProvenance(bin, info, &Provenance{Artifact: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p Provenance
+	if err = json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	sum, size, err := fileSHA256(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.SHA256 != sum || p.Size != size {
+		t.Fatalf("provenance digest should match the packed artifact: %s", data)
+	}
+	if p.Module == "" || len(p.Settings) == 0 {
+		t.Fatalf("provenance lost module info: %s", data)
+	}
+}
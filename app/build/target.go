@@ -0,0 +1,241 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DistTarget go tool dist list 支持的目标
+type DistTarget struct {
+	GOOS         string
+	GOARCH       string
+	CgoSupported bool
+}
+
+var dist struct {
+	once    sync.Once
+	targets []DistTarget
+	err     error
+}
+
+// DistList 返回当前 Go 版本支持的所有目标
+func DistList() ([]DistTarget, error) {
+	dist.once.Do(func() {
+		out, err := exec.Command("go", "tool", "dist", "list", "-json").Output()
+		if err != nil {
+			dist.err = fmt.Errorf("获取 go tool dist list 失败: %w", err)
+			return
+		}
+		dist.err = json.Unmarshal(out, &dist.targets)
+	})
+	return dist.targets, dist.err
+}
+
+// variantEnv 各架构的子版本环境变量
+var variantEnv = map[string]string{
+	"386":      "GO386",
+	"amd64":    "GOAMD64",
+	"arm":      "GOARM",
+	"arm64":    "GOARM64",
+	"mips":     "GOMIPS",
+	"mipsle":   "GOMIPS",
+	"mips64":   "GOMIPS64",
+	"mips64le": "GOMIPS64",
+	"ppc64":    "GOPPC64",
+	"ppc64le":  "GOPPC64",
+	"riscv64":  "GORISCV64",
+	"wasm":     "GOWASM",
+}
+
+// variantValues 可校验的子版本取值
+var variantValues = map[string][]string{
+	"GO386":    {"sse2", "softfloat"},
+	"GOAMD64":  {"v1", "v2", "v3", "v4"},
+	"GOARM":    {"5", "6", "7"},
+	"GOMIPS":   {"hardfloat", "softfloat"},
+	"GOMIPS64": {"hardfloat", "softfloat"},
+}
+
+// defaultArchs 只指定系统时默认编译的架构
+var defaultArchs = map[string][]string{
+	"windows": {"386", "amd64"},
+	"linux":   {"386", "amd64"},
+	"darwin":  {"arm64"},
+	"android": {"arm64"},
+	"freebsd": {"amd64", "arm64"},
+	"openbsd": {"amd64", "arm64"},
+	"js":      {"wasm"},
+	"wasip1":  {"wasm"},
+}
+
+func targetOS(goos string) string {
+	switch goos {
+	case "w", "win", "windows":
+		return "windows"
+	case "l", "linux":
+		return "linux"
+	case "d", "darwin", "mac", "m", "macos":
+		return "darwin"
+	case "android", "a":
+		return "android"
+	case "f", "freebsd":
+		return "freebsd"
+	case "o", "openbsd":
+		return "openbsd"
+	case "wasm", "js":
+		return "js"
+	case "wasi", "wasip1":
+		return "wasip1"
+	}
+	return goos
+}
+
+// TargetsCommad 解析单个目标，格式为 系统[/架构[/子版本]]，
+// 架构支持 * 通配符、32/64 简写与 armv7 这类带子版本的写法，
+// 结果会根据 go tool dist list 校验，开启 CGO 时同时校验是否可交叉编译
+func TargetsCommad(target string, cgo bool) ([]OSData, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, nil
+	}
+	list, err := DistList()
+	if err != nil {
+		return nil, err
+	}
+
+	t := strings.Split(target, "/")
+	if len(t) > 3 {
+		return nil, fmt.Errorf("目标格式错误 %s，应为 系统/架构/子版本", target)
+	}
+	goos, goarch, variant := targetOS(t[0]), "", ""
+	if len(t) > 1 {
+		goarch = t[1]
+	}
+	if len(t) > 2 {
+		variant = t[2]
+	}
+	if (goos == "js" || goos == "wasip1") && goarch == "" {
+		goarch = "wasm"
+	}
+	switch {
+	case goarch == "32":
+		goarch = "386"
+	case goarch == "64" && goos == "darwin":
+		goarch = "arm64"
+	case goarch == "64":
+		goarch = "amd64"
+	case strings.HasPrefix(goarch, "armv") && len(goarch) == 5 && variant == "":
+		goarch, variant = "arm", goarch[4:]
+	}
+
+	pairs := make([]DistTarget, 0)
+	for _, d := range list {
+		if (goos == "*" || d.GOOS == goos) && (goarch == "*" || d.GOARCH == goarch) {
+			pairs = append(pairs, d)
+		}
+	}
+	if goarch == "" {
+		archs, ok := defaultArchs[goos]
+		if !ok {
+			return nil, fmt.Errorf("没有为 %s 预设 GOARCH，请补全，例如: %[1]s/amd64 或 %[1]s/*", goos)
+		}
+		for _, d := range list {
+			if d.GOOS == goos && containsString(archs, d.GOARCH) {
+				pairs = append(pairs, d)
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, unsupportedTarget(list, goos, goarch)
+	}
+
+	result := make([]OSData, 0, len(pairs))
+	for _, d := range pairs {
+		if variant != "" {
+			if err := checkVariant(d.GOARCH, variant); err != nil {
+				return nil, fmt.Errorf("目标 %s 错误: %w", target, err)
+			}
+		}
+		if cgo {
+			if err := checkCGO(d); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, OSData{Goos: d.GOOS, Goarch: d.GOARCH, Variant: variant})
+	}
+	return result, nil
+}
+
+func unsupportedTarget(list []DistTarget, goos, goarch string) error {
+	archs := make([]string, 0)
+	for _, d := range list {
+		if d.GOOS == goos {
+			archs = append(archs, d.GOARCH)
+		}
+	}
+	if len(archs) == 0 {
+		return fmt.Errorf("不支持的系统 %s，可通过 go tool dist list 查看", goos)
+	}
+	sort.Strings(archs)
+	return fmt.Errorf("不支持的目标 %s/%s，可用架构: %s", goos, goarch, strings.Join(archs, ", "))
+}
+
+func checkVariant(goarch, variant string) error {
+	env, ok := variantEnv[goarch]
+	if !ok {
+		return fmt.Errorf("%s 不支持指定子版本", goarch)
+	}
+	if values, ok := variantValues[env]; ok && !containsString(values, variant) {
+		return fmt.Errorf("%s=%s 无效，可选: %s", env, variant, strings.Join(values, ", "))
+	}
+	return nil
+}
+
+// checkCGO 校验开启 CGO 时目标是否可以编译
+func checkCGO(d DistTarget) error {
+	if !d.CgoSupported {
+		return fmt.Errorf("%s/%s 不支持 CGO", d.GOOS, d.GOARCH)
+	}
+	if d.GOOS == runtime.GOOS && d.GOARCH == runtime.GOARCH {
+		return nil
+	}
+	if zigTarget(d.GOOS, d.GOARCH) == "" {
+		return fmt.Errorf("开启 CGO 时暂不支持交叉编译到 %s/%s", d.GOOS, d.GOARCH)
+	}
+	return nil
+}
+
+// VariantEnv 返回目标的子版本环境变量
+func (o OSData) VariantEnv() string {
+	if o.Variant == "" {
+		return ""
+	}
+	return variantEnv[o.Goarch] + "=" + o.Variant
+}
+
+// ArchLabel 用于输出文件名与展示的架构名，包含子版本
+func (o OSData) ArchLabel() string {
+	switch {
+	case o.Variant == "":
+		return o.Goarch
+	case o.Variant[0] >= '0' && o.Variant[0] <= '9':
+		return o.Goarch + "v" + o.Variant
+	case o.Variant[0] == 'v':
+		return o.Goarch + o.Variant
+	}
+	return o.Goarch + "_" + o.Variant
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
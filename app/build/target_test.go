@@ -0,0 +1,71 @@
+package build
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func targetNames(targets []OSData) string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.Goos+"/"+t.ArchLabel())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestTargetsCommad(t *testing.T) {
+	if _, err := DistList(); err != nil {
+		t.Skip(err)
+	}
+	for target, want := range map[string]string{
+		"win":                  "windows/386,windows/amd64",
+		"mac/64":               "darwin/arm64",
+		"l/32":                 "linux/386",
+		"linux/armv7":          "linux/armv7",
+		"linux/amd64/v3":       "linux/amd64v3",
+		"linux/mips/softfloat": "linux/mips_softfloat",
+		"freebsd":              "freebsd/amd64,freebsd/arm64",
+		"wasm":                 "js/wasm",
+		"wasip1":               "wasip1/wasm",
+	} {
+		targets, err := TargetsCommad(target, false)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if got := targetNames(targets); got != want {
+			t.Errorf("%s: got %s, want %s", target, got, want)
+		}
+	}
+
+	all, err := TargetsCommad("linux/*", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) < 5 || !strings.Contains(targetNames(all), "linux/riscv64") {
+		t.Fatalf("unexpected wildcard expansion: %s", targetNames(all))
+	}
+
+	for _, target := range []string{"linux/foo", "plan9", "nope/amd64", "linux/arm/9", "windows/386/x/y", "linux/s390x/v1"} {
+		if _, err = TargetsCommad(target, false); err == nil {
+			t.Errorf("%s: expected error", target)
+		}
+	}
+}
+
+func TestTargetsCommadCGO(t *testing.T) {
+	if _, err := DistList(); err != nil {
+		t.Skip(err)
+	}
+	if _, err := TargetsCommad("linux/amd64", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TargetsCommad("js/wasm", true); err == nil {
+		t.Fatal("expected error for target without cgo support")
+	}
+	if runtime.GOOS != "freebsd" {
+		if _, err := TargetsCommad("freebsd/amd64", true); err == nil {
+			t.Fatal("expected error for cgo cross compile without zig target")
+		}
+	}
+}
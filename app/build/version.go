@@ -0,0 +1,162 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// VersionInfo 版本变量模板可用的数据
+type VersionInfo struct {
+	// Version 由 git describe 推导的版本号，保留标签的 v 前缀
+	Version string
+	// Semver 不含 v 前缀的语义化版本号
+	Semver string
+	// Tag 最近的 git 标签
+	Tag         string
+	Commit      string
+	ShortCommit string
+	// Commits 最近标签之后的提交数
+	Commits int
+	Dirty   bool
+	// Date 编译时间，RFC3339 格式
+	Date string
+	// Timestamp 编译时间的 Unix 时间戳
+	Timestamp int64
+	Env       map[string]string
+}
+
+var (
+	describeRe = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]+)$`)
+	semverRe   = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// GetVersionInfo 读取当前仓库的版本信息
+func GetVersionInfo(now time.Time) *VersionInfo {
+	info := ParseDescribe(gitOutput("describe", "--tags", "--long", "--dirty"))
+	if info.Commit = gitOutput("rev-parse", "HEAD"); info.Commit == "" {
+		info.Commit = "未知"
+	} else if info.Tag == "" {
+		info.ShortCommit = info.Commit[:7]
+		info.Dirty = gitOutput("status", "--porcelain", "--untracked-files=no") != ""
+		// 没有标签时使用 v0.0.0 的预发布版本
+		info.Version = "v0.0.0-dev+g" + info.ShortCommit
+		if info.Dirty {
+			info.Version += ".dirty"
+		}
+	}
+	info.Semver = strings.TrimPrefix(info.Version, "v")
+	info.Date = now.UTC().Format(time.RFC3339)
+	info.Timestamp = now.Unix()
+	info.Env = make(map[string]string)
+	for _, e := range os.Environ() {
+		if i := strings.Index(e, "="); i > 0 {
+			info.Env[e[:i]] = e[i+1:]
+		}
+	}
+	return info
+}
+
+// ParseDescribe 解析 git describe --tags --long --dirty 的输出，
+// 正好位于标签上时版本号即标签，否则推导出比标签更高的预发布版本，
+// 如 v1.2.3 之后 5 个提交为 v1.2.4-dev.5+gabc1234，
+// v1.2.3-rc.1 之后为 v1.2.3-rc.1.dev.5+gabc1234，有未提交的修改时追加 dirty
+func ParseDescribe(describe string) *VersionInfo {
+	info := &VersionInfo{Version: "dev"}
+	describe = strings.TrimSpace(describe)
+	if strings.HasSuffix(describe, "-dirty") {
+		info.Dirty = true
+		describe = strings.TrimSuffix(describe, "-dirty")
+	}
+	m := describeRe.FindStringSubmatch(describe)
+	if m == nil {
+		return info
+	}
+	info.Tag, info.ShortCommit = m[1], m[3]
+	info.Commits, _ = strconv.Atoi(m[2])
+	info.Version = devVersion(info.Tag, info.Commits, info.ShortCommit, info.Dirty)
+	return info
+}
+
+func devVersion(tag string, commits int, commit string, dirty bool) string {
+	m := semverRe.FindStringSubmatch(tag)
+	if m == nil {
+		// 非语义化版本的标签原样使用
+		v := tag
+		if commits > 0 {
+			v += "-" + strconv.Itoa(commits) + "-g" + commit
+		}
+		if dirty {
+			v += "-dirty"
+		}
+		return v
+	}
+	base, pre := m[1]+m[2]+"."+m[3]+"."+m[4], m[5]
+	if commits == 0 {
+		v := base
+		if pre != "" {
+			v += "-" + pre
+		}
+		if dirty {
+			v += "+dirty"
+		}
+		return v
+	}
+	if pre == "" {
+		patch, _ := strconv.Atoi(m[4])
+		base = m[1] + m[2] + "." + m[3] + "." + strconv.Itoa(patch+1)
+		pre = "dev." + strconv.Itoa(commits)
+	} else {
+		pre += ".dev." + strconv.Itoa(commits)
+	}
+	v := base + "-" + pre + "+g" + commit
+	if dirty {
+		v += ".dirty"
+	}
+	return v
+}
+
+// RenderVars 渲染包变量模板，格式为 包名.变量=模板，
+// 模板可用 {{.Version}} {{.Commit}} {{.Date}} {{.Env.NAME}} 等变量
+func RenderVars(vars []string, info *VersionInfo) ([]string, error) {
+	result := make([]string, 0, len(vars))
+	for _, x := range vars {
+		i := strings.Index(x, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("vars 格式应为 包名.变量=值，实际为 %s", x)
+		}
+		name, value := x[:i], x[i+1:]
+		if strings.Contains(value, "{{") {
+			t, err := template.New(name).Option("missingkey=zero").Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("变量 %s 模板错误: %w", name, err)
+			}
+			var buf bytes.Buffer
+			if err = t.Execute(&buf, info); err != nil {
+				return nil, fmt.Errorf("变量 %s 模板错误: %w", name, err)
+			}
+			value = buf.String()
+		}
+		if strings.Contains(value, "'") {
+			return nil, fmt.Errorf("变量 %s 的值不能包含单引号", name)
+		}
+		result = append(result, name+"="+value)
+	}
+	return result, nil
+}
+
+func gitOutput(args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = "."
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
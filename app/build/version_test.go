@@ -0,0 +1,63 @@
+package build
+
+import "testing"
+
+func TestParseDescribe(t *testing.T) {
+	tests := []struct {
+		describe, version string
+		dirty             bool
+	}{
+		{"v1.2.3-0-gabc1234", "v1.2.3", false},
+		{"v1.2.3-0-gabc1234-dirty", "v1.2.3+dirty", true},
+		{"v1.2.3-5-gabc1234", "v1.2.4-dev.5+gabc1234", false},
+		{"v1.2.3-5-gabc1234-dirty", "v1.2.4-dev.5+gabc1234.dirty", true},
+		{"1.0.0-rc.1-2-gabc1234", "1.0.0-rc.1.dev.2+gabc1234", false},
+		{"release-2-gabc1234", "release-2-gabc1234", false},
+		{"release-0-gabc1234", "release", false},
+		{"", "dev", false},
+	}
+	for _, tt := range tests {
+		info := ParseDescribe(tt.describe)
+		if info.Version != tt.version || info.Dirty != tt.dirty {
+			t.Errorf("ParseDescribe(%q) = %s %v, want %s %v", tt.describe, info.Version, info.Dirty, tt.version, tt.dirty)
+		}
+	}
+	if info := ParseDescribe("v1.2.3-5-gabc1234"); info.Tag != "v1.2.3" || info.Commits != 5 || info.ShortCommit != "abc1234" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+}
+
+func TestRenderVars(t *testing.T) {
+	info := &VersionInfo{
+		Version: "v1.0.0",
+		Commit:  "abcdef",
+		Date:    "2024-01-01T00:00:00Z",
+		Env:     map[string]string{"STAGE": "prod"},
+	}
+	vars, err := RenderVars([]string{
+		"main.Version={{.Version}}",
+		"example.com/app/version.Info={{.Commit}}@{{.Date}}",
+		"main.Stage={{.Env.STAGE}}{{.Env.MISSING}}",
+		"main.Plain=a=b",
+	}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"main.Version=v1.0.0",
+		"example.com/app/version.Info=abcdef@2024-01-01T00:00:00Z",
+		"main.Stage=prod",
+		"main.Plain=a=b",
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("vars[%d] = %s, want %s", i, vars[i], want[i])
+		}
+	}
+
+	for _, x := range []string{"main.Version", "main.Version={{.Unknown}}", "main.Version={{.Version", "main.Q='"} {
+		if _, err = RenderVars([]string{x}, info); err == nil {
+			t.Errorf("expected error for %s", x)
+		}
+	}
+}
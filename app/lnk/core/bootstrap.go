@@ -0,0 +1,417 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// BootstrapManifest 声明式引导清单，位于仓库根目录，优先于 bootstrap.sh
+	BootstrapManifest = "bootstrap.yaml"
+
+	bootstrapStateFilename = "bootstrap.json"
+)
+
+// 引导步骤的执行计划
+const (
+	BootstrapRun  = "run"
+	BootstrapSkip = "skip"
+)
+
+// BootstrapStep 引导清单中的一个步骤，包列表按包管理器区分，只会安装缺失的包；
+// 设置 check 时命令执行成功即跳过该步骤，否则 run 只在首次或步骤变更后执行
+type BootstrapStep struct {
+	Name   string   `mapstructure:"name" json:"name,omitempty"`
+	OS     []string `mapstructure:"os" json:"os,omitempty"`
+	Hosts  []string `mapstructure:"hosts" json:"hosts,omitempty"`
+	Check  string   `mapstructure:"check" json:"check,omitempty"`
+	Apt    []string `mapstructure:"apt" json:"apt,omitempty"`
+	Brew   []string `mapstructure:"brew" json:"brew,omitempty"`
+	Pacman []string `mapstructure:"pacman" json:"pacman,omitempty"`
+	Go     []string `mapstructure:"go" json:"go,omitempty"`
+	Npm    []string `mapstructure:"npm" json:"npm,omitempty"`
+	Run    string   `mapstructure:"run" json:"run,omitempty"`
+}
+
+type BootstrapManifestFile struct {
+	Steps []BootstrapStep `mapstructure:"steps"`
+}
+
+// BootstrapAction 单个步骤的执行计划
+type BootstrapAction struct {
+	Step     string
+	Action   string
+	Reason   string
+	Commands []string
+	LastRun  time.Time
+
+	key  string
+	hash string
+	argv [][]string
+}
+
+type bootstrapRecord struct {
+	Hash  string    `json:"hash"`
+	RanAt time.Time `json:"ran_at"`
+}
+
+type packageManager struct {
+	name      string
+	binary    string
+	sudo      bool
+	installed func(pkg string) bool
+	install   func(pkgs []string) [][]string
+}
+
+func commandSucceeds(name string, args ...string) bool {
+	cmd := exec.Command(name, args...)
+	return cmd.Run() == nil
+}
+
+var goMajorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// goBinaryName 根据 go install 的包路径推断生成的命令名
+func goBinaryName(pkg string) string {
+	pkg = strings.SplitN(pkg, "@", 2)[0]
+	name := path.Base(pkg)
+	if goMajorSuffix.MatchString(name) {
+		name = path.Base(path.Dir(pkg))
+	}
+	return name
+}
+
+func goBinInstalled(pkg string) bool {
+	name := goBinaryName(pkg)
+	if _, err := exec.LookPath(name); err == nil {
+		return true
+	}
+	binDir := os.Getenv("GOBIN")
+	if binDir == "" {
+		gopath := os.Getenv("GOPATH")
+		if gopath == "" {
+			home, _ := os.UserHomeDir()
+			gopath = filepath.Join(home, "go")
+		}
+		binDir = filepath.Join(gopath, "bin")
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	_, err := os.Stat(filepath.Join(binDir, name))
+	return err == nil
+}
+
+func npmPackageName(pkg string) string {
+	if i := strings.LastIndex(pkg, "@"); i > 0 {
+		return pkg[:i]
+	}
+	return pkg
+}
+
+var packageManagers = []packageManager{
+	{
+		name: "apt", binary: "apt-get", sudo: true,
+		installed: func(pkg string) bool { return commandSucceeds("dpkg", "-s", pkg) },
+		install: func(pkgs []string) [][]string {
+			return [][]string{append([]string{"apt-get", "install", "-y"}, pkgs...)}
+		},
+	},
+	{
+		name: "brew", binary: "brew",
+		installed: func(pkg string) bool { return commandSucceeds("brew", "list", "--versions", pkg) },
+		install: func(pkgs []string) [][]string {
+			return [][]string{append([]string{"brew", "install"}, pkgs...)}
+		},
+	},
+	{
+		name: "pacman", binary: "pacman", sudo: true,
+		installed: func(pkg string) bool { return commandSucceeds("pacman", "-Q", pkg) },
+		install: func(pkgs []string) [][]string {
+			return [][]string{append([]string{"pacman", "-S", "--needed", "--noconfirm"}, pkgs...)}
+		},
+	},
+	{
+		name: "go", binary: "go",
+		installed: goBinInstalled,
+		install: func(pkgs []string) [][]string {
+			// 带版本的 go install 每次只能安装同一模块的包
+			cmds := make([][]string, 0, len(pkgs))
+			for _, p := range pkgs {
+				if !strings.Contains(p, "@") {
+					p += "@latest"
+				}
+				cmds = append(cmds, []string{"go", "install", p})
+			}
+			return cmds
+		},
+	},
+	{
+		name: "npm", binary: "npm",
+		installed: func(pkg string) bool {
+			return commandSucceeds("npm", "ls", "-g", "--depth=0", npmPackageName(pkg))
+		},
+		install: func(pkgs []string) [][]string {
+			return [][]string{append([]string{"npm", "install", "-g"}, pkgs...)}
+		},
+	},
+}
+
+func (s BootstrapStep) packages(manager string) []string {
+	switch manager {
+	case "apt":
+		return s.Apt
+	case "brew":
+		return s.Brew
+	case "pacman":
+		return s.Pacman
+	case "go":
+		return s.Go
+	case "npm":
+		return s.Npm
+	}
+	return nil
+}
+
+func (s BootstrapStep) hash() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func shellCommand(script string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", script}
+	}
+	return []string{"sh", "-c", script}
+}
+
+func displayCommand(argv []string) string {
+	if len(argv) == 3 && (argv[0] == "sh" && argv[1] == "-c" || argv[0] == "cmd" && argv[1] == "/C") {
+		return argv[2]
+	}
+	return strings.Join(argv, " ")
+}
+
+func needSudo() bool {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		return false
+	}
+	_, err := exec.LookPath("sudo")
+	return err == nil
+}
+
+func (l *Lnk) FindBootstrapManifest() (string, error) {
+	manifest := filepath.Join(l.repoPath, BootstrapManifest)
+	if !l.fs.FileExists(manifest) {
+		return "", NewStructuredError(ErrCodeBootstrapNotFound, "引导清单未找到: "+manifest, SeverityWarning).
+			WithSuggestion("在仓库根目录创建 " + BootstrapManifest + " 声明需要安装的软件包与执行的步骤")
+	}
+	return manifest, nil
+}
+
+func (l *Lnk) LoadBootstrapManifest() (*BootstrapManifestFile, error) {
+	file, err := l.FindBootstrapManifest()
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, WrapError(err, ErrCodeBootstrapManifest, "读取引导清单失败", SeverityError).
+			WithContext("file", file)
+	}
+	var manifest BootstrapManifestFile
+	if err := v.Unmarshal(&manifest); err != nil {
+		return nil, WrapError(err, ErrCodeBootstrapManifest, "解析引导清单失败", SeverityError).
+			WithContext("file", file)
+	}
+	for i, s := range manifest.Steps {
+		if s.Run == "" && s.Apt == nil && s.Brew == nil && s.Pacman == nil && s.Go == nil && s.Npm == nil {
+			return nil, NewStructuredError(ErrCodeBootstrapManifest, fmt.Sprintf("第 %d 个步骤没有可执行的内容", i+1), SeverityError).
+				WithContext("file", file).
+				WithSuggestion("每个步骤需要 run 命令或 apt/brew/pacman/go/npm 包列表")
+		}
+	}
+	return &manifest, nil
+}
+
+func (l *Lnk) loadBootstrapState() (map[string]bootstrapRecord, error) {
+	state := make(map[string]bootstrapRecord)
+	if err := l.readState(bootstrapStateFilename, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (l *Lnk) saveBootstrapState(state map[string]bootstrapRecord) error {
+	return l.writeState(bootstrapStateFilename, state)
+}
+
+// bootstrapHosts 用于匹配 hosts 条件的名称：当前主机、组合的各层与系统主机名
+func (l *Lnk) bootstrapHosts() map[string]bool {
+	hosts := map[string]bool{layerName(l.host): true}
+	if layers, err := l.ProfileLayers(); err == nil {
+		for _, name := range layers {
+			hosts[name] = true
+		}
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts[hostname] = true
+	}
+	return hosts
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanBootstrap 生成引导清单的执行计划，会执行步骤的 check 命令与包检查，
+// force 为 true 时忽略已执行记录
+func (l *Lnk) PlanBootstrap(force bool) ([]BootstrapAction, error) {
+	manifest, err := l.LoadBootstrapManifest()
+	if err != nil {
+		return nil, err
+	}
+	state, err := l.loadBootstrapState()
+	if err != nil {
+		return nil, err
+	}
+	hosts := l.bootstrapHosts()
+	sudo := needSudo()
+
+	plan := make([]BootstrapAction, 0, len(manifest.Steps))
+	for i, step := range manifest.Steps {
+		act := BootstrapAction{Step: step.Name, Action: BootstrapSkip, hash: step.hash()}
+		if act.Step == "" {
+			act.Step = fmt.Sprintf("步骤 %d", i+1)
+		}
+		act.key = step.Name
+		if act.key == "" {
+			act.key = "#" + act.hash[:12]
+		}
+		record, ran := state[act.key]
+		act.LastRun = record.RanAt
+
+		if !matchAny(step.OS, func(s string) bool { return s == runtime.GOOS }) {
+			act.Reason = "系统不匹配"
+			plan = append(plan, act)
+			continue
+		}
+		if !matchAny(step.Hosts, func(s string) bool { return hosts[s] }) {
+			act.Reason = "主机不匹配"
+			plan = append(plan, act)
+			continue
+		}
+		if step.Check != "" {
+			argv := shellCommand(step.Check)
+			check := exec.Command(argv[0], argv[1:]...)
+			check.Dir = l.repoPath
+			if check.Run() == nil {
+				act.Reason = "检查已通过"
+				plan = append(plan, act)
+				continue
+			}
+		}
+
+		var reasons []string
+		for _, pm := range packageManagers {
+			pkgs := step.packages(pm.name)
+			if len(pkgs) == 0 {
+				continue
+			}
+			if _, err := exec.LookPath(pm.binary); err != nil {
+				reasons = append(reasons, pm.name+" 不可用")
+				continue
+			}
+			var missing []string
+			for _, pkg := range pkgs {
+				if !pm.installed(pkg) {
+					missing = append(missing, pkg)
+				}
+			}
+			if len(missing) == 0 {
+				reasons = append(reasons, pm.name+" 包已安装")
+				continue
+			}
+			for _, argv := range pm.install(missing) {
+				if pm.sudo && sudo {
+					argv = append([]string{"sudo"}, argv...)
+				}
+				act.argv = append(act.argv, argv)
+			}
+		}
+		if step.Run != "" {
+			if step.Check == "" && ran && record.Hash == act.hash && !force {
+				reasons = append(reasons, "已执行")
+			} else {
+				act.argv = append(act.argv, shellCommand(step.Run))
+			}
+		}
+
+		for _, argv := range act.argv {
+			act.Commands = append(act.Commands, displayCommand(argv))
+		}
+		if len(act.argv) > 0 {
+			act.Action = BootstrapRun
+		} else {
+			act.Reason = strings.Join(reasons, "，")
+		}
+		plan = append(plan, act)
+	}
+	return plan, nil
+}
+
+// RunBootstrap 按计划执行引导清单，遇到失败的步骤立即停止，已完成的步骤会被记录
+func (l *Lnk) RunBootstrap(plan []BootstrapAction) error {
+	state, err := l.loadBootstrapState()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := l.saveBootstrapState(state); err != nil {
+			l.printf("保存引导记录失败: %v\n", err)
+		}
+	}()
+
+	for _, act := range plan {
+		if act.Action != BootstrapRun {
+			continue
+		}
+		l.printf("==> %s\n", act.Step)
+		for _, argv := range act.argv {
+			cmd := exec.Command(argv[0], argv[1:]...)
+			cmd.Dir = l.repoPath
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			cmd.Env = append(os.Environ(), "LNK_REPO="+l.repoPath, "LNK_HOST="+layerName(l.host))
+			if err := cmd.Run(); err != nil {
+				return WrapError(err, ErrCodeBootstrapExecution, "执行引导步骤失败", SeverityError).
+					WithContext("step", act.Step).
+					WithContext("command", displayCommand(argv))
+			}
+		}
+		state[act.key] = bootstrapRecord{Hash: act.hash, RanAt: time.Now()}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func planActions(t *testing.T, lnk *Lnk, force bool) ([]BootstrapAction, map[string]string) {
+	t.Helper()
+	plan, err := lnk.PlanBootstrap(force)
+	if err != nil {
+		t.Fatalf("PlanBootstrap failed: %v", err)
+	}
+	actions := make(map[string]string)
+	for _, act := range plan {
+		actions[act.Step] = act.Action
+	}
+	return plan, actions
+}
+
+func TestBootstrapManifestIsIdempotent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("steps use sh")
+	}
+	repoDir := t.TempDir()
+	manifest := `steps:
+  - name: once
+    run: echo x >> count.txt
+  - name: checked
+    check: test -f done.txt
+    run: touch done.txt
+  - name: other-os
+    os: [plan9]
+    run: touch never.txt
+  - name: work-only
+    hosts: [work]
+    run: touch work.txt
+  - name: home-only
+    hosts: [home]
+    run: touch home.txt
+`
+	writeFile(t, filepath.Join(repoDir, BootstrapManifest), manifest)
+	lnk := NewLnk(WithRepoPath(repoDir), WithHost("work"))
+
+	plan, actions := planActions(t, lnk, false)
+	want := map[string]string{
+		"once": BootstrapRun, "checked": BootstrapRun, "other-os": BootstrapSkip,
+		"work-only": BootstrapRun, "home-only": BootstrapSkip,
+	}
+	for step, action := range want {
+		if actions[step] != action {
+			t.Fatalf("expected %s to %s, got %v", step, action, actions)
+		}
+	}
+	if err := lnk.RunBootstrap(plan); err != nil {
+		t.Fatalf("RunBootstrap failed: %v", err)
+	}
+	assertExists(t, filepath.Join(repoDir, "done.txt"))
+	assertExists(t, filepath.Join(repoDir, "work.txt"))
+	assertNotExists(t, filepath.Join(repoDir, "never.txt"))
+	assertNotExists(t, filepath.Join(repoDir, "home.txt"))
+
+	plan, actions = planActions(t, lnk, false)
+	for step, action := range actions {
+		if action != BootstrapSkip {
+			t.Fatalf("expected %s to be skipped on second run, got %v", step, actions)
+		}
+	}
+	if err := lnk.RunBootstrap(plan); err != nil {
+		t.Fatalf("RunBootstrap failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repoDir, "count.txt")); strings.Count(string(data), "x") != 1 {
+		t.Fatalf("expected step to run once, got %q", data)
+	}
+
+	if _, actions = planActions(t, lnk, true); actions["once"] != BootstrapRun || actions["checked"] != BootstrapSkip {
+		t.Fatalf("expected force to rerun steps without check only, got %v", actions)
+	}
+
+	writeFile(t, filepath.Join(repoDir, BootstrapManifest), strings.Replace(manifest, "echo x", "echo y", 1))
+	if _, actions = planActions(t, lnk, false); actions["once"] != BootstrapRun {
+		t.Fatalf("expected changed step to run again, got %v", actions)
+	}
+}
+
+func TestBootstrapManifestStopsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("steps use sh")
+	}
+	repoDir := t.TempDir()
+	writeFile(t, filepath.Join(repoDir, BootstrapManifest), `steps:
+  - name: ok
+    run: "true"
+  - name: broken
+    run: exit 3
+  - name: after
+    run: touch after.txt
+`)
+	lnk := NewLnk(WithRepoPath(repoDir))
+	plan, _ := planActions(t, lnk, false)
+	err := lnk.RunBootstrap(plan)
+	se, ok := err.(StructuredError)
+	if !ok || se.Code() != ErrCodeBootstrapExecution {
+		t.Fatalf("expected %s error, got %v", ErrCodeBootstrapExecution, err)
+	}
+	assertNotExists(t, filepath.Join(repoDir, "after.txt"))
+
+	if _, actions := planActions(t, lnk, false); actions["ok"] != BootstrapSkip || actions["broken"] != BootstrapRun {
+		t.Fatalf("expected completed step to be recorded, got %v", actions)
+	}
+}
+
+func TestGoBinaryName(t *testing.T) {
+	for pkg, want := range map[string]string{
+		"golang.org/x/tools/gopls@latest":             "gopls",
+		"github.com/go-delve/delve/cmd/dlv":           "dlv",
+		"github.com/sohaha/zzz/v2@v2.0.0":             "zzz",
+		"honnef.co/go/tools/cmd/staticcheck@2023.1.7": "staticcheck",
+	} {
+		if got := goBinaryName(pkg); got != want {
+			t.Fatalf("goBinaryName(%q) = %q, want %q", pkg, got, want)
+		}
+	}
+}
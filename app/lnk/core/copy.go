@@ -0,0 +1,318 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const copyStateFilename = "copy.json"
+
+// 复制条目的同步结果
+const (
+	CopySynced   = "synced"
+	CopyToRepo   = "to-repo"
+	CopyToTarget = "to-target"
+	CopyConflict = "conflict"
+)
+
+type CopySyncResult struct {
+	Path   string `json:"path" yaml:"path"`
+	Action string `json:"action" yaml:"action"`
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadCopyState 读取上次同步时的内容哈希，键为仓库内的相对路径
+func (l *Lnk) loadCopyState() (map[string]string, error) {
+	state := make(map[string]string)
+	if err := l.readState(copyStateFilename, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (l *Lnk) saveCopyState(state map[string]string) error {
+	return l.writeState(copyStateFilename, state)
+}
+
+// syncCopyEntry 根据上次同步的哈希判断哪一侧发生了变化并复制，两侧都变化时返回冲突，
+// restore 为 true 时没有同步记录的本地文件会被备份后用仓库版本覆盖
+func (l *Lnk) syncCopyEntry(ent TrackedEntry, state map[string]string, restore bool) (string, error) {
+	repoFile := l.getRepoFilePath(ent.Path)
+	target := trackedToAbsPath(ent.Path)
+	key := l.entryRelativePath(ent)
+
+	repoHash, err := fileHash(repoFile)
+	if err != nil {
+		return "", fmt.Errorf("读取仓库文件失败: %w", err)
+	}
+	if repoHash == "" {
+		return "", fmt.Errorf("仓库文件不存在: %s", repoFile)
+	}
+	targetHash, err := fileHash(target)
+	if err != nil {
+		return "", fmt.Errorf("读取目标文件失败: %w", err)
+	}
+	base := state[key]
+
+	switch {
+	case repoHash == targetHash:
+		state[key] = repoHash
+		return CopySynced, nil
+	case targetHash == "", base == targetHash:
+		if err := l.fs.CopyFile(repoFile, target); err != nil {
+			return "", err
+		}
+		state[key] = repoHash
+		return CopyToTarget, nil
+	case base == repoHash:
+		if err := l.fs.CopyFile(target, repoFile); err != nil {
+			return "", err
+		}
+		state[key] = targetHash
+		return CopyToRepo, nil
+	case base == "" && restore:
+		backupPath, err := l.createBackup(target)
+		if err != nil {
+			return "", fmt.Errorf("创建备份失败: %w", err)
+		}
+		l.printf("已备份现有文件: %s -> %s\n", target, backupPath)
+		if err := l.fs.CopyFile(repoFile, target); err != nil {
+			return "", err
+		}
+		state[key] = repoHash
+		return CopyToTarget, nil
+	}
+	return CopyConflict, nil
+}
+
+// copySyncer 返回同步函数与保存函数，同步状态在首次遇到复制条目时才读取
+func (l *Lnk) copySyncer(restore bool) (func(ent TrackedEntry) (string, error), func() error) {
+	var (
+		state    map[string]string
+		stateErr error
+	)
+	sync := func(ent TrackedEntry) (string, error) {
+		if state == nil && stateErr == nil {
+			state, stateErr = l.loadCopyState()
+		}
+		if stateErr != nil {
+			return "", stateErr
+		}
+		return l.syncCopyEntry(ent, state, restore)
+	}
+	save := func() error {
+		if state == nil {
+			return nil
+		}
+		return l.saveCopyState(state)
+	}
+	return sync, save
+}
+
+// SyncCopies 同步当前主机（组合主机包括各层）的所有复制条目，返回发生复制或冲突的条目
+func (l *Lnk) SyncCopies() ([]CopySyncResult, error) {
+	groups, err := l.layerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var results []CopySyncResult
+	for _, g := range groups {
+		r, err := g.lnk.syncCopies(g.entries)
+		results = append(results, r...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (l *Lnk) syncCopies(entries []TrackedEntry) ([]CopySyncResult, error) {
+	sync, save := l.copySyncer(false)
+	var results []CopySyncResult
+	for _, ent := range entries {
+		if ent.Type != LinkTypeCopy {
+			continue
+		}
+		action, err := sync(ent)
+		if err != nil {
+			_ = save()
+			return results, fmt.Errorf("同步 %s 失败: %w", ent.Path, err)
+		}
+		if action != CopySynced {
+			results = append(results, CopySyncResult{Path: ent.Path, Action: action})
+		}
+	}
+	return results, save()
+}
+
+func copyConflicts(results []CopySyncResult) []string {
+	conflicts := make([]string, 0)
+	for _, r := range results {
+		if r.Action == CopyConflict {
+			conflicts = append(conflicts, r.Path)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+func newCopyConflictError(conflicts []string) error {
+	return NewStructuredError(ErrCodeCopyConflict, fmt.Sprintf("%d 个复制文件在本地与仓库中都被修改", len(conflicts)), SeverityError).
+		WithContext("files", conflicts).
+		WithSuggestion("请使用 'zzz lnk resolve <file> --keep local|repo' 选择保留的版本")
+}
+
+// ResolveCopyConflict 使用本地（local）或仓库（repo）版本解决复制条目的冲突
+func (l *Lnk) ResolveCopyConflict(filePath, keep string) error {
+	absPath, trackKey := resolveRemovePath(l, filePath)
+	ent, ok := l.findTrackedEntry(trackKey)
+	if !ok {
+		return &FileNotManagedError{FilePath: absPath}
+	}
+	if ent.Type != LinkTypeCopy {
+		return fmt.Errorf("不是复制条目: %s", filePath)
+	}
+
+	state, err := l.loadCopyState()
+	if err != nil {
+		return err
+	}
+	repoFile := l.getRepoFilePath(ent.Path)
+	switch keep {
+	case "local":
+		err = l.fs.CopyFile(absPath, repoFile)
+	case "repo":
+		err = l.fs.CopyFile(repoFile, absPath)
+	default:
+		return fmt.Errorf("无效的版本: %s，可选: local|repo", keep)
+	}
+	if err != nil {
+		return err
+	}
+	hash, err := fileHash(repoFile)
+	if err != nil {
+		return err
+	}
+	state[l.entryRelativePath(ent)] = hash
+	return l.saveCopyState(state)
+}
+
+// AddCopy 将文件复制到仓库，本地保留普通文件，之后按内容双向同步
+func (l *Lnk) AddCopy(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("文件路径列表不能为空")
+	}
+
+	type item struct {
+		abs, trackKey string
+	}
+	var items []item
+	for _, filePath := range filePaths {
+		normalizedPath := l.normalizeFilePath(filePath)
+		if err := l.fs.ValidateFileForAdd(normalizedPath); err != nil {
+			return fmt.Errorf("文件 %s 验证失败: %w", filePath, err)
+		}
+		if l.fs.IsDir(normalizedPath) {
+			return fmt.Errorf("%s 条目仅支持文件: %s", LinkTypeCopy, filePath)
+		}
+		trackKey := l.toTrackingPath(normalizedPath)
+		managed, err := l.isFileManaged(trackKey)
+		if err != nil {
+			return fmt.Errorf("检查文件 %s 管理状态失败: %w", filePath, err)
+		}
+		if managed {
+			return &FileAlreadyManagedError{FilePath: normalizedPath}
+		}
+		items = append(items, item{abs: normalizedPath, trackKey: trackKey})
+	}
+
+	if err := l.ensureHostDir(); err != nil {
+		return fmt.Errorf("创建主机目录失败: %w", err)
+	}
+	state, err := l.loadCopyState()
+	if err != nil {
+		return err
+	}
+
+	var rollbackActions []func() error
+	rollback := func() {
+		for i := len(rollbackActions) - 1; i >= 0; i-- {
+			if err := rollbackActions[i](); err != nil {
+				l.printf("回滚操作失败: %v\n", err)
+			}
+		}
+	}
+
+	gitFilesToAdd := make([]string, 0, len(items))
+
+	for _, it := range items {
+		ent := TrackedEntry{Path: it.trackKey, Type: LinkTypeCopy}
+		repoFile := l.getRepoFilePath(it.trackKey)
+		if err := l.fs.CopyFile(it.abs, repoFile); err != nil {
+			rollback()
+			return WrapError(err, ErrCodeFileOperation, "复制文件到仓库失败", SeverityError).
+				WithContext("source", it.abs).
+				WithContext("destination", repoFile)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return os.Remove(p) }
+		}(repoFile))
+
+		if err := l.addToTrackingFileWithType(it.trackKey, LinkTypeCopy); err != nil {
+			rollback()
+			return fmt.Errorf("添加文件 %s 到跟踪文件失败: %w", it.trackKey, err)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return l.removeFromTrackingFile(p) }
+		}(it.trackKey))
+
+		hash, err := fileHash(repoFile)
+		if err != nil {
+			rollback()
+			return err
+		}
+		state[l.entryRelativePath(ent)] = hash
+		gitFilesToAdd = append(gitFilesToAdd, l.entryRelativePath(ent))
+	}
+
+	if err := l.git.AddMultiple(gitFilesToAdd); err != nil {
+		rollback()
+		return WrapError(err, ErrCodeGitCommand, "添加文件到 Git 失败", SeverityError)
+	}
+	if err := l.stageTrackingFile(); err != nil {
+		rollback()
+		return err
+	}
+
+	commitMsg := fmt.Sprintf("lnk: 添加复制文件 %s", filepath.Base(items[0].abs))
+	if len(items) > 1 {
+		commitMsg = fmt.Sprintf("lnk: 批量添加 %d 个复制文件", len(items))
+	}
+	if err := l.git.Commit(commitMsg); err != nil {
+		rollback()
+		return WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError).
+			WithContext("commit_message", commitMsg)
+	}
+	l.recordOperation(OpAdd)
+	return l.saveCopyState(state)
+}
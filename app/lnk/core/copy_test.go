@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddCopySyncsBothDirections(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithLinkType(LinkTypeCopy))
+	target := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if info, err := os.Lstat(target); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("expected regular file to be kept, got %v (%v)", info, err)
+	}
+	repoFile := lnk.getRepoFilePath(lnk.toTrackingPath(target))
+	if content, _ := os.ReadFile(repoFile); string(content) != "v1\n" {
+		t.Fatalf("unexpected repo content %q", content)
+	}
+	if tracked := runGit(t, repoDir, "ls-files"); strings.Contains(tracked, StateDir) {
+		t.Fatalf("sync state must not be tracked: %q", tracked)
+	}
+
+	writeFile(t, target, "local\n")
+	results, err := lnk.SyncCopies()
+	if err != nil || len(results) != 1 || results[0].Action != CopyToRepo {
+		t.Fatalf("expected to-repo, got %v (%v)", results, err)
+	}
+	if content, _ := os.ReadFile(repoFile); string(content) != "local\n" {
+		t.Fatalf("expected repo to be updated, got %q", content)
+	}
+
+	writeFile(t, repoFile, "remote\n")
+	results, err = lnk.SyncCopies()
+	if err != nil || len(results) != 1 || results[0].Action != CopyToTarget {
+		t.Fatalf("expected to-target, got %v (%v)", results, err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "remote\n" {
+		t.Fatalf("expected target to be updated, got %q", content)
+	}
+
+	if results, err := lnk.SyncCopies(); err != nil || len(results) != 0 {
+		t.Fatalf("expected nothing to sync, got %v (%v)", results, err)
+	}
+}
+
+func TestCopyConflictBlocksPushUntilResolved(t *testing.T) {
+	lnk, _ := newTestLnk(t, WithLinkType(LinkTypeCopy))
+	target := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	repoFile := lnk.getRepoFilePath(lnk.toTrackingPath(target))
+	writeFile(t, target, "local\n")
+	writeFile(t, repoFile, "remote\n")
+
+	_, err := lnk.Push("")
+	var se StructuredError
+	if !errors.As(err, &se) || se.Code() != ErrCodeCopyConflict {
+		t.Fatalf("expected copy conflict error, got %v", err)
+	}
+	status, err := lnk.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.CopyConflicts) != 1 {
+		t.Fatalf("expected conflict in status, got %v", status.CopyConflicts)
+	}
+
+	if err := lnk.ResolveCopyConflict(target, "local"); err != nil {
+		t.Fatalf("ResolveCopyConflict failed: %v", err)
+	}
+	if content, _ := os.ReadFile(repoFile); string(content) != "local\n" {
+		t.Fatalf("expected local version in repo, got %q", content)
+	}
+	if results, err := lnk.SyncCopies(); err != nil || len(results) != 0 {
+		t.Fatalf("expected conflict to be resolved, got %v (%v)", results, err)
+	}
+}
+
+func TestRemoveCopyKeepsLocalFile(t *testing.T) {
+	lnk, _ := newTestLnk(t, WithLinkType(LinkTypeCopy))
+	target := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	repoFile := lnk.getRepoFilePath(lnk.toTrackingPath(target))
+
+	if err := lnk.Remove(target); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	assertNotExists(t, repoFile)
+	if content, _ := os.ReadFile(target); string(content) != "v1\n" {
+		t.Fatalf("expected local file to be kept, got %q", content)
+	}
+}
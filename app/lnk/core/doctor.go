@@ -8,8 +8,8 @@ import (
 )
 
 type DoctorResult struct {
-	InvalidEntries []string
-	BrokenSymlinks []string
+	InvalidEntries []string `json:"invalid_entries" yaml:"invalid_entries"`
+	BrokenSymlinks []string `json:"broken_symlinks" yaml:"broken_symlinks"`
 }
 
 func (r *DoctorResult) HasIssues() bool {
@@ -71,7 +71,7 @@ func (l *Lnk) findInvalidEntries(entries []TrackedEntry) []string {
 			invalid = append(invalid, ent.Path)
 			continue
 		}
-		if !l.fs.FileExists(l.getRepoFilePath(ent.Path)) {
+		if !l.fs.FileExists(l.entryRepoFilePath(ent)) {
 			invalid = append(invalid, ent.Path)
 		}
 	}
@@ -91,10 +91,10 @@ func (l *Lnk) findBrokenSymlinks(entries []TrackedEntry) []string {
 			continue
 		}
 
-		repoFilePath := l.getRepoFilePath(ent.Path)
-		if !l.fs.FileExists(repoFilePath) {
+		if !l.fs.FileExists(l.entryRepoFilePath(ent)) {
 			continue
 		}
+		repoFilePath := l.entryLinkTarget(ent)
 		absPath := trackedToAbsPath(ent.Path)
 		if ent.Type == LinkTypeHard {
 			if !l.fs.FileExists(absPath) || !l.fs.IsHardlinkTo(absPath, repoFilePath) {
@@ -102,6 +102,12 @@ func (l *Lnk) findBrokenSymlinks(entries []TrackedEntry) []string {
 			}
 			continue
 		}
+		if ent.Type == LinkTypeCopy {
+			if !l.fs.FileExists(absPath) {
+				broken = append(broken, ent.Path)
+			}
+			continue
+		}
 		if !isValidSoftlink(l, absPath, repoFilePath) {
 			broken = append(broken, ent.Path)
 		}
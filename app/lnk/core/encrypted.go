@@ -0,0 +1,405 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sohaha/zzz/app/lnk/secret"
+)
+
+const (
+	// EncryptedSuffix 仓库中密文文件的后缀
+	EncryptedSuffix = ".enc"
+	// SecretsDir 解密后文件的存放目录，位于仓库内并被 git 忽略
+	SecretsDir = ".lnk-secrets"
+	// SaltFilename 口令派生密钥使用的盐值，随仓库同步
+	SaltFilename = ".lnk-salt"
+)
+
+type KeyInfo struct {
+	KeyFile        string
+	Exists         bool
+	KeyID          string
+	Passphrase     bool
+	EncryptedFiles []string
+	Mismatched     []string
+}
+
+func WithKeyFile(path string) Option {
+	return func(l *Lnk) {
+		l.keyFile = path
+	}
+}
+
+func (l *Lnk) getKeyFile() string {
+	if l.keyFile != "" {
+		return l.keyFile
+	}
+	return secret.DefaultKeyFile()
+}
+
+func (l *Lnk) loadKey() (secret.Key, error) {
+	key, err := secret.LoadKey(l.getKeyFile())
+	if err != nil {
+		var notFound *secret.KeyNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, WrapError(err, ErrCodeSecretKey, "未找到加密密钥", SeverityError).
+				WithContext("key_file", notFound.Path).
+				WithSuggestion("请运行 'zzz lnk key init' 生成密钥，或使用 'zzz lnk key import' 导入其他机器的密钥")
+		}
+		return nil, WrapError(err, ErrCodeSecretKey, "读取加密密钥失败", SeverityError).
+			WithContext("key_file", l.getKeyFile())
+	}
+	return key, nil
+}
+
+// AddEncrypted 将文件加密后存入仓库，原位置链接到仓库内被忽略的解密文件
+func (l *Lnk) AddEncrypted(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("文件路径列表不能为空")
+	}
+	key, err := l.loadKey()
+	if err != nil {
+		return err
+	}
+	return l.addGenerated(filePaths, LinkTypeEncrypted, func(path string, data []byte) ([]byte, []byte, error) {
+		ciphertext, err := secret.Encrypt(key, data)
+		if err != nil {
+			return nil, nil, WrapError(err, ErrCodeSecretKey, "加密文件失败", SeverityError).WithContext("file", path)
+		}
+		return ciphertext, data, nil
+	})
+}
+
+// decryptEntry 解密到本地，已存在且内容不同的文件会先备份
+func (l *Lnk) decryptEntry(ent TrackedEntry, key secret.Key) error {
+	ciphertext, err := os.ReadFile(l.entryRepoFilePath(ent))
+	if err != nil {
+		return fmt.Errorf("读取密文失败: %w", err)
+	}
+	plaintext, err := secret.Decrypt(key, ciphertext)
+	if err != nil {
+		return WrapError(err, ErrCodeSecretDecrypt, "解密失败", SeverityError).
+			WithContext("file", ent.Path).
+			WithSuggestion("请确认已导入加密该文件时使用的密钥: zzz lnk key import")
+	}
+
+	return l.writeGeneratedFile(ent, plaintext)
+}
+
+// encryptChanged 将本地修改过的解密文件重新加密到仓库，内容未变时保留原密文避免无意义的提交
+func (l *Lnk) encryptChanged() ([]string, error) {
+	groups, err := l.layerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, g := range groups {
+		c, err := g.lnk.encryptEntries(g.entries)
+		changed = append(changed, c...)
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+func (l *Lnk) encryptEntries(entries []TrackedEntry) ([]string, error) {
+	var (
+		key     secret.Key
+		changed []string
+	)
+	for _, ent := range entries {
+		if ent.Type != LinkTypeEncrypted {
+			continue
+		}
+		plaintext, err := os.ReadFile(l.entryLinkTarget(ent))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return changed, fmt.Errorf("读取解密文件 %s 失败: %w", ent.Path, err)
+		}
+		if key == nil {
+			if key, err = l.loadKey(); err != nil {
+				return changed, err
+			}
+		}
+		encPath := l.entryRepoFilePath(ent)
+		if old, err := os.ReadFile(encPath); err == nil {
+			if current, err := secret.Decrypt(key, old); err == nil && bytes.Equal(current, plaintext) {
+				continue
+			}
+		}
+		ciphertext, err := secret.Encrypt(key, plaintext)
+		if err != nil {
+			return changed, fmt.Errorf("加密文件 %s 失败: %w", ent.Path, err)
+		}
+		if err := os.WriteFile(encPath, ciphertext, 0o644); err != nil {
+			return changed, fmt.Errorf("写入密文 %s 失败: %w", encPath, err)
+		}
+		changed = append(changed, ent.Path)
+	}
+	return changed, nil
+}
+
+func (l *Lnk) readSalt() ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.repoPath, SaltFilename))
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(data)))
+}
+
+// writeSalt 生成新的盐值并提交到仓库
+func (l *Lnk) writeSalt() ([]byte, error) {
+	salt, err := secret.GenerateSalt()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(l.repoPath, SaltFilename), []byte(hex.EncodeToString(salt)+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("写入盐值失败: %w", err)
+	}
+	if err := l.git.Add(SaltFilename); err != nil {
+		return nil, WrapError(err, ErrCodeGitCommand, "添加盐值到 Git 失败", SeverityError)
+	}
+	return salt, nil
+}
+
+// InitKey 生成密钥，口令不为空时由口令与仓库中的盐值派生，其他机器输入相同口令即可得到同一密钥
+func (l *Lnk) InitKey(passphrase string, force bool) (*KeyInfo, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	keyFile := l.getKeyFile()
+	if _, err := os.Stat(keyFile); err == nil && !force {
+		return nil, NewStructuredError(ErrCodeSecretKey, "加密密钥已存在: "+keyFile, SeverityError).
+			WithSuggestion("如需重新生成请使用 --force，已加密的文件需要先执行 'zzz lnk key rotate'")
+	}
+
+	var (
+		key secret.Key
+		err error
+	)
+	if passphrase != "" {
+		salt, saltErr := l.readSalt()
+		if saltErr != nil {
+			if salt, err = l.writeSalt(); err != nil {
+				return nil, err
+			}
+			if err = l.git.Commit("lnk: 添加密钥盐值"); err != nil {
+				return nil, WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError)
+			}
+		}
+		key, err = secret.DeriveKey(passphrase, salt)
+	} else {
+		key, err = secret.GenerateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := secret.SaveKey(keyFile, key); err != nil {
+		return nil, fmt.Errorf("保存密钥失败: %w", err)
+	}
+	return l.KeyStatus()
+}
+
+// ImportKey 导入 export 输出的密钥，或输入口令从仓库盐值派生
+func (l *Lnk) ImportKey(value, passphrase string) (*KeyInfo, error) {
+	var (
+		key secret.Key
+		err error
+	)
+	if passphrase != "" {
+		salt, saltErr := l.readSalt()
+		if saltErr != nil {
+			return nil, NewStructuredError(ErrCodeSecretKey, "仓库中没有盐值文件 "+SaltFilename, SeverityError).
+				WithSuggestion("该仓库的密钥不是由口令生成，请在原机器执行 'zzz lnk key export' 后导入")
+		}
+		key, err = secret.DeriveKey(passphrase, salt)
+	} else {
+		key, err = secret.ParseKey(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := secret.SaveKey(l.getKeyFile(), key); err != nil {
+		return nil, fmt.Errorf("保存密钥失败: %w", err)
+	}
+	return l.KeyStatus()
+}
+
+func (l *Lnk) ExportKey() (string, error) {
+	key, err := l.loadKey()
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+// KeyStatus 返回密钥信息以及所有主机中的加密文件，Mismatched 为当前密钥无法解密的文件
+func (l *Lnk) KeyStatus() (*KeyInfo, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	info := &KeyInfo{KeyFile: l.getKeyFile()}
+	if _, err := os.Stat(filepath.Join(l.repoPath, SaltFilename)); err == nil {
+		info.Passphrase = true
+	}
+	key, err := secret.LoadKey(info.KeyFile)
+	if err == nil {
+		info.Exists, info.KeyID = true, key.ID()
+	}
+
+	files, err := l.allEncryptedFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		rel, _ := filepath.Rel(l.repoPath, f)
+		info.EncryptedFiles = append(info.EncryptedFiles, rel)
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if id, ok := secret.KeyID(data); !ok || id != info.KeyID {
+			info.Mismatched = append(info.Mismatched, rel)
+		}
+	}
+	return info, nil
+}
+
+// allEncryptedFiles 所有主机跟踪文件中加密条目对应的密文
+func (l *Lnk) allEncryptedFiles() ([]string, error) {
+	dirEntries, err := os.ReadDir(l.repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取仓库目录失败: %w", err)
+	}
+	originalHost := l.host
+	defer func() {
+		l.host = originalHost
+	}()
+
+	var files []string
+	for _, d := range dirEntries {
+		name := d.Name()
+		if d.IsDir() || (name != TrackFilename && !strings.HasPrefix(name, TrackFilename+".")) {
+			continue
+		}
+		l.host = strings.TrimPrefix(strings.TrimPrefix(name, TrackFilename), ".")
+		content, err := os.ReadFile(filepath.Join(l.repoPath, name))
+		if err != nil {
+			return nil, fmt.Errorf("读取跟踪文件失败: %w", err)
+		}
+		for _, ent := range l.parseTrackingLines(strings.Split(string(content), "\n")) {
+			if ent.Type == LinkTypeEncrypted {
+				files = append(files, l.entryRepoFilePath(ent))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// RotateKey 生成新密钥并重新加密所有主机的加密文件，口令不为空时同时更换盐值
+func (l *Lnk) RotateKey(passphrase string) (int, error) {
+	if !l.IsInitialized() {
+		return 0, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	if _, err := l.encryptChanged(); err != nil {
+		return 0, err
+	}
+	oldKey, err := l.loadKey()
+	if err != nil {
+		return 0, err
+	}
+	files, err := l.allEncryptedFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	plaintexts := make(map[string][]byte, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return 0, fmt.Errorf("读取密文 %s 失败: %w", f, err)
+		}
+		if plaintexts[f], err = secret.Decrypt(oldKey, data); err != nil {
+			return 0, WrapError(err, ErrCodeSecretDecrypt, "使用当前密钥解密失败", SeverityError).
+				WithContext("file", f)
+		}
+	}
+
+	var newKey secret.Key
+	if passphrase != "" {
+		salt, err := l.writeSalt()
+		if err != nil {
+			return 0, err
+		}
+		newKey, err = secret.DeriveKey(passphrase, salt)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		if newKey, err = secret.GenerateKey(); err != nil {
+			return 0, err
+		}
+		if _, err := os.Stat(filepath.Join(l.repoPath, SaltFilename)); err == nil {
+			if err := l.git.Remove(SaltFilename); err != nil {
+				return 0, WrapError(err, ErrCodeGitCommand, "移除盐值失败", SeverityError)
+			}
+		}
+	}
+
+	relFiles := make([]string, 0, len(files))
+	for _, f := range files {
+		ciphertext, err := secret.Encrypt(newKey, plaintexts[f])
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(f, ciphertext, 0o644); err != nil {
+			return 0, fmt.Errorf("写入密文 %s 失败: %w", f, err)
+		}
+		rel, _ := filepath.Rel(l.repoPath, f)
+		relFiles = append(relFiles, rel)
+	}
+
+	// 先保留旧密钥，提交失败时仍可解密
+	keyFile := l.getKeyFile()
+	if err := secret.SaveKey(keyFile+".old", oldKey); err != nil {
+		return 0, fmt.Errorf("备份旧密钥失败: %w", err)
+	}
+	if err := secret.SaveKey(keyFile, newKey); err != nil {
+		return 0, fmt.Errorf("保存密钥失败: %w", err)
+	}
+	if err := l.git.AddMultiple(relFiles); err != nil {
+		return 0, WrapError(err, ErrCodeGitCommand, "添加密文到 Git 失败", SeverityError)
+	}
+	if err := l.git.Commit(fmt.Sprintf("lnk: 轮换加密密钥 (%d 个文件)", len(files))); err != nil {
+		return 0, WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError)
+	}
+	return len(files), nil
+}
+
+// entryDecrypter 返回解密函数，首次遇到加密条目时才读取密钥
+func (l *Lnk) entryDecrypter() func(ent TrackedEntry) error {
+	var (
+		key    secret.Key
+		keyErr error
+	)
+	return func(ent TrackedEntry) error {
+		if key == nil && keyErr == nil {
+			key, keyErr = l.loadKey()
+		}
+		if keyErr != nil {
+			return keyErr
+		}
+		return l.decryptEntry(ent, key)
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zzz/app/lnk/secret"
+)
+
+// newTestLnk 在临时目录中初始化带有一次空提交的 git 仓库，并以其为仓库创建 Lnk
+func newTestLnk(t *testing.T, opts ...Option) (*Lnk, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoDir := t.TempDir()
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test")
+	runGit(t, repoDir, "commit", "--allow-empty", "-m", "init")
+
+	lnk := NewLnk(append([]Option{WithRepoPath(repoDir)}, opts...)...)
+	return lnk, lnk.GetRepoPath()
+}
+
+func TestAddEncryptedStoresCiphertext(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithKeyFile(filepath.Join(t.TempDir(), "lnk.key")))
+	if _, err := lnk.InitKey("", false); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "token.conf")
+	writeFile(t, target, "password=hunter2")
+
+	lnk.SetLinkType(LinkTypeEncrypted)
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ent := TrackedEntry{Path: lnk.toTrackingPath(target), Type: LinkTypeEncrypted}
+	encPath := lnk.entryRepoFilePath(ent)
+	data, err := os.ReadFile(encPath)
+	if err != nil {
+		t.Fatalf("read ciphertext failed: %v", err)
+	}
+	if !secret.IsEncrypted(data) || bytes.Contains(data, []byte("hunter2")) {
+		t.Fatalf("expected ciphertext in repo, got %q", data)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil || resolved != lnk.entryLinkTarget(ent) {
+		t.Fatalf("expected link to %s, got %s (%v)", lnk.entryLinkTarget(ent), resolved, err)
+	}
+
+	tracked := runGit(t, repoDir, "ls-files")
+	if strings.Contains(tracked, SecretsDir) || !strings.Contains(tracked, filepath.Base(encPath)) {
+		t.Fatalf("unexpected tracked files: %q", tracked)
+	}
+	if msg := runGit(t, repoDir, "log", "-1", "--format=%s"); msg != "lnk: 添加加密文件 token.conf" {
+		t.Fatalf("unexpected commit message: %q", msg)
+	}
+
+	if err := os.RemoveAll(filepath.Join(repoDir, SecretsDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := lnk.RestoreSymlinks(); err != nil {
+		t.Fatalf("RestoreSymlinks failed: %v", err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil || string(content) != "password=hunter2" {
+		t.Fatalf("expected decrypted content, got %q (%v)", content, err)
+	}
+
+	if err := lnk.Remove(target); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if lnk.fs.IsSymlink(target) {
+		t.Fatalf("expected regular file after remove")
+	}
+	assertNotExists(t, encPath)
+}
+
+func TestEncryptChangedAndRotate(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithKeyFile(filepath.Join(t.TempDir(), "lnk.key")))
+	if _, err := lnk.InitKey("", false); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+	target := filepath.Join(t.TempDir(), "secret.env")
+	writeFile(t, target, "A=1")
+	if err := lnk.AddEncrypted([]string{target}); err != nil {
+		t.Fatalf("AddEncrypted failed: %v", err)
+	}
+
+	changed, err := lnk.encryptChanged()
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("expected no re-encryption, got %v (%v)", changed, err)
+	}
+	writeFile(t, target, "A=2")
+	if changed, err = lnk.encryptChanged(); err != nil || len(changed) != 1 {
+		t.Fatalf("expected one re-encrypted file, got %v (%v)", changed, err)
+	}
+
+	before, _ := lnk.KeyStatus()
+	n, err := lnk.RotateKey("")
+	if err != nil || n != 1 {
+		t.Fatalf("RotateKey = %d, %v", n, err)
+	}
+	after, _ := lnk.KeyStatus()
+	if after.KeyID == before.KeyID || len(after.Mismatched) != 0 {
+		t.Fatalf("unexpected key status after rotate: %+v", after)
+	}
+	if msg := runGit(t, repoDir, "log", "-1", "--format=%s"); !strings.HasPrefix(msg, "lnk: 轮换加密密钥") {
+		t.Fatalf("unexpected commit message: %q", msg)
+	}
+
+	key, err := lnk.loadKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(lnk.entryRepoFilePath(TrackedEntry{Path: lnk.toTrackingPath(target), Type: LinkTypeEncrypted}))
+	plain, err := secret.Decrypt(key, data)
+	if err != nil || string(plain) != "A=2" {
+		t.Fatalf("expected rotated ciphertext to decrypt to A=2, got %q (%v)", plain, err)
+	}
+}
+
+func TestAddEncryptedWithoutKey(t *testing.T) {
+	lnk, _ := newTestLnk(t, WithKeyFile(filepath.Join(t.TempDir(), "lnk.key")))
+	target := filepath.Join(t.TempDir(), "a.conf")
+	writeFile(t, target, "a")
+
+	err := lnk.AddEncrypted([]string{target})
+	se, ok := err.(StructuredError)
+	if !ok || se.Code() != ErrCodeSecretKey {
+		t.Fatalf("expected %s error, got %v", ErrCodeSecretKey, err)
+	}
+	assertExists(t, target)
+}
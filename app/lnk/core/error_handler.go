@@ -17,6 +17,7 @@ const (
 	ErrCodeFileNotManaged     ErrorCode = "FILE_NOT_MANAGED"
 	ErrCodeFilePermission     ErrorCode = "FILE_PERMISSION"
 	ErrCodeFileOperation      ErrorCode = "FILE_OPERATION"
+	ErrCodeCopyConflict       ErrorCode = "COPY_CONFLICT"
 
 	ErrCodeGitCommand       ErrorCode = "GIT_COMMAND"
 	ErrCodeGitNetwork       ErrorCode = "GIT_NETWORK"
@@ -27,6 +28,17 @@ const (
 
 	ErrCodeBootstrapNotFound  ErrorCode = "BOOTSTRAP_NOT_FOUND"
 	ErrCodeBootstrapExecution ErrorCode = "BOOTSTRAP_EXECUTION"
+	ErrCodeBootstrapManifest  ErrorCode = "BOOTSTRAP_MANIFEST"
+
+	ErrCodeSecretKey      ErrorCode = "SECRET_KEY"
+	ErrCodeSecretDecrypt  ErrorCode = "SECRET_DECRYPT"
+	ErrCodeSecretDetected ErrorCode = "SECRET_DETECTED"
+
+	ErrCodeTemplate ErrorCode = "TEMPLATE"
+
+	ErrCodeProfile ErrorCode = "PROFILE"
+
+	ErrCodeUnknown ErrorCode = "UNKNOWN"
 )
 
 type ErrorSeverity string
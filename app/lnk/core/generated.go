@@ -0,0 +1,293 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 加密与模板条目在仓库中保存源文件，链接指向仓库内被 git 忽略的生成文件
+
+func isGeneratedType(t string) bool {
+	return t == LinkTypeEncrypted || t == LinkTypeTemplate
+}
+
+func generatedSuffix(t string) string {
+	switch t {
+	case LinkTypeEncrypted:
+		return EncryptedSuffix
+	case LinkTypeTemplate:
+		return TemplateSuffix
+	}
+	return ""
+}
+
+func generatedDir(t string) string {
+	switch t {
+	case LinkTypeEncrypted:
+		return SecretsDir
+	case LinkTypeTemplate:
+		return RenderedDir
+	}
+	return ""
+}
+
+func (l *Lnk) entryRepoFilePath(ent TrackedEntry) string {
+	return l.getRepoFilePath(ent.Path) + generatedSuffix(ent.Type)
+}
+
+// entryLinkTarget 链接实际指向的文件，加密与模板条目指向生成的文件
+func (l *Lnk) entryLinkTarget(ent TrackedEntry) string {
+	if isGeneratedType(ent.Type) {
+		return filepath.Join(l.repoPath, generatedDir(ent.Type), l.getRelativePathInRepo(ent.Path))
+	}
+	return l.getRepoFilePath(ent.Path)
+}
+
+func (l *Lnk) entryRelativePath(ent TrackedEntry) string {
+	return l.getRelativePathInRepo(ent.Path) + generatedSuffix(ent.Type)
+}
+
+func (l *Lnk) findTrackedEntry(trackKey string) (TrackedEntry, bool) {
+	entries, err := l.readTrackingEntries()
+	if err != nil {
+		return TrackedEntry{Path: trackKey, Type: LinkTypeSoft}, false
+	}
+	for _, e := range entries {
+		if e.Path == trackKey {
+			return e, true
+		}
+	}
+	return TrackedEntry{Path: trackKey, Type: LinkTypeSoft}, false
+}
+
+// ensureIgnored 确保生成目录写入 .gitignore，修改了 .gitignore 时返回恢复原内容的函数，否则返回 nil
+func (l *Lnk) ensureIgnored(dir string) (func() error, error) {
+	ignoreFile := filepath.Join(l.repoPath, ".gitignore")
+	rule := "/" + dir + "/"
+	original, err := os.ReadFile(ignoreFile)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取 .gitignore 失败: %w", err)
+	}
+	for _, line := range strings.Split(string(original), "\n") {
+		line = strings.TrimSpace(line)
+		if line == rule || line == dir || line == dir+"/" || line == "/"+dir {
+			return nil, nil
+		}
+	}
+	content := append([]byte{}, original...)
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(rule+"\n")...)
+	if err := os.WriteFile(ignoreFile, content, 0o644); err != nil {
+		return nil, fmt.Errorf("写入 .gitignore 失败: %w", err)
+	}
+	return func() error {
+		if !existed {
+			return os.Remove(ignoreFile)
+		}
+		return os.WriteFile(ignoreFile, original, 0o644)
+	}, nil
+}
+
+// writeGeneratedFile 写入生成文件，已存在且内容不同的文件会先备份
+func (l *Lnk) writeGeneratedFile(ent TrackedEntry, data []byte) error {
+	path := l.entryLinkTarget(ent)
+	mode := os.FileMode(0o600)
+	if ent.Type == LinkTypeTemplate {
+		mode = 0o644
+	}
+	if current, err := os.ReadFile(path); err == nil {
+		if bytes.Equal(current, data) {
+			return nil
+		}
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		backupPath, err := l.createBackup(path)
+		if err != nil {
+			return fmt.Errorf("备份生成文件失败: %w", err)
+		}
+		l.printf("已备份本地修改的文件: %s -> %s\n", path, backupPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+// entryGenerator 返回生成函数，密钥与模板变量在首次用到时才读取
+func (l *Lnk) entryGenerator() func(ent TrackedEntry) error {
+	var (
+		decrypt func(TrackedEntry) error
+		render  func(TrackedEntry) error
+	)
+	return func(ent TrackedEntry) error {
+		switch ent.Type {
+		case LinkTypeEncrypted:
+			if decrypt == nil {
+				decrypt = l.entryDecrypter()
+			}
+			return decrypt(ent)
+		case LinkTypeTemplate:
+			if render == nil {
+				render = l.entryRenderer()
+			}
+			return render(ent)
+		}
+		return nil
+	}
+}
+
+// addGenerated 将文件内容经 encode 转换为仓库中的源文件与生成文件，原位置链接到生成文件，
+// 所有文件在一次提交中完成
+func (l *Lnk) addGenerated(filePaths []string, typ string, encode func(path string, data []byte) (source, generated []byte, err error)) error {
+	type item struct {
+		abs, trackKey string
+		info          os.FileInfo
+	}
+	var items []item
+	for _, filePath := range filePaths {
+		normalizedPath := l.normalizeFilePath(filePath)
+		if err := l.fs.ValidateFileForAdd(normalizedPath); err != nil {
+			return fmt.Errorf("文件 %s 验证失败: %w", filePath, err)
+		}
+		if l.fs.IsDir(normalizedPath) {
+			return fmt.Errorf("%s 条目仅支持文件: %s", typ, filePath)
+		}
+		trackKey := l.toTrackingPath(normalizedPath)
+		managed, err := l.isFileManaged(trackKey)
+		if err != nil {
+			return fmt.Errorf("检查文件 %s 管理状态失败: %w", filePath, err)
+		}
+		if managed {
+			return &FileAlreadyManagedError{FilePath: normalizedPath}
+		}
+		info, err := l.fs.GetFileInfo(normalizedPath)
+		if err != nil {
+			return fmt.Errorf("获取文件 %s 信息失败: %w", filePath, err)
+		}
+		items = append(items, item{abs: normalizedPath, trackKey: trackKey, info: info})
+	}
+
+	if err := l.ensureHostDir(); err != nil {
+		return fmt.Errorf("创建主机目录失败: %w", err)
+	}
+
+	var rollbackActions []func() error
+	rollback := func() {
+		for i := len(rollbackActions) - 1; i >= 0; i-- {
+			if err := rollbackActions[i](); err != nil {
+				l.printf("回滚操作失败: %v\n", err)
+			}
+		}
+	}
+
+	restoreIgnore, err := l.ensureIgnored(generatedDir(typ))
+	if err != nil {
+		return err
+	}
+	gitFilesToAdd := make([]string, 0, len(items)+1)
+	if restoreIgnore != nil {
+		gitFilesToAdd = append(gitFilesToAdd, ".gitignore")
+		rollbackActions = append(rollbackActions, restoreIgnore)
+	}
+
+	for _, it := range items {
+		ent := TrackedEntry{Path: it.trackKey, Type: typ}
+		data, err := os.ReadFile(it.abs)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("读取文件 %s 失败: %w", it.abs, err)
+		}
+		source, generated, err := encode(it.abs, data)
+		if err != nil {
+			rollback()
+			return err
+		}
+
+		repoFilePath := l.entryRepoFilePath(ent)
+		if err := l.fs.EnsureDir(filepath.Dir(repoFilePath)); err != nil {
+			rollback()
+			return fmt.Errorf("创建仓库目录失败: %w", err)
+		}
+		if err := os.WriteFile(repoFilePath, source, 0o644); err != nil {
+			rollback()
+			return fmt.Errorf("写入 %s 失败: %w", repoFilePath, err)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return os.Remove(p) }
+		}(repoFilePath))
+
+		linkTarget := l.entryLinkTarget(ent)
+		if err := os.MkdirAll(filepath.Dir(linkTarget), 0o700); err != nil {
+			rollback()
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		mode := it.info.Mode().Perm()
+		if typ == LinkTypeEncrypted {
+			mode = 0o600
+		}
+		if err := os.WriteFile(linkTarget, generated, mode); err != nil {
+			rollback()
+			return fmt.Errorf("写入 %s 失败: %w", linkTarget, err)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return os.Remove(p) }
+		}(linkTarget))
+
+		if err := os.Remove(it.abs); err != nil {
+			rollback()
+			return fmt.Errorf("移除原文件 %s 失败: %w", it.abs, err)
+		}
+		rollbackActions = append(rollbackActions, func(p string, data []byte, mode os.FileMode) func() error {
+			return func() error { return os.WriteFile(p, data, mode) }
+		}(it.abs, data, it.info.Mode().Perm()))
+
+		if err := l.fs.CreateSymlink(linkTarget, it.abs); err != nil {
+			rollback()
+			return WrapError(err, ErrCodeFileOperation, "创建符号链接失败", SeverityError).
+				WithContext("target", linkTarget).
+				WithContext("link", it.abs)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return l.fs.RemoveFile(p) }
+		}(it.abs))
+
+		if err := l.addToTrackingFileWithType(it.trackKey, typ); err != nil {
+			rollback()
+			return fmt.Errorf("添加文件 %s 到跟踪文件失败: %w", it.trackKey, err)
+		}
+		rollbackActions = append(rollbackActions, func(p string) func() error {
+			return func() error { return l.removeFromTrackingFile(p) }
+		}(it.trackKey))
+
+		gitFilesToAdd = append(gitFilesToAdd, l.entryRelativePath(ent))
+	}
+
+	if err := l.git.AddMultiple(gitFilesToAdd); err != nil {
+		rollback()
+		return WrapError(err, ErrCodeGitCommand, "添加文件到 Git 失败", SeverityError)
+	}
+	if err := l.stageTrackingFile(); err != nil {
+		rollback()
+		return err
+	}
+
+	noun := map[string]string{LinkTypeEncrypted: "加密文件", LinkTypeTemplate: "模板"}[typ]
+	commitMsg := fmt.Sprintf("lnk: 添加%s %s", noun, filepath.Base(items[0].abs))
+	if len(items) > 1 {
+		commitMsg = fmt.Sprintf("lnk: 批量添加 %d 个%s", len(items), noun)
+	}
+	if err := l.git.Commit(commitMsg); err != nil {
+		rollback()
+		return WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError).
+			WithContext("commit_message", commitMsg)
+	}
+	l.recordOperation(OpAdd)
+	return nil
+}
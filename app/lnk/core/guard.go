@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// DefaultMaxFileSize 递归添加时单个文件的默认大小上限
+const DefaultMaxFileSize int64 = 1 << 20
+
+// 递归添加时跳过文件的原因
+const (
+	SkipIgnored  = "ignored"
+	SkipManaged  = "managed"
+	SkipInvalid  = "invalid"
+	SkipSpecial  = "special"
+	SkipTooLarge = "too-large"
+	SkipBinary   = "binary"
+)
+
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// AddReport 递归添加的结果
+type AddReport struct {
+	Added   []string
+	Skipped []SkippedFile
+}
+
+// SecretFinding 文件中疑似敏感信息的位置
+type SecretFinding struct {
+	Path string
+	Line int
+	Kind string
+}
+
+var secretPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"API key", regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{32,}\b`)},
+	{"token", regexp.MustCompile(`(?i)\b[A-Za-z0-9_.\-]*(api[_-]?key|secret|token|passw(or)?d)[A-Za-z0-9_.\-]*\s*[:=]\s*["']?[A-Za-z0-9+/_\-.=]{16,}`)},
+}
+
+// scanSecrets 检查内容中的私钥与令牌，每种类型只报告第一次出现的位置
+func scanSecrets(path string, data []byte) []SecretFinding {
+	var findings []SecretFinding
+	for _, p := range secretPatterns {
+		loc := p.re.FindIndex(data)
+		if loc == nil {
+			continue
+		}
+		line := bytes.Count(data[:loc[0]], []byte("\n")) + 1
+		findings = append(findings, SecretFinding{Path: path, Line: line, Kind: p.kind})
+	}
+	return findings
+}
+
+// isBinary 与 Git 相同，前 8000 字节中包含 NUL 即视为二进制文件
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func WithMaxFileSize(size int64) Option {
+	return func(l *Lnk) {
+		l.maxFileSize = size
+	}
+}
+
+// WithAllowSecrets 递归添加时不因敏感信息拒绝添加
+func WithAllowSecrets(allow bool) Option {
+	return func(l *Lnk) {
+		l.allowSecrets = allow
+	}
+}
+
+// isSpecialFile 套接字、命名管道与设备文件
+func isSpecialFile(info os.FileInfo) bool {
+	return info.Mode()&(os.ModeSocket|os.ModeNamedPipe|os.ModeDevice|os.ModeCharDevice|os.ModeIrregular) != 0
+}
+
+// guardFile 检查单个文件是否应被递归添加，返回跳过原因与敏感信息，原因为空表示可以添加
+func (l *Lnk) guardFile(filePath string) (string, []SecretFinding, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	if l.maxFileSize > 0 && info.Size() > l.maxFileSize {
+		return SkipTooLarge, nil, nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if l.maxFileSize > 0 {
+		r = io.LimitReader(f, l.maxFileSize)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if isBinary(data) {
+		return SkipBinary, nil, nil
+	}
+	// 加密条目在仓库中只保存密文，无需检查
+	if l.linkType == LinkTypeEncrypted {
+		return "", nil, nil
+	}
+	return "", scanSecrets(filePath, data), nil
+}
+
+func newSecretError(findings []SecretFinding) error {
+	files := make([]string, 0, len(findings))
+	for _, f := range findings {
+		files = append(files, fmt.Sprintf("%s:%d (%s)", f.Path, f.Line, f.Kind))
+	}
+	sort.Strings(files)
+	return NewStructuredError(ErrCodeSecretDetected, fmt.Sprintf("%d 处疑似私钥或令牌，已拒绝添加", len(findings)), SeverityError).
+		WithContext("files", files).
+		WithSuggestion("使用 --encrypt 加密添加，或在 " + IgnoreFilename + " 中排除这些文件；确认无误后可使用 --force 强制添加")
+}
@@ -0,0 +1,352 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zzz/app/lnk/git"
+)
+
+// 可撤销的 lnk 操作
+const (
+	OpAdd     = "add"
+	OpRemove  = "rm"
+	OpPush    = "push"
+	OpRestore = "restore"
+	OpSync    = "sync"
+
+	historyFilename = "history.json"
+	historyLimit    = 50
+)
+
+// Operation 本机执行过的 lnk 操作，记录在仓库的 StateDir 中
+type Operation struct {
+	Op     string    `json:"op"`
+	Commit string    `json:"commit"`
+	Host   string    `json:"host"`
+	Time   time.Time `json:"time"`
+}
+
+type UndoResult struct {
+	Operation
+	Title  string
+	Pushed bool
+}
+
+func (l *Lnk) loadHistory() ([]Operation, error) {
+	var ops []Operation
+	if err := l.readState(historyFilename, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+func (l *Lnk) saveHistory(ops []Operation) error {
+	if len(ops) > historyLimit {
+		ops = ops[len(ops)-historyLimit:]
+	}
+	return l.writeState(historyFilename, ops)
+}
+
+// recordOperation 在提交成功后记录操作，记录失败不影响操作本身
+func (l *Lnk) recordOperation(op string) {
+	commit, err := l.git.RevParse("HEAD")
+	if err != nil {
+		return
+	}
+	ops, err := l.loadHistory()
+	if err != nil {
+		ops = nil
+	}
+	ops = append(ops, Operation{Op: op, Commit: commit, Host: l.host, Time: time.Now()})
+	if err := l.saveHistory(ops); err != nil {
+		l.printf("保存操作记录失败: %v\n", err)
+	}
+}
+
+// findDeployedEntry 在当前主机（组合主机包括各层）中查找文件对应的条目
+func (l *Lnk) findDeployedEntry(filePath string) (*Lnk, TrackedEntry, error) {
+	absPath, trackKey := resolveRemovePath(l, filePath)
+	groups, err := l.layerGroups()
+	if err != nil {
+		return nil, TrackedEntry{}, err
+	}
+	for _, g := range groups {
+		for _, ent := range g.entries {
+			if ent.Path == trackKey {
+				return g.lnk, ent, nil
+			}
+		}
+	}
+	return nil, TrackedEntry{}, &FileNotManagedError{FilePath: absPath}
+}
+
+// FileLog 返回被管理文件在仓库中的提交记录，同时返回仓库内的相对路径
+func (l *Lnk) FileLog(filePath string, limit int) ([]git.CommitInfo, string, error) {
+	if !l.IsInitialized() {
+		return nil, "", &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	lc, ent, err := l.findDeployedEntry(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	relPath := filepath.ToSlash(lc.entryRelativePath(ent))
+	commits, err := l.git.Log(limit, relPath)
+	if err != nil {
+		return nil, relPath, WrapError(err, ErrCodeGitCommand, "读取提交记录失败", SeverityError).
+			WithContext("file", relPath)
+	}
+	return commits, relPath, nil
+}
+
+// RestoreFile 将单个被管理文件恢复到指定版本并提交
+func (l *Lnk) RestoreFile(filePath, rev string) error {
+	if !l.IsInitialized() {
+		return &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	lc, ent, err := l.findDeployedEntry(filePath)
+	if err != nil {
+		return err
+	}
+	commit, err := l.git.RevParse(rev)
+	if err != nil {
+		return WrapError(err, ErrCodeGitCommand, "无效的版本: "+rev, SeverityError).
+			WithSuggestion("使用 'zzz lnk log <file>' 查看可用的版本")
+	}
+	relPath := filepath.ToSlash(lc.entryRelativePath(ent))
+	data, err := l.git.ShowFile(commit, relPath)
+	if err != nil {
+		return WrapError(err, ErrCodeGitCommand, "该版本中不存在此文件", SeverityError).
+			WithContext("file", relPath).
+			WithContext("rev", rev)
+	}
+
+	repoFile := lc.entryRepoFilePath(ent)
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(repoFile); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("目录条目不支持按版本恢复: %s", filePath)
+		}
+		mode = info.Mode().Perm()
+	}
+	// 原地写入，硬链接仍指向同一文件
+	if err := os.WriteFile(repoFile, data, mode); err != nil {
+		return fmt.Errorf("写入仓库文件失败: %w", err)
+	}
+
+	switch {
+	case isGeneratedType(ent.Type):
+		if err := lc.entryGenerator()(ent); err != nil {
+			return err
+		}
+	case ent.Type == LinkTypeCopy:
+		sync, save := lc.copySyncer(false)
+		action, err := sync(ent)
+		if err != nil {
+			return err
+		}
+		if err := save(); err != nil {
+			return err
+		}
+		if action == CopyConflict {
+			return newCopyConflictError([]string{ent.Path})
+		}
+	}
+
+	if err := l.git.Add(relPath); err != nil {
+		return WrapError(err, ErrCodeGitCommand, "添加文件到 Git 失败", SeverityError)
+	}
+	short := commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if err := l.git.Commit(fmt.Sprintf("lnk: 恢复 %s 到 %s", filepath.Base(ent.Path), short)); err != nil {
+		return WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError)
+	}
+	l.recordOperation(OpRestore)
+	return nil
+}
+
+type hostEntry struct {
+	host string
+	ent  TrackedEntry
+}
+
+// trackingChanges 对比提交前后的跟踪文件，返回提交新增与移除的条目
+func (l *Lnk) trackingChanges(commit string) (added, removed []hostEntry, err error) {
+	files, err := l.git.CommitFiles(commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	read := func(rev, file string) map[string]TrackedEntry {
+		result := make(map[string]TrackedEntry)
+		data, err := l.git.ShowFile(rev, file)
+		if err != nil {
+			return result
+		}
+		for _, ent := range l.parseTrackingLines(strings.Split(string(data), "\n")) {
+			result[ent.Path] = ent
+		}
+		return result
+	}
+	for _, file := range files {
+		var host string
+		switch {
+		case file == TrackFilename:
+		case strings.HasPrefix(file, TrackFilename+"."):
+			host = strings.TrimPrefix(file, TrackFilename+".")
+		default:
+			continue
+		}
+		before, after := read(commit+"^", file), read(commit, file)
+		for p, ent := range after {
+			if _, ok := before[p]; !ok {
+				added = append(added, hostEntry{host: host, ent: ent})
+			}
+		}
+		for p, ent := range before {
+			if _, ok := after[p]; !ok {
+				removed = append(removed, hostEntry{host: host, ent: ent})
+			}
+		}
+	}
+	return added, removed, nil
+}
+
+// Undo 撤销本机最近一次 add/rm/push/restore/sync 操作：反向提交该操作的变更，
+// 撤销添加时文件恢复为普通文件，撤销移除时重新链接，撤销已推送的操作时同时推送
+func (l *Lnk) Undo() (*UndoResult, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	ops, err := l.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	head, err := l.git.RevParse("HEAD")
+	if err != nil {
+		return nil, WrapError(err, ErrCodeGitCommand, "读取当前版本失败", SeverityError)
+	}
+	// 已不在当前分支上的记录（如被重置）无法撤销
+	for len(ops) > 0 && !l.git.IsAncestor(ops[len(ops)-1].Commit, head) {
+		ops = ops[:len(ops)-1]
+	}
+	if len(ops) == 0 {
+		return nil, NewStructuredError(ErrCodeGitCommand, "没有可撤销的操作", SeverityWarning).
+			WithSuggestion("只能撤销本机通过 lnk 执行的 add/rm/push/restore/sync 操作")
+	}
+	op := ops[len(ops)-1]
+	title, _ := l.git.GetCommitTitle(op.Commit)
+	result := &UndoResult{Operation: op, Title: title}
+
+	added, removed, err := l.trackingChanges(op.Commit)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeGitCommand, "读取操作内容失败", SeverityError)
+	}
+
+	var rollbackActions []func() error
+	rollback := func() {
+		for i := len(rollbackActions) - 1; i >= 0; i-- {
+			if err := rollbackActions[i](); err != nil {
+				l.printf("回滚操作失败: %v\n", err)
+			}
+		}
+	}
+
+	// 撤销添加：在仓库文件被删除前移回原位置
+	for _, he := range added {
+		if he.ent.Type == LinkTypeCopy {
+			continue
+		}
+		lc := l.forHost(he.host)
+		absPath := trackedToAbsPath(he.ent.Path)
+		linkTarget := lc.entryLinkTarget(he.ent)
+		info, err := lc.fs.GetFileInfo(linkTarget)
+		if err != nil {
+			continue
+		}
+		if lc.fs.FileExists(absPath) || lc.fs.IsSymlink(absPath) {
+			if err := os.Remove(absPath); err != nil {
+				rollback()
+				return nil, fmt.Errorf("删除链接 %s 失败: %w", absPath, err)
+			}
+			ent := he.ent
+			rollbackActions = append(rollbackActions, func() error {
+				if ent.Type == LinkTypeHard {
+					return lc.fs.CreateHardlink(linkTarget, absPath)
+				}
+				return lc.fs.CreateSymlink(linkTarget, absPath)
+			})
+		}
+		if err := lc.fs.Move(linkTarget, absPath, info); err != nil {
+			rollback()
+			return nil, fmt.Errorf("恢复原始文件 %s 失败: %w", absPath, err)
+		}
+		rollbackActions = append(rollbackActions, func() error {
+			return lc.fs.Move(absPath, linkTarget, info)
+		})
+	}
+
+	if err := l.git.Revert(op.Commit); err != nil {
+		rollback()
+		return nil, WrapError(err, ErrCodeGitCommand, "撤销提交失败", SeverityError).
+			WithContext("commit", op.Commit).
+			WithSuggestion("请先提交或推送本地的修改后重试")
+	}
+	if err := l.git.Commit(fmt.Sprintf("lnk: 撤销 %s (%s)", op.Op, title)); err != nil {
+		rollback()
+		return nil, WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError)
+	}
+	l.cache.Clear()
+
+	if err := l.saveHistory(ops[:len(ops)-1]); err != nil {
+		l.printf("保存操作记录失败: %v\n", err)
+	}
+
+	var errors []string
+	// 撤销移除：仓库文件已恢复，内容相同的本地文件直接替换为链接
+	byHost := make(map[string][]TrackedEntry)
+	var hosts []string
+	for _, he := range removed {
+		if _, ok := byHost[he.host]; !ok {
+			hosts = append(hosts, he.host)
+		}
+		byHost[he.host] = append(byHost[he.host], he.ent)
+		lc := l.forHost(he.host)
+		absPath := trackedToAbsPath(he.ent.Path)
+		if he.ent.Type == LinkTypeCopy || lc.fs.IsSymlink(absPath) || lc.fs.IsDir(absPath) {
+			continue
+		}
+		if lc.fs.FileExists(lc.entryRepoFilePath(he.ent)) && !isGeneratedType(he.ent.Type) {
+			if a, _ := fileHash(absPath); a != "" {
+				if b, _ := fileHash(lc.entryRepoFilePath(he.ent)); a == b {
+					_ = os.Remove(absPath)
+				}
+			}
+		}
+	}
+	for _, h := range hosts {
+		_, errs := l.forHost(h).restoreEntries(byHost[h])
+		errors = append(errors, errs...)
+	}
+	// 内容变更需要重新生成、同步
+	if err := l.RestoreSymlinks(); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	if (op.Op == OpPush || op.Op == OpSync) && l.git.HasRemote() {
+		if err := l.git.Push(); err != nil {
+			errors = append(errors, fmt.Sprintf("推送到远程仓库失败: %v", err))
+		} else {
+			result.Pushed = true
+		}
+	}
+
+	if len(errors) > 0 {
+		return result, fmt.Errorf("已撤销 %s，但部分文件处理失败:\n%s", op.Op, strings.Join(errors, "\n"))
+	}
+	return result, nil
+}
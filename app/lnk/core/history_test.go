@@ -0,0 +1,95 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	if data, err := os.ReadFile(path); err != nil || string(data) != want {
+		t.Fatalf("expected %s to contain %q, got %q (%v)", path, want, data, err)
+	}
+}
+
+func TestFileLogRestoreAndUndo(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	target := filepath.Join(t.TempDir(), "app.conf")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	writeFile(t, target, "v2\n")
+	if committed, _ := lnk.Push("update app.conf"); !committed {
+		t.Fatal("expected push to commit the change")
+	}
+
+	commits, relPath, err := lnk.FileLog(target, 0)
+	if err != nil {
+		t.Fatalf("FileLog failed: %v", err)
+	}
+	if relPath != "app.conf" || len(commits) != 2 || commits[0].Subject != "update app.conf" {
+		t.Fatalf("unexpected log for %s: %+v", relPath, commits)
+	}
+
+	if err := lnk.RestoreFile(target, commits[1].Hash); err != nil {
+		t.Fatalf("RestoreFile failed: %v", err)
+	}
+	assertContent(t, target, "v1\n")
+
+	for _, want := range []struct{ op, content string }{{OpRestore, "v2\n"}, {OpPush, "v1\n"}} {
+		result, err := lnk.Undo()
+		if err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+		if result.Op != want.op {
+			t.Fatalf("expected to undo %s, got %s", want.op, result.Op)
+		}
+		assertContent(t, target, want.content)
+	}
+
+	result, err := lnk.Undo()
+	if err != nil || result.Op != OpAdd {
+		t.Fatalf("expected to undo add, got %+v (%v)", result, err)
+	}
+	if lnk.fs.IsSymlink(target) {
+		t.Fatal("expected a regular file after undoing add")
+	}
+	assertContent(t, target, "v1\n")
+	assertNotExists(t, filepath.Join(repoDir, "app.conf"))
+	if managed, _ := lnk.isFileManaged(lnk.toTrackingPath(target)); managed {
+		t.Fatal("expected file to be unmanaged after undoing add")
+	}
+
+	if _, err := lnk.Undo(); err == nil {
+		t.Fatal("expected nothing left to undo")
+	}
+}
+
+func TestUndoRemoveRelinksFile(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	target := filepath.Join(t.TempDir(), "app.conf")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := lnk.Remove(target); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	result, err := lnk.Undo()
+	if err != nil || result.Op != OpRemove {
+		t.Fatalf("expected to undo rm, got %+v (%v)", result, err)
+	}
+	if !lnk.fs.IsSymlink(target) {
+		t.Fatal("expected file to be linked again")
+	}
+	assertContent(t, target, "v1\n")
+	assertContent(t, filepath.Join(repoDir, "app.conf"), "v1\n")
+	assertNotExists(t, target+".lnk.backup")
+	if managed, _ := lnk.isFileManaged(lnk.toTrackingPath(target)); !managed {
+		t.Fatal("expected file to be managed after undoing rm")
+	}
+}
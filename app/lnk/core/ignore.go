@@ -0,0 +1,130 @@
+package core
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFilename 递归添加时的忽略规则，位于仓库根目录，语法与 .gitignore 相同，
+// 路径相对于用户主目录（与跟踪文件中的路径一致）
+const IgnoreFilename = ".lnkignore"
+
+type ignoreRule struct {
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	segments []string
+}
+
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+func parseIgnoreRules(content string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// 未转义的行尾空格被忽略
+		if !strings.HasSuffix(line, "\\ ") {
+			line = strings.TrimRight(line, " \t")
+		}
+		var r ignoreRule
+		switch {
+		case strings.HasPrefix(line, "!"):
+			r.negate = true
+			line = line[1:]
+		case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// 开头或中间包含 / 的规则相对于主目录，否则匹配任意层级的名称
+		r.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		r.segments = strings.Split(line, "/")
+		m.rules = append(m.rules, r)
+	}
+	return m
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func (r ignoreRule) match(parts []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		return matchSegments(r.segments, parts[len(parts)-1:])
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// matchPath 按规则顺序匹配，最后一条匹配的规则生效
+func (m *ignoreMatcher) matchPath(parts []string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.match(parts, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// Ignored 判断路径是否被忽略，上级目录被忽略时其中的文件无法再被包含
+func (m *ignoreMatcher) Ignored(relPath string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	parts := strings.Split(strings.Trim(filepath.ToSlash(relPath), "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchPath(parts[:i], true) {
+			return true
+		}
+	}
+	return m.matchPath(parts, isDir)
+}
+
+// loadIgnore 读取仓库的 .lnkignore，文件不存在时不忽略任何路径
+func (l *Lnk) loadIgnore() (*ignoreMatcher, error) {
+	content, err := os.ReadFile(filepath.Join(l.repoPath, IgnoreFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ignoreMatcher{}, nil
+		}
+		return nil, WrapError(err, ErrCodeFileOperation, "读取 "+IgnoreFilename+" 失败", SeverityError)
+	}
+	return parseIgnoreRules(string(content)), nil
+}
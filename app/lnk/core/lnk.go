@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -22,8 +23,11 @@ const (
 )
 
 const (
-	LinkTypeSoft = "soft"
-	LinkTypeHard = "hard"
+	LinkTypeSoft      = "soft"
+	LinkTypeHard      = "hard"
+	LinkTypeEncrypted = "encrypted"
+	LinkTypeTemplate  = "template"
+	LinkTypeCopy      = "copy"
 )
 
 type Lnk struct {
@@ -35,6 +39,10 @@ type Lnk struct {
 	resources    *ResourceManager
 	cache        *TrackingCache
 	linkType     string
+	keyFile      string
+	maxFileSize  int64
+	allowSecrets bool
+	output       io.Writer
 }
 
 func (l *Lnk) readTrackingEntries() ([]TrackedEntry, error) {
@@ -85,11 +93,7 @@ func (l *Lnk) addToTrackingFileWithType(filePath, typ string) error {
 			return nil
 		}
 	}
-	t := LinkTypeSoft
-	if typ == LinkTypeHard {
-		t = LinkTypeHard
-	}
-	entries = append(entries, TrackedEntry{Path: filePath, Type: t})
+	entries = append(entries, TrackedEntry{Path: filePath, Type: normalizeLinkType(typ)})
 	return l.writeTrackingEntries(entries)
 }
 
@@ -98,22 +102,22 @@ type TrackedEntry struct {
 	Type string
 }
 
+func normalizeLinkType(t string) string {
+	switch t {
+	case LinkTypeHard, LinkTypeEncrypted, LinkTypeTemplate, LinkTypeCopy:
+		return t
+	}
+	return LinkTypeSoft
+}
+
 func WithLinkType(t string) Option {
 	return func(l *Lnk) {
-		if t != LinkTypeHard {
-			l.linkType = LinkTypeSoft
-		} else {
-			l.linkType = LinkTypeHard
-		}
+		l.linkType = normalizeLinkType(t)
 	}
 }
 
 func (l *Lnk) SetLinkType(t string) {
-	if t != LinkTypeHard {
-		l.linkType = LinkTypeSoft
-	} else {
-		l.linkType = LinkTypeHard
-	}
+	l.linkType = normalizeLinkType(t)
 }
 
 func (l *Lnk) parseTrackingLines(lines []string) []TrackedEntry {
@@ -127,12 +131,7 @@ func (l *Lnk) parseTrackingLines(lines []string) []TrackedEntry {
 		p := strings.TrimSpace(parts[0])
 		t := LinkTypeSoft
 		if len(parts) == 2 {
-			tt := strings.TrimSpace(parts[1])
-			if tt == LinkTypeHard {
-				t = LinkTypeHard
-			} else {
-				t = LinkTypeSoft
-			}
+			t = normalizeLinkType(strings.TrimSpace(parts[1]))
 		}
 		entries = append(entries, TrackedEntry{Path: p, Type: t})
 	}
@@ -145,11 +144,7 @@ func (l *Lnk) serializeTrackingEntries(entries []TrackedEntry) []string {
 		if e.Path == "" {
 			continue
 		}
-		lt := e.Type
-		if lt != LinkTypeHard {
-			lt = LinkTypeSoft
-		}
-		out = append(out, fmt.Sprintf("%s|%s", e.Path, lt))
+		out = append(out, fmt.Sprintf("%s|%s", e.Path, normalizeLinkType(e.Type)))
 	}
 	return out
 }
@@ -297,6 +292,7 @@ func (l *Lnk) consolidateDirectory(dirPath string) error {
 		return WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError).
 			WithContext("commit_message", commitMsg)
 	}
+	l.recordOperation(OpAdd)
 
 	rollbackActions = nil
 	return nil
@@ -401,6 +397,8 @@ func NewLnk(opts ...Option) *Lnk {
 		resources:    NewResourceManager(),
 		cache:        NewTrackingCache(),
 		linkType:     LinkTypeSoft,
+		maxFileSize:  DefaultMaxFileSize,
+		output:       os.Stdout,
 	}
 
 	for _, opt := range opts {
@@ -433,6 +431,17 @@ func WithHost(host string) Option {
 	}
 }
 
+// WithOutput 设置备份、清理等提示信息的输出位置，默认为标准输出
+func WithOutput(w io.Writer) Option {
+	return func(l *Lnk) {
+		l.output = w
+	}
+}
+
+func (l *Lnk) printf(format string, a ...interface{}) {
+	fmt.Fprintf(l.output, format, a...)
+}
+
 func (l *Lnk) GetRepoPath() string {
 	return l.repoPath
 }
@@ -826,7 +835,7 @@ func (l *Lnk) initWithRemote(remoteURL string, force bool, runBootstrap bool) er
 
 	if runBootstrap {
 		if err := l.runBootstrapIfExists(); err != nil {
-			fmt.Printf("警告: bootstrap 脚本执行失败: %v\n", err)
+			l.printf("警告: bootstrap 脚本执行失败: %v\n", err)
 		}
 	}
 
@@ -871,6 +880,15 @@ func (l *Lnk) hasAnyLnkFiles() bool {
 }
 
 func (l *Lnk) runBootstrapIfExists() error {
+	// 存在引导清单时不再执行 bootstrap.sh
+	if _, err := l.FindBootstrapManifest(); err == nil {
+		plan, err := l.PlanBootstrap(false)
+		if err != nil {
+			return err
+		}
+		return l.RunBootstrap(plan)
+	}
+
 	bootstrapScript := filepath.Join(l.repoPath, "bootstrap.sh")
 
 	if !l.fs.FileExists(bootstrapScript) {
@@ -923,6 +941,15 @@ func (l *Lnk) FindBootstrapScript() (string, error) {
 }
 
 func (l *Lnk) Add(filePath string) error {
+	switch l.linkType {
+	case LinkTypeEncrypted:
+		return l.AddEncrypted([]string{filePath})
+	case LinkTypeTemplate:
+		return l.AddTemplate([]string{filePath})
+	case LinkTypeCopy:
+		return l.AddCopy([]string{filePath})
+	}
+
 	normalizedPath := l.normalizeFilePath(filePath)
 
 	if err := l.fs.ValidateFileForAdd(normalizedPath); err != nil {
@@ -1045,6 +1072,7 @@ func (l *Lnk) Add(filePath string) error {
 			WithSuggestion("请检查 Git 配置和仓库状态").
 			WithRecoverable(true)
 	}
+	l.recordOperation(OpAdd)
 
 	l.errorHandler.ClearRollback()
 
@@ -1055,6 +1083,14 @@ func (l *Lnk) AddMultiple(filePaths []string) error {
 	if len(filePaths) == 0 {
 		return fmt.Errorf("文件路径列表不能为空")
 	}
+	switch l.linkType {
+	case LinkTypeEncrypted:
+		return l.AddEncrypted(filePaths)
+	case LinkTypeTemplate:
+		return l.AddTemplate(filePaths)
+	case LinkTypeCopy:
+		return l.AddCopy(filePaths)
+	}
 
 	var validPaths []string
 	for _, filePath := range filePaths {
@@ -1090,7 +1126,7 @@ func (l *Lnk) AddMultiple(filePaths []string) error {
 	rollback := func() {
 		for i := len(rollbackActions) - 1; i >= 0; i-- {
 			if err := rollbackActions[i](); err != nil {
-				fmt.Printf("回滚操作失败: %v\n", err)
+				l.printf("回滚操作失败: %v\n", err)
 			}
 		}
 	}
@@ -1169,6 +1205,7 @@ func (l *Lnk) AddMultiple(filePaths []string) error {
 		rollback()
 		return fmt.Errorf("提交变更失败: %w", err)
 	}
+	l.recordOperation(OpAdd)
 
 	return nil
 }
@@ -1180,8 +1217,24 @@ func (l *Lnk) AddRecursive(paths []string) error {
 type ProgressCallback func(current, total int, currentFile string)
 
 func (l *Lnk) AddRecursiveWithProgress(paths []string, progressCallback ProgressCallback) error {
+	_, err := l.AddRecursiveWithReport(paths, progressCallback)
+	return err
+}
+
+// AddRecursiveWithReport 递归添加目录中的文件，跳过被 .lnkignore 忽略、特殊、过大与二进制的文件，
+// 文件中包含私钥或令牌时拒绝添加（加密添加或 WithAllowSecrets 除外）
+func (l *Lnk) AddRecursiveWithReport(paths []string, progressCallback ProgressCallback) (*AddReport, error) {
 	if len(paths) == 0 {
-		return fmt.Errorf("路径列表不能为空")
+		return nil, fmt.Errorf("路径列表不能为空")
+	}
+
+	ignore, err := l.loadIgnore()
+	if err != nil {
+		return nil, err
+	}
+	report := &AddReport{}
+	skip := func(path, reason string) {
+		report.Skipped = append(report.Skipped, SkippedFile{Path: path, Reason: reason})
 	}
 
 	var allFiles []string
@@ -1190,42 +1243,83 @@ func (l *Lnk) AddRecursiveWithProgress(paths []string, progressCallback Progress
 
 		if l.fs.IsDir(normalizedPath) {
 
-			files, err := l.collectFilesRecursively(normalizedPath)
+			files, err := l.collectFilesRecursively(normalizedPath, ignore, skip)
 			if err != nil {
-				return fmt.Errorf("收集目录 %s 中的文件失败: %w", path, err)
+				return report, fmt.Errorf("收集目录 %s 中的文件失败: %w", path, err)
 			}
 			allFiles = append(allFiles, files...)
+		} else if ignore.Ignored(l.toTrackingPath(normalizedPath), false) {
+			skip(normalizedPath, SkipIgnored)
 		} else {
 			allFiles = append(allFiles, normalizedPath)
 		}
 	}
 
 	if len(allFiles) == 0 {
-		return fmt.Errorf("没有找到需要添加的文件")
+		return report, fmt.Errorf("没有找到需要添加的文件")
 	}
 
 	var validFiles []string
+	var secrets []SecretFinding
 	for _, filePath := range allFiles {
 
+		if info, err := os.Stat(filePath); err == nil && isSpecialFile(info) {
+			skip(filePath, SkipSpecial)
+			continue
+		}
 		if err := l.fs.ValidateFileForAdd(filePath); err != nil {
+			skip(filePath, SkipInvalid)
 			continue
 		}
 
 		managed, err := l.isFileManaged(l.toTrackingPath(filePath))
 		if err != nil {
+			skip(filePath, SkipInvalid)
 			continue
 		}
 		if managed {
+			skip(filePath, SkipManaged)
 			continue
 		}
 
+		reason, found, err := l.guardFile(filePath)
+		if err != nil {
+			skip(filePath, SkipInvalid)
+			continue
+		}
+		if reason != "" {
+			skip(filePath, reason)
+			continue
+		}
+		secrets = append(secrets, found...)
+
 		validFiles = append(validFiles, filePath)
 	}
 
+	if len(secrets) > 0 && !l.allowSecrets {
+		return report, newSecretError(secrets)
+	}
 	if len(validFiles) == 0 {
-		return fmt.Errorf("没有需要添加的文件（所有文件都已被管理或无效）")
+		return report, fmt.Errorf("没有需要添加的文件（所有文件都已被管理、被忽略或无效）")
+	}
+	switch l.linkType {
+	case LinkTypeEncrypted:
+		err = l.AddEncrypted(validFiles)
+	case LinkTypeTemplate:
+		err = l.AddTemplate(validFiles)
+	case LinkTypeCopy:
+		err = l.AddCopy(validFiles)
+	default:
+		err = l.addFilesWithProgress(validFiles, progressCallback)
 	}
+	if err != nil {
+		return report, err
+	}
+	report.Added = validFiles
+	return report, nil
+}
 
+func (l *Lnk) addFilesWithProgress(validFiles []string, progressCallback ProgressCallback) error {
 	if err := l.ensureHostDir(); err != nil {
 		return fmt.Errorf("创建主机目录失败: %w", err)
 	}
@@ -1236,7 +1330,7 @@ func (l *Lnk) AddRecursiveWithProgress(paths []string, progressCallback Progress
 	rollback := func() {
 		for i := len(rollbackActions) - 1; i >= 0; i-- {
 			if err := rollbackActions[i](); err != nil {
-				fmt.Printf("回滚操作失败: %v\n", err)
+				l.printf("回滚操作失败: %v\n", err)
 			}
 		}
 	}
@@ -1323,7 +1417,7 @@ func (l *Lnk) AddRecursiveWithProgress(paths []string, progressCallback Progress
 	return nil
 }
 
-func (l *Lnk) collectFilesRecursively(dirPath string) ([]string, error) {
+func (l *Lnk) collectFilesRecursively(dirPath string, ignore *ignoreMatcher, skip func(path, reason string)) ([]string, error) {
 	var files []string
 
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
@@ -1331,6 +1425,14 @@ func (l *Lnk) collectFilesRecursively(dirPath string) ([]string, error) {
 			return nil
 		}
 
+		if ignore.Ignored(l.toTrackingPath(path), info.IsDir()) {
+			skip(path, SkipIgnored)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if info.IsDir() {
 			return nil
 		}
@@ -1372,11 +1474,12 @@ func (l *Lnk) Remove(filePath string) error {
 		return &FileNotManagedError{FilePath: normalizedPath}
 	}
 
-	if !l.fs.IsSymlink(normalizedPath) {
+	ent, _ := l.findTrackedEntry(trackKey)
+	if ent.Type != LinkTypeCopy && !l.fs.IsSymlink(normalizedPath) {
 		return fmt.Errorf("不是符号链接: %s", normalizedPath)
 	}
 
-	repoFilePath := l.getRepoFilePath(trackKey)
+	repoFilePath := l.entryLinkTarget(ent)
 
 	if !l.fs.FileExists(repoFilePath) {
 		return fmt.Errorf("仓库中的文件不存在: %s", repoFilePath)
@@ -1391,35 +1494,47 @@ func (l *Lnk) Remove(filePath string) error {
 	rollback := func() {
 		for i := len(rollbackActions) - 1; i >= 0; i-- {
 			if err := rollbackActions[i](); err != nil {
-				fmt.Printf("回滚操作失败: %v\n", err)
+				l.printf("回滚操作失败: %v\n", err)
 			}
 		}
 	}
 
-	if err := l.fs.RemoveFile(normalizedPath); err != nil {
-		return fmt.Errorf("删除符号链接失败: %w", err)
-	}
-	rollbackActions = append(rollbackActions, func() error {
-		return l.fs.CreateSymlink(repoFilePath, normalizedPath)
-	})
+	if ent.Type == LinkTypeCopy && l.fs.FileExists(normalizedPath) {
+		// 复制文件本地已是普通文件，只移除仓库中的副本
+		if err := os.Remove(repoFilePath); err != nil {
+			return fmt.Errorf("删除仓库文件失败: %w", err)
+		}
+		rollbackActions = append(rollbackActions, func() error {
+			return l.fs.CopyFile(normalizedPath, repoFilePath)
+		})
+	} else {
+		if ent.Type != LinkTypeCopy {
+			if err := l.fs.RemoveFile(normalizedPath); err != nil {
+				return fmt.Errorf("删除符号链接失败: %w", err)
+			}
+			rollbackActions = append(rollbackActions, func() error {
+				return l.fs.CreateSymlink(repoFilePath, normalizedPath)
+			})
+		}
 
-	if err := l.fs.Move(repoFilePath, normalizedPath, fileInfo); err != nil {
-		rollback()
-		return fmt.Errorf("恢复原始文件失败: %w", err)
+		if err := l.fs.Move(repoFilePath, normalizedPath, fileInfo); err != nil {
+			rollback()
+			return fmt.Errorf("恢复原始文件失败: %w", err)
+		}
+		rollbackActions = append(rollbackActions, func() error {
+			return l.fs.Move(normalizedPath, repoFilePath, fileInfo)
+		})
 	}
-	rollbackActions = append(rollbackActions, func() error {
-		return l.fs.Move(normalizedPath, repoFilePath, fileInfo)
-	})
 
 	if err := l.removeFromTrackingFile(trackKey); err != nil {
 		rollback()
 		return fmt.Errorf("从跟踪文件中移除失败: %w", err)
 	}
 	rollbackActions = append(rollbackActions, func() error {
-		return l.addToTrackingFile(trackKey)
+		return l.addToTrackingFileWithType(trackKey, ent.Type)
 	})
 
-	relPath := l.getRelativePathInRepo(trackKey)
+	relPath := l.entryRelativePath(ent)
 	if err := l.git.Remove(relPath); err != nil {
 		rollback()
 		return fmt.Errorf("从 Git 中移除文件失败: %w", err)
@@ -1440,6 +1555,7 @@ func (l *Lnk) Remove(filePath string) error {
 		rollback()
 		return fmt.Errorf("提交变更失败: %w", err)
 	}
+	l.recordOperation(OpRemove)
 
 	return nil
 }
@@ -1476,7 +1592,7 @@ func (l *Lnk) RemoveMultiple(filePaths []string) error {
 		}
 
 		// Tolerant check
-		if !l.fs.IsSymlink(abs) {
+		if ent, _ := l.findTrackedEntry(tk); ent.Type != LinkTypeCopy && !l.fs.IsSymlink(abs) {
 			return fmt.Errorf("文件 %s 不是符号链接", inPath)
 		}
 
@@ -1488,7 +1604,7 @@ func (l *Lnk) RemoveMultiple(filePaths []string) error {
 	rollback := func() {
 		for i := len(rollbackActions) - 1; i >= 0; i-- {
 			if err := rollbackActions[i](); err != nil {
-				fmt.Printf("回滚操作失败: %v\n", err)
+				l.printf("回滚操作失败: %v\n", err)
 			}
 		}
 	}
@@ -1497,7 +1613,8 @@ func (l *Lnk) RemoveMultiple(filePaths []string) error {
 		filePath := it.abs
 		trackKey := it.tk
 
-		repoFilePath := l.getRepoFilePath(trackKey)
+		ent, _ := l.findTrackedEntry(trackKey)
+		repoFilePath := l.entryLinkTarget(ent)
 
 		if !l.fs.FileExists(repoFilePath) {
 			rollback()
@@ -1510,37 +1627,52 @@ func (l *Lnk) RemoveMultiple(filePaths []string) error {
 			return fmt.Errorf("获取仓库文件 %s 信息失败: %w", filePath, err)
 		}
 
-		if err := l.fs.RemoveFile(filePath); err != nil {
-			rollback()
-			return fmt.Errorf("删除符号链接 %s 失败: %w", filePath, err)
-		}
-		rollbackActions = append(rollbackActions, func(path, repoPath string) func() error {
-			return func() error {
-				return l.fs.CreateSymlink(repoPath, path)
+		if ent.Type == LinkTypeCopy && l.fs.FileExists(filePath) {
+			// 复制文件本地已是普通文件，只移除仓库中的副本
+			if err := os.Remove(repoFilePath); err != nil {
+				rollback()
+				return fmt.Errorf("删除仓库文件 %s 失败: %w", repoFilePath, err)
+			}
+			rollbackActions = append(rollbackActions, func(path, repoPath string) func() error {
+				return func() error {
+					return l.fs.CopyFile(path, repoPath)
+				}
+			}(filePath, repoFilePath))
+		} else {
+			if ent.Type != LinkTypeCopy {
+				if err := l.fs.RemoveFile(filePath); err != nil {
+					rollback()
+					return fmt.Errorf("删除符号链接 %s 失败: %w", filePath, err)
+				}
+				rollbackActions = append(rollbackActions, func(path, repoPath string) func() error {
+					return func() error {
+						return l.fs.CreateSymlink(repoPath, path)
+					}
+				}(filePath, repoFilePath))
 			}
-		}(filePath, repoFilePath))
 
-		if err := l.fs.Move(repoFilePath, filePath, fileInfo); err != nil {
-			rollback()
-			return fmt.Errorf("恢复原始文件 %s 失败: %w", filePath, err)
-		}
-		rollbackActions = append(rollbackActions, func(path, repoPath string, info os.FileInfo) func() error {
-			return func() error {
-				return l.fs.Move(path, repoPath, info)
+			if err := l.fs.Move(repoFilePath, filePath, fileInfo); err != nil {
+				rollback()
+				return fmt.Errorf("恢复原始文件 %s 失败: %w", filePath, err)
 			}
-		}(filePath, repoFilePath, fileInfo))
+			rollbackActions = append(rollbackActions, func(path, repoPath string, info os.FileInfo) func() error {
+				return func() error {
+					return l.fs.Move(path, repoPath, info)
+				}
+			}(filePath, repoFilePath, fileInfo))
+		}
 
 		if err := l.removeFromTrackingFile(trackKey); err != nil {
 			rollback()
 			return fmt.Errorf("从跟踪文件中移除 %s 失败: %w", trackKey, err)
 		}
-		rollbackActions = append(rollbackActions, func(path string) func() error {
+		rollbackActions = append(rollbackActions, func(ent TrackedEntry) func() error {
 			return func() error {
-				return l.addToTrackingFile(path)
+				return l.addToTrackingFileWithType(ent.Path, ent.Type)
 			}
-		}(trackKey))
+		}(ent))
 
-		relPath := l.getRelativePathInRepo(trackKey)
+		relPath := l.entryRelativePath(ent)
 		if err := l.git.Remove(relPath); err != nil {
 			rollback()
 			return fmt.Errorf("从 Git 中移除文件 %s 失败: %w", filePath, err)
@@ -1564,6 +1696,7 @@ func (l *Lnk) RemoveMultiple(filePaths []string) error {
 		rollback()
 		return fmt.Errorf("提交变更失败: %w", err)
 	}
+	l.recordOperation(OpRemove)
 
 	return nil
 }
@@ -1678,69 +1811,68 @@ func (l *Lnk) Status() (*StatusInfo, error) {
 		}
 	}
 
+	// 先同步复制文件，Git 状态才能反映本地的修改
+	copyChanges, err := l.SyncCopies()
+	if err != nil {
+		return nil, fmt.Errorf("同步复制文件失败: %w", err)
+	}
+	if copyChanges == nil {
+		copyChanges = make([]CopySyncResult, 0)
+	}
+
 	gitStatus, err := l.git.GetStatus()
 	if err != nil {
 		return nil, fmt.Errorf("获取 Git 状态失败: %w", err)
 	}
 
-	entries, err := l.readTrackingEntries()
+	groups, err := l.layerGroups()
 	if err != nil {
 		return nil, fmt.Errorf("获取管理文件列表失败: %w", err)
 	}
 
-	brokenLinks := l.checkSymlinkStatus(entries)
+	var (
+		layers      []string
+		entries     []LayeredEntry
+		details     = make([]EntryDetail, 0)
+		brokenLinks = make([]string, 0)
+	)
+	for _, g := range groups {
+		layers = append(layers, g.name)
+		for _, ent := range g.entries {
+			entries = append(entries, LayeredEntry{TrackedEntry: ent, Layer: g.name})
+			d := g.lnk.entryDetail(ent, g.name, true)
+			details = append(details, d)
+			if d.Health != HealthOK {
+				brokenLinks = append(brokenLinks, d.Target)
+			}
+		}
+	}
 
 	return &StatusInfo{
-		RepoPath:     l.repoPath,
-		Host:         l.host,
-		GitStatus:    gitStatus,
-		ManagedFiles: len(entries),
-		BrokenLinks:  brokenLinks,
+		RepoPath:      l.repoPath,
+		Host:          l.host,
+		Layers:        layers,
+		GitStatus:     gitStatus,
+		ManagedFiles:  len(entries),
+		Entries:       entries,
+		Details:       details,
+		BrokenLinks:   brokenLinks,
+		CopyChanges:   copyChanges,
+		CopyConflicts: copyConflicts(copyChanges),
 	}, nil
 }
 
 type StatusInfo struct {
-	RepoPath     string
-	Host         string
-	GitStatus    *git.StatusInfo
-	ManagedFiles int
-	BrokenLinks  []string
-}
-
-func (l *Lnk) checkSymlinkStatus(entries []TrackedEntry) []string {
-	var brokenLinks []string
-
-	for _, ent := range entries {
-		trackKey := ent.Path
-
-		absPath := trackKey
-		if !filepath.IsAbs(trackKey) {
-			if homeDir, err := os.UserHomeDir(); err == nil {
-				absPath = filepath.Join(homeDir, strings.TrimPrefix(filepath.Clean(trackKey), "./"))
-			}
-		}
-
-		if !l.fs.FileExists(absPath) {
-			brokenLinks = append(brokenLinks, absPath)
-			continue
-		}
-		if ent.Type == LinkTypeHard {
-			// 对硬链接：不做符号链接校验，只要存在即认为正常
-			continue
-		}
-		// 软链接校验
-		if !l.fs.IsSymlink(absPath) {
-			brokenLinks = append(brokenLinks, absPath)
-			continue
-		}
-		target, err := l.fs.ReadSymlink(absPath)
-		if err != nil || !l.fs.FileExists(target) {
-			brokenLinks = append(brokenLinks, absPath)
-			continue
-		}
-	}
-
-	return brokenLinks
+	RepoPath      string           `json:"repo_path" yaml:"repo_path"`
+	Host          string           `json:"host" yaml:"host"`
+	Layers        []string         `json:"layers" yaml:"layers"`
+	GitStatus     *git.StatusInfo  `json:"git" yaml:"git"`
+	ManagedFiles  int              `json:"managed_files" yaml:"managed_files"`
+	Entries       []LayeredEntry   `json:"-" yaml:"-"`
+	Details       []EntryDetail    `json:"entries" yaml:"entries"`
+	BrokenLinks   []string         `json:"broken_links" yaml:"broken_links"`
+	CopyChanges   []CopySyncResult `json:"copy_changes" yaml:"copy_changes"`
+	CopyConflicts []string         `json:"copy_conflicts" yaml:"copy_conflicts"`
 }
 
 func (l *Lnk) GetManagedFileCount() (int, error) {
@@ -1795,7 +1927,7 @@ func (l *Lnk) ValidateRepository() error {
 	var errors []string
 	for _, ent := range entries {
 		filePath := ent.Path
-		repoFilePath := l.getRepoFilePath(filePath)
+		repoFilePath := l.entryRepoFilePath(ent)
 
 		if !l.fs.FileExists(repoFilePath) {
 			errors = append(errors, fmt.Sprintf("仓库文件不存在: %s", repoFilePath))
@@ -1814,6 +1946,12 @@ func (l *Lnk) ValidateRepository() error {
 			}
 			continue
 		}
+		if ent.Type == LinkTypeCopy {
+			if !l.fs.FileExists(absPath) {
+				errors = append(errors, fmt.Sprintf("复制文件不存在: %s", absPath))
+			}
+			continue
+		}
 		if !l.fs.FileExists(absPath) {
 			errors = append(errors, fmt.Sprintf("符号链接不存在: %s", absPath))
 			continue
@@ -1827,7 +1965,7 @@ func (l *Lnk) ValidateRepository() error {
 			errors = append(errors, fmt.Sprintf("符号链接目标不存在或无效: %s", absPath))
 			continue
 		}
-		if target != repoFilePath {
+		if target != l.entryLinkTarget(ent) {
 			errors = append(errors, fmt.Sprintf("符号链接目标不正确: %s", absPath))
 		}
 	}
@@ -1846,6 +1984,18 @@ func (l *Lnk) Push(message string) (bool, error) {
 		}
 	}
 
+	if _, err := l.encryptChanged(); err != nil {
+		return false, fmt.Errorf("加密变更失败: %w", err)
+	}
+
+	copyChanges, err := l.SyncCopies()
+	if err != nil {
+		return false, fmt.Errorf("同步复制文件失败: %w", err)
+	}
+	if conflicts := copyConflicts(copyChanges); len(conflicts) > 0 {
+		return false, newCopyConflictError(conflicts)
+	}
+
 	if err := l.git.AddAll(); err != nil {
 		return false, fmt.Errorf("暂存变更失败: %w", err)
 	}
@@ -1854,6 +2004,7 @@ func (l *Lnk) Push(message string) (bool, error) {
 		message = "lnk: 自动同步变更"
 	}
 	committed := true
+	before, _ := l.git.RevParse("HEAD")
 	if err := l.git.Commit(message); err != nil {
 		em := err.Error()
 		if !(strings.Contains(em, "nothing to commit") || strings.Contains(em, "无文件要提交") || strings.Contains(em, "干净的工作区")) {
@@ -1861,6 +2012,9 @@ func (l *Lnk) Push(message string) (bool, error) {
 		}
 		committed = false
 	}
+	if after, _ := l.git.RevParse("HEAD"); committed && after != before {
+		l.recordOperation(OpPush)
+	}
 
 	if !l.git.HasRemote() {
 		return committed, fmt.Errorf("没有配置远程仓库，无法推送")
@@ -1873,26 +2027,10 @@ func (l *Lnk) Push(message string) (bool, error) {
 	return committed, nil
 }
 
+// Pull 从远程仓库拉取并恢复链接，跟踪文件以外的文件冲突时取消拉取
 func (l *Lnk) Pull() error {
-	if !l.IsInitialized() {
-		return &RepoNotInitializedError{
-			RepoPath: l.repoPath,
-		}
-	}
-
-	if !l.git.HasRemote() {
-		return fmt.Errorf("没有配置远程仓库，无法拉取")
-	}
-
-	if err := l.git.Pull(); err != nil {
-		return fmt.Errorf("从远程仓库拉取失败: %w", err)
-	}
-
-	if err := l.RestoreSymlinks(); err != nil {
-		return fmt.Errorf("恢复符号链接失败: %w", err)
-	}
-
-	return nil
+	_, err := l.PullWithResolver(nil)
+	return err
 }
 
 func (l *Lnk) RestoreSymlinks() error {
@@ -1902,17 +2040,40 @@ func (l *Lnk) RestoreSymlinks() error {
 		}
 	}
 
-	entries, err := l.readTrackingEntries()
+	groups, err := l.layerGroups()
 	if err != nil {
 		return fmt.Errorf("获取管理文件列表失败: %w", err)
 	}
 
-	if len(entries) == 0 {
-		return nil
+	var errors []string
+	restoredCount := 0
+	for _, g := range groups {
+		n, errs := g.lnk.restoreEntries(g.entries)
+		restoredCount += n
+		errors = append(errors, errs...)
 	}
+	if len(errors) > 0 {
+		return fmt.Errorf("恢复符号链接时发生错误 (成功: %d, 失败: %d):\n%s",
+			restoredCount, len(errors), strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
 
+// restoreEntries 恢复当前主机目录中的条目，返回成功数量与错误信息
+func (l *Lnk) restoreEntries(entries []TrackedEntry) (int, []string) {
 	var errors []string
 	restoredCount := 0
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	generate := l.entryGenerator()
+	syncCopy, saveCopyState := l.copySyncer(true)
+	defer func() {
+		if err := saveCopyState(); err != nil {
+			l.printf("保存同步状态失败: %v\n", err)
+		}
+	}()
 
 	for _, ent := range entries {
 		trackKey := ent.Path
@@ -1924,13 +2085,33 @@ func (l *Lnk) RestoreSymlinks() error {
 			}
 		}
 
-		repoFilePath := l.getRepoFilePath(trackKey)
+		if !l.fs.FileExists(l.entryRepoFilePath(ent)) {
+			errors = append(errors, fmt.Sprintf("仓库文件不存在: %s", l.entryRepoFilePath(ent)))
+			continue
+		}
 
-		if !l.fs.FileExists(repoFilePath) {
-			errors = append(errors, fmt.Sprintf("仓库文件不存在: %s", repoFilePath))
+		if ent.Type == LinkTypeCopy {
+			action, err := syncCopy(ent)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("同步复制文件失败 %s: %v", absPath, err))
+				continue
+			}
+			if action == CopyConflict {
+				errors = append(errors, fmt.Sprintf("复制文件冲突 %s: 本地与仓库都被修改，请使用 'zzz lnk resolve' 处理", absPath))
+				continue
+			}
+			restoredCount++
 			continue
 		}
 
+		if isGeneratedType(ent.Type) {
+			if err := generate(ent); err != nil {
+				errors = append(errors, fmt.Sprintf("生成文件失败 %s: %v", absPath, err))
+				continue
+			}
+		}
+		repoFilePath := l.entryLinkTarget(ent)
+
 		if l.fs.IsSymlink(absPath) {
 			target, err := l.fs.ReadSymlink(absPath)
 			if err == nil && target == repoFilePath {
@@ -1952,7 +2133,7 @@ func (l *Lnk) RestoreSymlinks() error {
 				errors = append(errors, fmt.Sprintf("创建备份失败 %s: %v", absPath, err))
 				continue
 			}
-			fmt.Printf("已备份现有文件: %s -> %s\n", absPath, backupPath)
+			l.printf("已备份现有文件: %s -> %s\n", absPath, backupPath)
 		} else if l.fs.FileExists(absPath) {
 			if err := l.fs.RemoveFile(absPath); err != nil {
 				errors = append(errors, fmt.Sprintf("删除错误符号链接失败 %s: %v", absPath, err))
@@ -1980,12 +2161,8 @@ func (l *Lnk) RestoreSymlinks() error {
 			}
 		}
 	}
-	if len(errors) > 0 {
-		return fmt.Errorf("恢复符号链接时发生错误 (成功: %d, 失败: %d):\n%s",
-			restoredCount, len(errors), strings.Join(errors, "\n"))
-	}
 
-	return nil
+	return restoredCount, errors
 }
 
 func (l *Lnk) RestoreSymlinksForHost(hostName string) error {
@@ -1995,6 +2172,13 @@ func (l *Lnk) RestoreSymlinksForHost(hostName string) error {
 		}
 	}
 
+	// 组合主机按层恢复
+	if layers, err := l.forHost(hostName).ProfileLayers(); err != nil {
+		return err
+	} else if len(layers) > 1 {
+		return l.forHost(hostName).RestoreSymlinks()
+	}
+
 	entries, err := l.readTrackingEntries()
 	if err != nil {
 		return fmt.Errorf("获取管理文件列表失败: %w", err)
@@ -2012,6 +2196,13 @@ func (l *Lnk) RestoreSymlinksForHost(hostName string) error {
 
 	var errors []string
 	restoredCount := 0
+	generate := l.entryGenerator()
+	syncCopy, saveCopyState := l.copySyncer(true)
+	defer func() {
+		if err := saveCopyState(); err != nil {
+			l.printf("保存同步状态失败: %v\n", err)
+		}
+	}()
 
 	for _, ent := range entries {
 		trackKey := ent.Path
@@ -2023,7 +2214,27 @@ func (l *Lnk) RestoreSymlinksForHost(hostName string) error {
 			}
 		}
 
-		repoFilePath := l.getRepoFilePath(trackKey)
+		if ent.Type == LinkTypeCopy {
+			action, err := syncCopy(ent)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("同步复制文件失败 %s: %v", absPath, err))
+				continue
+			}
+			if action == CopyConflict {
+				errors = append(errors, fmt.Sprintf("复制文件冲突 %s: 本地与仓库都被修改，请使用 'zzz lnk resolve' 处理", absPath))
+				continue
+			}
+			restoredCount++
+			continue
+		}
+
+		if isGeneratedType(ent.Type) {
+			if err := generate(ent); err != nil {
+				errors = append(errors, fmt.Sprintf("生成文件失败 %s: %v", absPath, err))
+				continue
+			}
+		}
+		repoFilePath := l.entryLinkTarget(ent)
 
 		// 若目标是硬链接，并且现有文件已是指向仓库文件的硬链接，则跳过
 		if ent.Type == LinkTypeHard && l.fs.FileExists(absPath) {
@@ -2074,7 +2285,7 @@ func (l *Lnk) RestoreSymlinksForHost(hostName string) error {
 	}
 
 	if len(errors) > 0 {
-		fmt.Printf("警告: 恢复主机 %s 的符号链接时发生 %d 个错误 (成功: %d):\n%s\n请检查 Windows 符号链接权限（启用开发者模式或以管理员身份运行）。\n",
+		l.printf("警告: 恢复主机 %s 的符号链接时发生 %d 个错误 (成功: %d):\n%s\n请检查 Windows 符号链接权限（启用开发者模式或以管理员身份运行）。\n",
 			hostName, len(errors), restoredCount, strings.Join(errors, "\n"))
 		return nil
 	}
@@ -2099,12 +2310,12 @@ func (l *Lnk) CleanupInvalidEntries() error {
 
 	for _, ent := range entries {
 		filePath := ent.Path
-		repoFilePath := l.getRepoFilePath(filePath)
+		repoFilePath := l.entryRepoFilePath(ent)
 
 		if l.fs.FileExists(repoFilePath) {
 			validEntries = append(validEntries, ent)
 		} else {
-			fmt.Printf("移除无效条目: %s (仓库文件不存在: %s)\n", filePath, repoFilePath)
+			l.printf("移除无效条目: %s (仓库文件不存在: %s)\n", filePath, repoFilePath)
 			removedCount++
 		}
 	}
@@ -2115,9 +2326,9 @@ func (l *Lnk) CleanupInvalidEntries() error {
 		}
 		l.cache.Clear()
 
-		fmt.Printf("清理完成: 移除了 %d 个无效条目，保留了 %d 个有效条目\n", removedCount, len(validEntries))
+		l.printf("清理完成: 移除了 %d 个无效条目，保留了 %d 个有效条目\n", removedCount, len(validEntries))
 	} else {
-		fmt.Println("没有发现无效条目")
+		l.printf("没有发现无效条目\n")
 	}
 
 	return nil
@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sohaha/zzz/app/lnk/git"
+	"github.com/sohaha/zzz/app/lnk/secret"
+)
+
+// 解决冲突时保留的版本
+const (
+	PreferLocal  = "local"
+	PreferRemote = "remote"
+)
+
+// MergeConflict 合并冲突的文件，Base/Local/Remote 为 nil 表示该版本中不存在此文件
+type MergeConflict struct {
+	// Path 仓库内的相对路径
+	Path string
+	// Target 在当前主机上的部署位置，不是当前主机部署的文件时为空
+	Target string
+	Type   string
+	Base   []byte
+	Local  []byte
+	Remote []byte
+	// Merged 工作区中带冲突标记的合并结果
+	Merged []byte
+	// Encrypted 加密文件，各版本均为解密后的内容，解决后的内容会重新加密
+	Encrypted bool
+
+	key                       secret.Key
+	localCipher, remoteCipher []byte
+}
+
+// ConflictResolver 返回冲突文件解决后的内容，返回 nil 表示删除该文件
+type ConflictResolver func(c *MergeConflict) ([]byte, error)
+
+// PreferResolver 返回总是保留本地（local）或远程（remote）版本的解决方式
+func PreferResolver(side string) (ConflictResolver, error) {
+	switch side {
+	case PreferLocal:
+		return func(c *MergeConflict) ([]byte, error) { return c.Local, nil }, nil
+	case PreferRemote:
+		return func(c *MergeConflict) ([]byte, error) { return c.Remote, nil }, nil
+	}
+	return nil, fmt.Errorf("无效的版本: %s，可选: local|remote", side)
+}
+
+func isTrackingFile(relPath string) bool {
+	return relPath == TrackFilename || strings.HasPrefix(relPath, TrackFilename+".")
+}
+
+// conflictTarget 查找仓库文件在当前主机上的部署位置
+func (l *Lnk) conflictTarget(relPath string) (string, string) {
+	groups, err := l.layerGroups()
+	if err != nil {
+		return "", ""
+	}
+	relPath = filepath.FromSlash(relPath)
+	for _, g := range groups {
+		for _, ent := range g.entries {
+			entRel := g.lnk.entryRelativePath(ent)
+			switch {
+			case entRel == relPath:
+				return trackedToAbsPath(ent.Path), ent.Type
+			case strings.HasPrefix(relPath, entRel+string(filepath.Separator)):
+				return filepath.Join(trackedToAbsPath(ent.Path), strings.TrimPrefix(relPath, entRel)), ent.Type
+			}
+		}
+	}
+	return "", ""
+}
+
+// MergeConflicts 返回当前合并中未解决的冲突文件
+func (l *Lnk) MergeConflicts() ([]*MergeConflict, error) {
+	files, err := l.git.ConflictFiles()
+	if err != nil {
+		return nil, WrapError(err, ErrCodeGitCommand, "读取冲突文件失败", SeverityError)
+	}
+	var (
+		key    secret.Key
+		keyErr error
+	)
+	conflicts := make([]*MergeConflict, 0, len(files))
+	for _, file := range files {
+		c := &MergeConflict{Path: file}
+		c.Target, c.Type = l.conflictTarget(file)
+		c.Base, _ = l.git.ShowStage(1, file)
+		c.Local, _ = l.git.ShowStage(2, file)
+		c.Remote, _ = l.git.ShowStage(3, file)
+		c.Merged, _ = os.ReadFile(filepath.Join(l.repoPath, filepath.FromSlash(file)))
+		if isEncryptedConflict(c) {
+			if key == nil && keyErr == nil {
+				key, keyErr = l.loadKey()
+			}
+			// 没有密钥时保留密文，仍可选择保留某一方的版本
+			if keyErr == nil {
+				l.decryptConflict(c, key)
+			}
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, nil
+}
+
+func isEncryptedConflict(c *MergeConflict) bool {
+	found := false
+	for _, data := range [][]byte{c.Base, c.Local, c.Remote} {
+		if data == nil {
+			continue
+		}
+		if !secret.IsEncrypted(data) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// decryptConflict 解密冲突的各个版本，并用解密后的内容重新生成合并结果；任一版本无法解密时保持不变
+func (l *Lnk) decryptConflict(c *MergeConflict, key secret.Key) {
+	versions := []*[]byte{&c.Base, &c.Local, &c.Remote}
+	plain := make([][]byte, len(versions))
+	for i, v := range versions {
+		if *v == nil {
+			continue
+		}
+		p, err := secret.Decrypt(key, *v)
+		if err != nil {
+			return
+		}
+		plain[i] = p
+	}
+
+	c.localCipher, c.remoteCipher = c.Local, c.Remote
+	c.Base, c.Local, c.Remote = plain[0], plain[1], plain[2]
+	c.Encrypted, c.key = true, key
+	switch {
+	case c.Local == nil:
+		c.Merged = c.Remote
+	case c.Remote == nil:
+		c.Merged = c.Local
+	default:
+		merged, err := l.mergeContent(c.Local, c.Base, c.Remote)
+		if err != nil {
+			merged = c.Local
+		}
+		c.Merged = merged
+	}
+}
+
+// mergeContent 三方合并内容，有冲突时结果中包含冲突标记
+func (l *Lnk) mergeContent(local, base, remote []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "lnk-merge-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string][]byte{"local": local, "base": base, "remote": remote} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return l.git.MergeFiles(filepath.Join(dir, "local"), filepath.Join(dir, "base"), filepath.Join(dir, "remote"))
+}
+
+// sealConflict 重新加密解决后的内容，与某一方相同时沿用其密文，避免产生无意义的变更
+func sealConflict(c *MergeConflict, content []byte) ([]byte, error) {
+	switch {
+	case c.Local != nil && bytes.Equal(content, c.Local):
+		return c.localCipher, nil
+	case c.Remote != nil && bytes.Equal(content, c.Remote):
+		return c.remoteCipher, nil
+	}
+	return secret.Encrypt(c.key, content)
+}
+
+// mergeTrackingContent 按条目三方合并跟踪文件：保留双方新增的条目，任一方删除的条目被删除，
+// 同一条目类型不同时以修改过的一方为准
+func (l *Lnk) mergeTrackingContent(c *MergeConflict) []byte {
+	parse := func(data []byte) []TrackedEntry {
+		return l.parseTrackingLines(strings.Split(string(data), "\n"))
+	}
+	index := func(entries []TrackedEntry) map[string]string {
+		m := make(map[string]string, len(entries))
+		for _, e := range entries {
+			m[e.Path] = e.Type
+		}
+		return m
+	}
+	local, remote := parse(c.Local), parse(c.Remote)
+	baseTypes, localTypes, remoteTypes := index(parse(c.Base)), index(local), index(remote)
+
+	var merged []TrackedEntry
+	for _, e := range local {
+		baseType, inBase := baseTypes[e.Path]
+		remoteType, inRemote := remoteTypes[e.Path]
+		switch {
+		case !inRemote && inBase && baseType == e.Type:
+			continue
+		case inRemote && e.Type == baseType:
+			e.Type = remoteType
+		}
+		merged = append(merged, e)
+	}
+	for _, e := range remote {
+		if _, ok := localTypes[e.Path]; ok {
+			continue
+		}
+		if baseType, inBase := baseTypes[e.Path]; inBase && baseType == e.Type {
+			continue
+		}
+		merged = append(merged, e)
+	}
+
+	content := strings.Join(l.serializeTrackingEntries(merged), "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return []byte(content)
+}
+
+func newPullConflictError(files []string) error {
+	return NewStructuredError(ErrCodeGitMergeConflict, fmt.Sprintf("%d 个文件在本地与远程仓库中都被修改，已取消拉取", len(files)), SeverityError).
+		WithContext("files", files).
+		WithSuggestion("请在终端中运行 'zzz lnk pull' 逐个解决，或使用 --prefer local|remote 选择保留的版本")
+}
+
+// resolveMerge 解决当前合并中的冲突并提交，跟踪文件自动合并，
+// 其余文件交给 resolve 处理；resolve 为 nil 或返回错误时中止合并
+func (l *Lnk) resolveMerge(resolve ConflictResolver) ([]string, error) {
+	conflicts, err := l.MergeConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, c := range conflicts {
+		if !isTrackingFile(c.Path) {
+			pending = append(pending, c.Path)
+		}
+	}
+	abort := func(err error) ([]string, error) {
+		if abortErr := l.git.MergeAbort(); abortErr != nil {
+			l.printf("中止合并失败: %v\n", abortErr)
+		}
+		return nil, err
+	}
+	if len(pending) > 0 && resolve == nil {
+		return abort(newPullConflictError(pending))
+	}
+
+	resolved := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		var content []byte
+		if isTrackingFile(c.Path) {
+			content = l.mergeTrackingContent(c)
+		} else if content, err = resolve(c); err != nil {
+			return abort(err)
+		}
+		if c.Encrypted && content != nil {
+			if content, err = sealConflict(c, content); err != nil {
+				return abort(WrapError(err, ErrCodeSecretKey, "加密文件失败", SeverityError).
+					WithContext("file", c.Path))
+			}
+		}
+
+		file := filepath.Join(l.repoPath, filepath.FromSlash(c.Path))
+		if content == nil {
+			if err := l.git.Remove(c.Path); err != nil {
+				return abort(WrapError(err, ErrCodeGitCommand, "删除冲突文件失败", SeverityError).
+					WithContext("file", c.Path))
+			}
+		} else {
+			if err := os.WriteFile(file, content, 0o644); err != nil {
+				return abort(fmt.Errorf("写入 %s 失败: %w", c.Path, err))
+			}
+			if err := l.git.Add(c.Path); err != nil {
+				return abort(WrapError(err, ErrCodeGitCommand, "添加文件到 Git 失败", SeverityError).
+					WithContext("file", c.Path))
+			}
+		}
+		resolved = append(resolved, c.Path)
+	}
+
+	message := "lnk: 合并远程更新"
+	if len(resolved) > 0 {
+		message = fmt.Sprintf("lnk: 合并远程更新，解决 %d 个冲突", len(resolved))
+	}
+	if err := l.git.Commit(message); err != nil {
+		return abort(WrapError(err, ErrCodeGitCommand, "提交合并失败", SeverityError))
+	}
+	return resolved, nil
+}
+
+// PullWithResolver 从远程仓库拉取并合并，冲突通过 resolve 逐个解决，完成后恢复链接；
+// 仓库中有未完成的合并时直接解决该合并。返回解决了冲突的文件
+func (l *Lnk) PullWithResolver(resolve ConflictResolver) ([]string, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{
+			RepoPath: l.repoPath,
+		}
+	}
+
+	var resolved []string
+	if l.git.MergeInProgress() {
+		r, err := l.resolveMerge(resolve)
+		if err != nil {
+			return nil, err
+		}
+		resolved = r
+	} else {
+		if !l.git.HasRemote() {
+			return nil, fmt.Errorf("没有配置远程仓库，无法拉取")
+		}
+		if err := l.git.Pull(); err != nil {
+			var conflict *git.MergeConflictError
+			if !errors.As(err, &conflict) {
+				return nil, fmt.Errorf("从远程仓库拉取失败: %w", err)
+			}
+			r, err := l.resolveMerge(resolve)
+			if err != nil {
+				return nil, err
+			}
+			resolved = r
+		}
+	}
+	l.cache.Clear()
+
+	if err := l.RestoreSymlinks(); err != nil {
+		return resolved, fmt.Errorf("恢复符号链接失败: %w", err)
+	}
+	return resolved, nil
+}
+
+// ConflictDiff 返回共同祖先到本地、到远程版本的差异，加密文件比较解密后的内容，二进制文件只给出提示
+func (l *Lnk) ConflictDiff(c *MergeConflict, color bool) (string, string, error) {
+	dir, err := os.MkdirTemp("", "lnk-merge-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string, data []byte) (string, error) {
+		p := filepath.Join(dir, name)
+		return p, os.WriteFile(p, data, 0o600)
+	}
+	base, err := write("base", c.Base)
+	if err != nil {
+		return "", "", err
+	}
+	diff := func(name string, data []byte) (string, error) {
+		switch {
+		case data == nil:
+			return "(文件已删除)\n", nil
+		case bytes.IndexByte(data, 0) >= 0 || bytes.IndexByte(c.Base, 0) >= 0:
+			return fmt.Sprintf("(二进制文件，%d 字节)\n", len(data)), nil
+		}
+		p, err := write(name, data)
+		if err != nil {
+			return "", err
+		}
+		return l.git.DiffFiles(base, p, color)
+	}
+	local, err := diff("local", c.Local)
+	if err != nil {
+		return "", "", err
+	}
+	remote, err := diff("remote", c.Remote)
+	if err != nil {
+		return "", "", err
+	}
+	return local, remote, nil
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zzz/app/lnk/secret"
+)
+
+func TestPullResolvesConflicts(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, repoDir, "init", "--bare", remote)
+	runGit(t, repoDir, "remote", "add", "origin", remote)
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app.conf")
+	writeFile(t, target, "base\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	runGit(t, repoDir, "push", "-u", "origin", "HEAD")
+
+	// 远程：修改 app.conf 并新增一个条目
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, repoDir, "clone", remote, other)
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test")
+	remoteTarget := filepath.Join(dir, "remote.conf")
+	remoteKey := lnk.toTrackingPath(remoteTarget)
+	remoteRel := lnk.getRelativePathInRepo(remoteKey)
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(other, remoteRel)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(other, remoteRel), "from remote\n")
+	writeFile(t, filepath.Join(other, "app.conf"), "remote\n")
+	tracking, _ := os.ReadFile(filepath.Join(other, TrackFilename))
+	writeFile(t, filepath.Join(other, TrackFilename), string(tracking)+remoteKey+"|soft\n")
+	runGit(t, other, "add", "-A")
+	runGit(t, other, "commit", "-m", "remote change")
+	runGit(t, other, "push")
+
+	// 本地：修改 app.conf 并新增另一个条目
+	localTarget := filepath.Join(dir, "local.conf")
+	writeFile(t, localTarget, "from local\n")
+	if err := lnk.Add(localTarget); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	writeFile(t, target, "local\n")
+	runGit(t, repoDir, "commit", "-am", "local change")
+
+	err := lnk.Pull()
+	var se StructuredError
+	if !errors.As(err, &se) || se.Code() != ErrCodeGitMergeConflict {
+		t.Fatalf("expected merge conflict error, got %v", err)
+	}
+	if files, _ := se.Context()["files"].([]string); len(files) != 1 || files[0] != "app.conf" {
+		t.Fatalf("unexpected conflict files: %v", se.Context()["files"])
+	}
+	assertNotExists(t, filepath.Join(repoDir, ".git", "MERGE_HEAD"))
+	assertContent(t, target, "local\n")
+
+	resolve, err := PreferResolver(PreferRemote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := lnk.PullWithResolver(resolve)
+	if err != nil {
+		t.Fatalf("PullWithResolver failed: %v", err)
+	}
+	if strings.Join(resolved, ",") != TrackFilename+",app.conf" {
+		t.Fatalf("unexpected resolved files: %v", resolved)
+	}
+	assertNotExists(t, filepath.Join(repoDir, ".git", "MERGE_HEAD"))
+	assertContent(t, target, "remote\n")
+	assertContent(t, localTarget, "from local\n")
+	assertContent(t, remoteTarget, "from remote\n")
+
+	for _, key := range []string{lnk.toTrackingPath(target), lnk.toTrackingPath(localTarget), remoteKey} {
+		if managed, _ := lnk.isFileManaged(key); !managed {
+			t.Fatalf("expected %s to be managed after merge", key)
+		}
+	}
+}
+
+func TestPullResolvesEncryptedConflict(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithKeyFile(filepath.Join(t.TempDir(), "lnk.key")))
+	if _, err := lnk.InitKey("", false); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+	key, err := lnk.loadKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, repoDir, "init", "--bare", remote)
+	runGit(t, repoDir, "remote", "add", "origin", remote)
+
+	target := filepath.Join(t.TempDir(), "token.conf")
+	writeFile(t, target, "user=a\npassword=base\n")
+	lnk.SetLinkType(LinkTypeEncrypted)
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	runGit(t, repoDir, "push", "-u", "origin", "HEAD")
+	encRel := filepath.ToSlash(lnk.entryRelativePath(TrackedEntry{Path: lnk.toTrackingPath(target), Type: LinkTypeEncrypted}))
+
+	encrypt := func(plaintext string) string {
+		data, err := secret.Encrypt(key, []byte(plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, repoDir, "clone", remote, other)
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(other, encRel), encrypt("user=a\npassword=remote\n"))
+	runGit(t, other, "commit", "-am", "remote change")
+	runGit(t, other, "push")
+
+	writeFile(t, filepath.Join(repoDir, encRel), encrypt("user=a\npassword=local\n"))
+	runGit(t, repoDir, "commit", "-am", "local change")
+
+	var seen *MergeConflict
+	resolved, err := lnk.PullWithResolver(func(c *MergeConflict) ([]byte, error) {
+		seen = c
+		return []byte("user=a\npassword=merged\n"), nil
+	})
+	if err != nil {
+		t.Fatalf("PullWithResolver failed: %v", err)
+	}
+	if len(resolved) != 1 || resolved[0] != encRel {
+		t.Fatalf("unexpected resolved files: %v", resolved)
+	}
+	if !seen.Encrypted || string(seen.Local) != "user=a\npassword=local\n" || string(seen.Remote) != "user=a\npassword=remote\n" {
+		t.Fatalf("expected decrypted versions, got local=%q remote=%q", seen.Local, seen.Remote)
+	}
+	if !strings.Contains(string(seen.Merged), "<<<<<<<") || !strings.Contains(string(seen.Merged), "password=remote") {
+		t.Fatalf("expected plaintext conflict markers, got %q", seen.Merged)
+	}
+
+	ciphertext, err := os.ReadFile(filepath.Join(repoDir, encRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := secret.Decrypt(key, ciphertext)
+	if err != nil {
+		t.Fatalf("resolved file is not valid ciphertext: %v", err)
+	}
+	if string(plaintext) != "user=a\npassword=merged\n" {
+		t.Fatalf("unexpected resolved content: %q", plaintext)
+	}
+	assertContent(t, target, "user=a\npassword=merged\n")
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// ProfilesFilename 主机配置组合文件，位于仓库根目录
+	ProfilesFilename = ".lnk-profiles.yaml"
+	// GeneralLayer 表示仓库根目录的通用配置（.lnk）
+	GeneralLayer = "general"
+)
+
+// LayeredEntry 带来源层的跟踪条目
+type LayeredEntry struct {
+	TrackedEntry
+	Layer string
+}
+
+// layerGroup 某一层中实际部署的条目（未被后面的层覆盖）
+type layerGroup struct {
+	name    string
+	lnk     *Lnk
+	entries []TrackedEntry
+}
+
+func layerName(host string) string {
+	if host == "" || host == "localhost" {
+		return GeneralLayer
+	}
+	return host
+}
+
+func layerHost(name string) string {
+	if name == GeneralLayer {
+		return ""
+	}
+	return name
+}
+
+// forHost 返回使用指定主机的副本，其余配置与当前实例相同
+func (l *Lnk) forHost(host string) *Lnk {
+	if layerName(host) == layerName(l.host) {
+		return l
+	}
+	c := *l
+	c.host = host
+	return &c
+}
+
+// loadProfiles 读取组合配置，键为组合名（小写），值为按顺序叠加的层
+func (l *Lnk) loadProfiles() (map[string][]string, error) {
+	file := filepath.Join(l.repoPath, ProfilesFilename)
+	if !l.fs.FileExists(file) {
+		return nil, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, WrapError(err, ErrCodeProfile, "读取主机组合配置失败", SeverityError).
+			WithContext("file", file)
+	}
+	profiles := make(map[string][]string)
+	for name := range v.AllSettings() {
+		profiles[name] = v.GetStringSlice(name)
+	}
+	return profiles, nil
+}
+
+// ProfileLayers 返回当前主机按顺序叠加的层，未定义组合时只有主机自身；
+// 组合自身的主机目录总是参与叠加，未在列表中出现时作为最后一层
+func (l *Lnk) ProfileLayers() ([]string, error) {
+	profiles, err := l.loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		layers []string
+		seen   = make(map[string]bool)
+		stack  = make(map[string]bool)
+	)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			layers = append(layers, name)
+		}
+	}
+	var expand func(name string) error
+	expand = func(name string) error {
+		members, ok := profiles[strings.ToLower(name)]
+		if !ok {
+			add(name)
+			return nil
+		}
+		stack[name] = true
+		defer delete(stack, name)
+		self := false
+		for _, m := range members {
+			if m == name {
+				self = true
+				add(m)
+				continue
+			}
+			if stack[m] {
+				return NewStructuredError(ErrCodeProfile, fmt.Sprintf("主机组合 %s 存在循环引用: %s", name, m), SeverityError).
+					WithSuggestion("请检查 " + ProfilesFilename)
+			}
+			if err := expand(m); err != nil {
+				return err
+			}
+		}
+		if !self {
+			add(name)
+		}
+		return nil
+	}
+	if err := expand(layerName(l.host)); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// layerGroups 按层读取跟踪条目，同一路径以后面的层为准
+func (l *Lnk) layerGroups() ([]layerGroup, error) {
+	layers, err := l.ProfileLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]layerGroup, 0, len(layers))
+	owner := make(map[string]int)
+	for i, name := range layers {
+		lc := l.forHost(layerHost(name))
+		entries, err := lc.readTrackingEntries()
+		if err != nil {
+			return nil, fmt.Errorf("读取层 %s 的跟踪文件失败: %w", name, err)
+		}
+		for _, ent := range entries {
+			owner[ent.Path] = i
+		}
+		groups = append(groups, layerGroup{name: name, lnk: lc, entries: entries})
+	}
+	for i := range groups {
+		var kept []TrackedEntry
+		for _, ent := range groups[i].entries {
+			if owner[ent.Path] == i {
+				kept = append(kept, ent)
+			}
+		}
+		groups[i].entries = kept
+	}
+	return groups, nil
+}
+
+// LayeredEntries 返回当前主机实际部署的条目及其来源层
+func (l *Lnk) LayeredEntries() ([]LayeredEntry, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	groups, err := l.layerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var result []LayeredEntry
+	for _, g := range groups {
+		for _, ent := range g.entries {
+			result = append(result, LayeredEntry{TrackedEntry: ent, Layer: g.name})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newProfileTestRepo(t *testing.T, profiles string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoDir := t.TempDir()
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "user.email", "test@example.com")
+	runGit(t, repoDir, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(repoDir, ProfilesFilename), profiles)
+	return repoDir
+}
+
+func addLayerFile(t *testing.T, repoDir, layer, target, content string) {
+	t.Helper()
+	lnk := NewLnk(WithRepoPath(repoDir), WithHost(layerHost(layer)))
+	repoFile := lnk.getRepoFilePath(target)
+	if err := os.MkdirAll(filepath.Dir(repoFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repoFile, content)
+	if err := lnk.addToTrackingFileWithType(lnk.toTrackingPath(target), LinkTypeSoft); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileLayersOverridePerPath(t *testing.T) {
+	repoDir := newProfileTestRepo(t, "laptop: [general, linux]\n")
+	home := t.TempDir()
+	a, b, c := filepath.Join(home, "a.conf"), filepath.Join(home, "b.conf"), filepath.Join(home, "c.conf")
+	addLayerFile(t, repoDir, GeneralLayer, a, "a")
+	addLayerFile(t, repoDir, GeneralLayer, b, "b-general")
+	addLayerFile(t, repoDir, "linux", b, "b-linux")
+	addLayerFile(t, repoDir, "laptop", c, "c")
+
+	lnk := NewLnk(WithRepoPath(repoDir), WithHost("laptop"))
+	layers, err := lnk.ProfileLayers()
+	if err != nil {
+		t.Fatalf("ProfileLayers failed: %v", err)
+	}
+	if want := []string{GeneralLayer, "linux", "laptop"}; !reflect.DeepEqual(layers, want) {
+		t.Fatalf("expected layers %v, got %v", want, layers)
+	}
+
+	entries, err := lnk.LayeredEntries()
+	if err != nil {
+		t.Fatalf("LayeredEntries failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, e := range entries {
+		got[e.Path] = e.Layer
+	}
+	want := map[string]string{
+		lnk.toTrackingPath(a): GeneralLayer,
+		lnk.toTrackingPath(b): "linux",
+		lnk.toTrackingPath(c): "laptop",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected entries %v, got %v", want, got)
+	}
+
+	if err := lnk.RestoreSymlinks(); err != nil {
+		t.Fatalf("RestoreSymlinks failed: %v", err)
+	}
+	for path, content := range map[string]string{a: "a", b: "b-linux", c: "c"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != content {
+			t.Fatalf("expected %s to contain %q, got %q (%v)", path, content, data, err)
+		}
+	}
+
+	status, err := lnk.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.ManagedFiles != 3 || !reflect.DeepEqual(status.Layers, layers) {
+		t.Fatalf("unexpected status: %d files, layers %v", status.ManagedFiles, status.Layers)
+	}
+}
+
+func TestProfileLayersNestedAndCycles(t *testing.T) {
+	repoDir := newProfileTestRepo(t, "laptop: [linux, work]\nlinux: [general, linux]\nwork: [general]\nloop: [other]\nother: [loop]\n")
+
+	layers, err := NewLnk(WithRepoPath(repoDir), WithHost("laptop")).ProfileLayers()
+	if err != nil {
+		t.Fatalf("ProfileLayers failed: %v", err)
+	}
+	if want := []string{GeneralLayer, "linux", "work", "laptop"}; !reflect.DeepEqual(layers, want) {
+		t.Fatalf("expected layers %v, got %v", want, layers)
+	}
+
+	layers, err = NewLnk(WithRepoPath(repoDir), WithHost("desktop")).ProfileLayers()
+	if err != nil || !reflect.DeepEqual(layers, []string{"desktop"}) {
+		t.Fatalf("expected plain host layer, got %v (%v)", layers, err)
+	}
+
+	_, err = NewLnk(WithRepoPath(repoDir), WithHost("loop")).ProfileLayers()
+	se, ok := err.(StructuredError)
+	if !ok || se.Code() != ErrCodeProfile {
+		t.Fatalf("expected %s error, got %v", ErrCodeProfile, err)
+	}
+}
@@ -16,6 +16,7 @@ type forceRemoveTarget struct {
 	absPath     string
 	trackKey    string
 	repoFile    string
+	linkTarget  string
 	relativeKey string
 }
 
@@ -64,6 +65,7 @@ func (l *Lnk) RemoveForceMultiple(filePaths []string) error {
 	if err := l.git.Commit(buildForceRemoveCommitMessage(targets)); err != nil {
 		return l.rollbackForceRemove(fmt.Errorf("提交变更失败: %w", err), rollback)
 	}
+	l.recordOperation(OpRemove)
 
 	l.cleanupForceRemoveBackups(rollback.backupDir)
 	return nil
@@ -129,7 +131,7 @@ func (l *Lnk) removeSymlinkForForce(target forceRemoveTarget, rollback *forceRem
 	}
 	rollback.removedLinks = append(rollback.removedLinks, removedSymlink{
 		path:   target.absPath,
-		target: target.repoFile,
+		target: target.linkTarget,
 	})
 	return nil
 }
@@ -288,7 +290,8 @@ func (l *Lnk) collectForceRemoveTargets(filePaths []string) ([]forceRemoveTarget
 		if !managed {
 			return nil, &FileNotManagedError{FilePath: absPath}
 		}
-		repoFile := l.getRepoFilePath(trackKey)
+		ent, _ := l.findTrackedEntry(trackKey)
+		repoFile := l.entryRepoFilePath(ent)
 		if err := validateForceRemoveRepoPath(l.repoPath, repoFile); err != nil {
 			return nil, err
 		}
@@ -296,7 +299,8 @@ func (l *Lnk) collectForceRemoveTargets(filePaths []string) ([]forceRemoveTarget
 			absPath:     absPath,
 			trackKey:    trackKey,
 			repoFile:    repoFile,
-			relativeKey: l.getRelativePathInRepo(trackKey),
+			linkTarget:  l.entryLinkTarget(ent),
+			relativeKey: l.entryRelativePath(ent),
 		})
 	}
 	return targets, nil
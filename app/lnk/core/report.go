@@ -0,0 +1,122 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 条目在当前主机上的健康状态
+const (
+	HealthOK      = "ok"
+	HealthBroken  = "broken"
+	HealthInvalid = "invalid"
+)
+
+// EntryDetail 跟踪条目的详细信息，用于结构化输出
+type EntryDetail struct {
+	// Path 跟踪文件中的路径
+	Path string `json:"path" yaml:"path"`
+	// Target 部署位置
+	Target string `json:"target" yaml:"target"`
+	// RepoPath 仓库内的相对路径
+	RepoPath string `json:"repo_path" yaml:"repo_path"`
+	LinkType string `json:"link_type" yaml:"link_type"`
+	// Host 条目所属的主机（层），general 表示通用配置
+	Host string `json:"host" yaml:"host"`
+	// Health 只对当前主机部署的条目计算：ok 正常，broken 链接损坏，invalid 仓库中缺少文件
+	Health string `json:"health,omitempty" yaml:"health,omitempty"`
+}
+
+// entryHealth 与 doctor 使用相同的检查
+func (l *Lnk) entryHealth(ent TrackedEntry) string {
+	single := []TrackedEntry{ent}
+	if len(l.findInvalidEntries(single)) > 0 {
+		return HealthInvalid
+	}
+	if len(l.findBrokenSymlinks(single)) > 0 {
+		return HealthBroken
+	}
+	return HealthOK
+}
+
+func (l *Lnk) entryDetail(ent TrackedEntry, host string, deployed bool) EntryDetail {
+	d := EntryDetail{
+		Path:     ent.Path,
+		Target:   trackedToAbsPath(ent.Path),
+		RepoPath: filepath.ToSlash(l.entryRelativePath(ent)),
+		LinkType: ent.Type,
+		Host:     host,
+	}
+	if deployed {
+		d.Health = l.entryHealth(ent)
+	}
+	return d
+}
+
+// EntryDetails 返回当前主机（组合主机包括各层）实际部署的条目及其健康状态
+func (l *Lnk) EntryDetails() ([]EntryDetail, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	groups, err := l.layerGroups()
+	if err != nil {
+		return nil, err
+	}
+	details := make([]EntryDetail, 0)
+	for _, g := range groups {
+		for _, ent := range g.entries {
+			details = append(details, g.lnk.entryDetail(ent, g.name, true))
+		}
+	}
+	return details, nil
+}
+
+// AllEntryDetails 返回所有主机的条目，只有当前主机部署的条目包含健康状态
+func (l *Lnk) AllEntryDetails() ([]EntryDetail, error) {
+	deployed, err := l.EntryDetails()
+	if err != nil {
+		return nil, err
+	}
+	health := make(map[string]string, len(deployed))
+	for _, d := range deployed {
+		health[d.Host+"|"+d.Path] = d.Health
+	}
+
+	files, err := os.ReadDir(l.repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, f := range files {
+		switch name := f.Name(); {
+		case f.IsDir():
+		case name == TrackFilename:
+			hosts = append(hosts, GeneralLayer)
+		case strings.HasPrefix(name, TrackFilename+"."):
+			hosts = append(hosts, strings.TrimPrefix(name, TrackFilename+"."))
+		}
+	}
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if hosts[i] == GeneralLayer || hosts[j] == GeneralLayer {
+			return hosts[i] == GeneralLayer && hosts[j] != GeneralLayer
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	details := make([]EntryDetail, 0)
+	for _, h := range hosts {
+		lc := l.forHost(layerHost(h))
+		entries, err := lc.readTrackingEntries()
+		if err != nil {
+			return nil, err
+		}
+		for _, ent := range entries {
+			d := lc.entryDetail(ent, h, false)
+			d.Health = health[h+"|"+ent.Path]
+			details = append(details, d)
+		}
+	}
+	return details, nil
+}
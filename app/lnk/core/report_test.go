@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEntryDetailsHealth(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	dir := t.TempDir()
+	okFile := filepath.Join(dir, "ok.conf")
+	brokenFile := filepath.Join(dir, "broken.conf")
+	invalidFile := filepath.Join(dir, "invalid.conf")
+	for _, f := range []string{okFile, brokenFile, invalidFile} {
+		writeFile(t, f, "x\n")
+	}
+	if err := lnk.AddMultiple([]string{okFile, brokenFile, invalidFile}); err != nil {
+		t.Fatalf("AddMultiple failed: %v", err)
+	}
+	if err := os.Remove(brokenFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(lnk.getRepoFilePath(lnk.toTrackingPath(invalidFile))); err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := lnk.EntryDetails()
+	if err != nil {
+		t.Fatalf("EntryDetails failed: %v", err)
+	}
+	health := make(map[string]EntryDetail)
+	for _, d := range details {
+		health[d.Target] = d
+	}
+	for file, want := range map[string]string{okFile: HealthOK, brokenFile: HealthBroken, invalidFile: HealthInvalid} {
+		d, ok := health[file]
+		if !ok || d.Health != want || d.Host != GeneralLayer || d.LinkType != LinkTypeSoft {
+			t.Errorf("unexpected detail for %s: %+v", file, d)
+		}
+	}
+	if d := health[okFile]; filepath.Join(repoDir, filepath.FromSlash(d.RepoPath)) != lnk.getRepoFilePath(d.Path) {
+		t.Errorf("unexpected repo path: %s", d.RepoPath)
+	}
+
+	status, err := lnk.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Details) != 3 || len(status.BrokenLinks) != 2 {
+		t.Fatalf("unexpected status: details=%d broken=%v", len(status.Details), status.BrokenLinks)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StateDir 本机状态（同步哈希、引导记录、操作记录）的存放目录，位于仓库内，
+// 通过 .git/info/exclude 忽略，不会修改共享的 .gitignore
+const StateDir = ".lnk-state"
+
+func (l *Lnk) ensureStateExcluded() error {
+	gitDir := filepath.Join(l.repoPath, ".git")
+	if !l.fs.IsDir(gitDir) {
+		return nil
+	}
+	excludeFile := filepath.Join(gitDir, "info", "exclude")
+	rule := "/" + StateDir + "/"
+	content, err := os.ReadFile(excludeFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取 %s 失败: %w", excludeFile, err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == rule {
+			return nil
+		}
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(rule+"\n")...)
+	if err := os.MkdirAll(filepath.Dir(excludeFile), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return os.WriteFile(excludeFile, content, 0o644)
+}
+
+// readState 读取状态文件，文件不存在时保持 v 不变
+func (l *Lnk) readState(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(l.repoPath, StateDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取状态文件 %s 失败: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析状态文件 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+func (l *Lnk) writeState(name string, v interface{}) error {
+	if err := l.ensureStateExcluded(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.repoPath, StateDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建状态目录失败: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
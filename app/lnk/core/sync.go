@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zzz/app/lnk/git"
+	"github.com/sohaha/zzz/app/watch"
+)
+
+// 同步事件类型
+const (
+	SyncEventSynced  = "synced"
+	SyncEventPaused  = "paused"
+	SyncEventResumed = "resumed"
+	SyncEventError   = "error"
+)
+
+type SyncResult struct {
+	// Committed 本次自动提交的文件（仓库内的相对路径）
+	Committed     []string
+	CopyConflicts []string
+	Pulled        bool
+	Pushed        bool
+}
+
+// Changed 是否有提交、拉取或推送
+func (r *SyncResult) Changed() bool {
+	return len(r.Committed) > 0 || r.Pulled || r.Pushed
+}
+
+type SyncEvent struct {
+	Type   string
+	Result *SyncResult
+	Err    error
+}
+
+type WatchOptions struct {
+	// Quiet 最后一次文件变更后等待多久再提交
+	Quiet time.Duration
+	// Interval 从远程仓库拉取的间隔
+	Interval time.Duration
+	OnEvent  func(SyncEvent)
+}
+
+// syncCommitMessage 根据变更的文件生成提交信息
+func syncCommitMessage(files []string) string {
+	if len(files) == 1 {
+		return fmt.Sprintf("lnk: 自动同步 %s", files[0])
+	}
+	const limit = 20
+	var b strings.Builder
+	fmt.Fprintf(&b, "lnk: 自动同步 %d 个文件\n\n", len(files))
+	for i, f := range files {
+		if i == limit {
+			fmt.Fprintf(&b, "- ... 等 %d 个文件\n", len(files)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	return b.String()
+}
+
+// commitLocalChanges 加密、同步复制文件后提交仓库中的所有变更
+func (l *Lnk) commitLocalChanges(result *SyncResult) error {
+	if _, err := l.encryptChanged(); err != nil {
+		return fmt.Errorf("加密变更失败: %w", err)
+	}
+	copyChanges, err := l.SyncCopies()
+	if err != nil {
+		return fmt.Errorf("同步复制文件失败: %w", err)
+	}
+	// 冲突的复制文件保持不动，不影响其他文件的同步
+	result.CopyConflicts = copyConflicts(copyChanges)
+
+	if err := l.git.AddAll(); err != nil {
+		return fmt.Errorf("暂存变更失败: %w", err)
+	}
+	files, err := l.git.StagedFiles()
+	if err != nil {
+		return fmt.Errorf("读取暂存变更失败: %w", err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	if err := l.git.Commit(syncCommitMessage(files)); err != nil {
+		return WrapError(err, ErrCodeGitCommand, "提交变更失败", SeverityError)
+	}
+	l.recordOperation(OpSync)
+	result.Committed = files
+	return nil
+}
+
+func newSyncConflictError(files []string) error {
+	return NewStructuredError(ErrCodeGitMergeConflict, "本地与远程仓库的修改存在冲突，已暂停自动同步", SeverityError).
+		WithContext("files", files).
+		WithSuggestion("请运行 'zzz lnk pull' 解决冲突，解决后自动同步会继续")
+}
+
+// Sync 提交本地变更，从远程仓库获取更新并合并，合并后恢复链接，最后推送本地提交；
+// 合并冲突时中止合并并返回 ErrCodeGitMergeConflict 错误，仓库保持合并前的状态
+func (l *Lnk) Sync() (*SyncResult, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+
+	result := &SyncResult{}
+	if err := l.commitLocalChanges(result); err != nil {
+		return result, err
+	}
+	if !l.git.HasRemote() {
+		return result, nil
+	}
+
+	if err := l.git.FetchRemote(); err != nil {
+		return result, WrapError(err, ErrCodeGitNetwork, "从远程仓库获取更新失败", SeverityError)
+	}
+	status, err := l.git.GetStatus()
+	if err != nil {
+		return result, WrapError(err, ErrCodeGitCommand, "读取仓库状态失败", SeverityError)
+	}
+
+	if status.Behind > 0 {
+		if err := l.git.MergeRemote(status.Ahead == 0); err != nil {
+			var conflict *git.MergeConflictError
+			if errors.As(err, &conflict) {
+				if abortErr := l.git.MergeAbort(); abortErr != nil {
+					l.printf("中止合并失败: %v\n", abortErr)
+				}
+				return result, newSyncConflictError(conflict.Files)
+			}
+			return result, WrapError(err, ErrCodeGitCommand, "合并远程更新失败", SeverityError)
+		}
+		result.Pulled = true
+		l.cache.Clear()
+		if err := l.RestoreSymlinks(); err != nil {
+			return result, fmt.Errorf("恢复符号链接失败: %w", err)
+		}
+		// 快进后不再领先，产生合并提交时需要推送
+		if status, err = l.git.GetStatus(); err != nil {
+			return result, WrapError(err, ErrCodeGitCommand, "读取仓库状态失败", SeverityError)
+		}
+	}
+
+	if status.Ahead > 0 {
+		if err := l.git.Push(); err != nil {
+			return result, WrapError(err, ErrCodeGitNetwork, "推送到远程仓库失败", SeverityError)
+		}
+		result.Pushed = true
+	}
+
+	return result, nil
+}
+
+// syncConflicted 判断错误是否为合并冲突
+func syncConflicted(err error) bool {
+	var se StructuredError
+	return errors.As(err, &se) && se.Code() == ErrCodeGitMergeConflict
+}
+
+// watchDirs 返回需要监听的目录：仓库中的目录（不含 .git 与本机状态目录）以及复制条目所在的目录
+func (l *Lnk) watchDirs() []string {
+	var dirs []string
+	_ = filepath.Walk(l.repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if name := info.Name(); path != l.repoPath && (name == ".git" || name == StateDir) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if entries, err := l.LayeredEntries(); err == nil {
+		for _, ent := range entries {
+			if ent.Type == LinkTypeCopy {
+				dirs = append(dirs, filepath.Dir(trackedToAbsPath(ent.Path)))
+			}
+		}
+	}
+	return dirs
+}
+
+// syncRelevant 过滤掉 Git 内部文件与本机状态文件的变更
+func (l *Lnk) syncRelevant(name string) bool {
+	rel, err := filepath.Rel(l.repoPath, name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		// 仓库外只有复制条目所在的目录被监听
+		return true
+	}
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return first != ".git" && first != StateDir
+}
+
+// WatchSync 持续监听被管理的文件，变更平息 Quiet 时长后自动提交并推送，
+// 每隔 Interval 从远程仓库拉取；遇到合并冲突时暂停，直到冲突被手动解决
+func (l *Lnk) WatchSync(ctx context.Context, opts WatchOptions) error {
+	if !l.IsInitialized() {
+		return &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	if opts.Quiet <= 0 {
+		opts.Quiet = 10 * time.Second
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Minute
+	}
+	emit := func(e SyncEvent) {
+		if opts.OnEvent != nil {
+			opts.OnEvent(e)
+		}
+	}
+
+	watcher, err := watch.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听失败: %w", err)
+	}
+	defer watcher.Close()
+
+	watched := make(map[string]bool)
+	register := func() {
+		for _, dir := range l.watchDirs() {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err == nil {
+				watched[dir] = true
+			}
+		}
+	}
+	register()
+
+	// 同步期间合并、恢复链接与生成解密文件等写入产生的事件不再触发同步，
+	// 修改时间按秒取整以兼容精度较低的文件系统
+	var syncStart, syncEnd time.Time
+	selfWritten := func(name string) bool {
+		info, err := os.Lstat(name)
+		if err != nil {
+			return false
+		}
+		mt := info.ModTime()
+		return !mt.Before(syncStart) && !mt.After(syncEnd)
+	}
+
+	paused := false
+	run := func() {
+		syncStart = time.Now().Truncate(time.Second)
+		result, err := l.Sync()
+		register()
+		syncEnd = time.Now()
+		switch {
+		case syncConflicted(err):
+			paused = true
+			emit(SyncEvent{Type: SyncEventPaused, Result: result, Err: err})
+		case err != nil:
+			emit(SyncEvent{Type: SyncEventError, Result: result, Err: err})
+		default:
+			emit(SyncEvent{Type: SyncEventSynced, Result: result})
+		}
+	}
+	// 暂停期间只检查冲突是否已解决（本地已包含远程的提交）
+	resolved := func() bool {
+		if err := l.git.FetchRemote(); err != nil {
+			return false
+		}
+		status, err := l.git.GetStatus()
+		return err == nil && status.Behind == 0
+	}
+
+	run()
+
+	quiet := time.NewTimer(opts.Quiet)
+	quiet.Stop()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			quiet.Stop()
+			return nil
+		case ev, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			if l.syncRelevant(ev.Name) && !selfWritten(ev.Name) {
+				pending = true
+				quiet.Reset(opts.Quiet)
+			}
+		case err, ok := <-watcher.Errors():
+			if !ok {
+				return nil
+			}
+			emit(SyncEvent{Type: SyncEventError, Err: err})
+		case <-quiet.C:
+			if pending && !paused {
+				pending = false
+				run()
+			}
+		case <-ticker.C:
+			if paused {
+				if !resolved() {
+					continue
+				}
+				paused = false
+				emit(SyncEvent{Type: SyncEventResumed})
+			}
+			pending = false
+			run()
+		}
+	}
+}
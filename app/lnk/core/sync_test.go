@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncCommitsPullsAndPausesOnConflict(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, repoDir, "init", "--bare", remote)
+	runGit(t, repoDir, "remote", "add", "origin", remote)
+	runGit(t, repoDir, "push", "-u", "origin", "HEAD")
+
+	target := filepath.Join(t.TempDir(), "app.conf")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	writeFile(t, target, "v2\n")
+
+	result, err := lnk.Sync()
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if strings.Join(result.Committed, ",") != "app.conf" || !result.Pushed || result.Pulled {
+		t.Fatalf("unexpected sync result: %+v", result)
+	}
+	if title := runGit(t, repoDir, "log", "-1", "--format=%s"); !strings.Contains(title, "自动同步 app.conf") {
+		t.Fatalf("unexpected commit message: %s", title)
+	}
+
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, repoDir, "clone", remote, other)
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(other, "app.conf"), "v3\n")
+	runGit(t, other, "commit", "-am", "remote change")
+	runGit(t, other, "push")
+
+	result, err = lnk.Sync()
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !result.Pulled || result.Pushed || len(result.Committed) != 0 {
+		t.Fatalf("unexpected sync result: %+v", result)
+	}
+	assertContent(t, target, "v3\n")
+
+	writeFile(t, filepath.Join(other, "app.conf"), "remote\n")
+	runGit(t, other, "commit", "-am", "conflicting change")
+	runGit(t, other, "push")
+	writeFile(t, target, "local\n")
+
+	_, err = lnk.Sync()
+	var se StructuredError
+	if !errors.As(err, &se) || se.Code() != ErrCodeGitMergeConflict {
+		t.Fatalf("expected merge conflict error, got %v", err)
+	}
+	if files, _ := se.Context()["files"].([]string); len(files) != 1 || files[0] != "app.conf" {
+		t.Fatalf("unexpected conflict files: %v", se.Context()["files"])
+	}
+	assertNotExists(t, filepath.Join(repoDir, ".git", "MERGE_HEAD"))
+	assertContent(t, target, "local\n")
+}
+
+func TestSyncPushesMergeCommit(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, repoDir, "init", "--bare", remote)
+	runGit(t, repoDir, "remote", "add", "origin", remote)
+
+	dir := t.TempDir()
+	local, other := filepath.Join(dir, "local.conf"), filepath.Join(dir, "other.conf")
+	writeFile(t, local, "v1\n")
+	writeFile(t, other, "v1\n")
+	if err := lnk.Add(local); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := lnk.Add(other); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	runGit(t, repoDir, "push", "-u", "origin", "HEAD")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, repoDir, "clone", remote, clone)
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(clone, "other.conf"), "remote\n")
+	runGit(t, clone, "commit", "-am", "remote change")
+	runGit(t, clone, "push")
+
+	writeFile(t, local, "local\n")
+	result, err := lnk.Sync()
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !result.Pulled || !result.Pushed {
+		t.Fatalf("unexpected sync result: %+v", result)
+	}
+	assertContent(t, other, "remote\n")
+	if ahead := runGit(t, repoDir, "rev-list", "--count", "@{u}..HEAD"); strings.TrimSpace(ahead) != "0" {
+		t.Fatalf("expected merge commit to be pushed, %s commits ahead", ahead)
+	}
+}
+
+func TestWatchSyncIgnoresOwnWrites(t *testing.T) {
+	lnk, repoDir := newTestLnk(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, repoDir, "init", "--bare", remote)
+	runGit(t, repoDir, "remote", "add", "origin", remote)
+
+	target := filepath.Join(t.TempDir(), "app.conf")
+	writeFile(t, target, "v1\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	runGit(t, repoDir, "push", "-u", "origin", "HEAD")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, repoDir, "clone", remote, clone)
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(clone, "app.conf"), "remote\n")
+	runGit(t, clone, "commit", "-am", "remote change")
+	runGit(t, clone, "push")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	var synced int
+	err := lnk.WatchSync(ctx, WatchOptions{
+		Quiet:    100 * time.Millisecond,
+		Interval: time.Hour,
+		OnEvent: func(e SyncEvent) {
+			if e.Type == SyncEventSynced {
+				synced++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("WatchSync failed: %v", err)
+	}
+	assertContent(t, target, "remote\n")
+	if synced != 1 {
+		t.Fatalf("expected only the initial sync, got %d", synced)
+	}
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// TemplateSuffix 仓库中模板源文件的后缀
+	TemplateSuffix = ".tmpl"
+	// RenderedDir 渲染结果的存放目录，位于仓库内并被 git 忽略
+	RenderedDir = ".lnk-rendered"
+	// ValuesFilename 模板变量文件，仓库根目录的为公共变量，主机目录中的同名文件覆盖公共变量
+	ValuesFilename = ".lnk-values.yaml"
+)
+
+// TemplateData 模板中可用的变量，Values 来自变量文件（键名为小写）
+type TemplateData struct {
+	Host     string
+	Hostname string
+	OS       string
+	Arch     string
+	User     string
+	Home     string
+	Values   map[string]interface{}
+}
+
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+	"replace":   strings.ReplaceAll,
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+}
+
+// TemplateData 返回当前主机的模板变量
+func (l *Lnk) TemplateData() (*TemplateData, error) {
+	hostname, _ := os.Hostname()
+	data := &TemplateData{
+		Host:     l.host,
+		Hostname: hostname,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+	}
+	if data.Host == "" || data.Host == "localhost" {
+		data.Host = hostname
+	}
+	if u, err := user.Current(); err == nil {
+		data.User = u.Username
+	}
+	data.Home, _ = os.UserHomeDir()
+
+	v := viper.New()
+	files := []string{filepath.Join(l.repoPath, ValuesFilename)}
+	if hostFile := filepath.Join(l.getHostDir(), ValuesFilename); hostFile != files[0] {
+		files = append(files, hostFile)
+	}
+	for _, file := range files {
+		if !l.fs.FileExists(file) {
+			continue
+		}
+		v.SetConfigFile(file)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, WrapError(err, ErrCodeTemplate, "读取模板变量失败", SeverityError).
+				WithContext("file", file)
+		}
+	}
+	data.Values = v.AllSettings()
+	return data, nil
+}
+
+func renderTemplate(name string, src []byte, data *TemplateData) ([]byte, error) {
+	tpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (l *Lnk) renderEntry(ent TrackedEntry, data *TemplateData) ([]byte, error) {
+	src, err := os.ReadFile(l.entryRepoFilePath(ent))
+	if err != nil {
+		return nil, fmt.Errorf("读取模板失败: %w", err)
+	}
+	out, err := renderTemplate(ent.Path, src, data)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeTemplate, "渲染模板失败", SeverityError).
+			WithContext("file", ent.Path).
+			WithSuggestion("变量文件中的键名为小写，可选变量请使用 index .Values \"key\"")
+	}
+	return out, nil
+}
+
+// entryRenderer 返回渲染函数，首次遇到模板条目时才读取变量
+func (l *Lnk) entryRenderer() func(ent TrackedEntry) error {
+	var (
+		data    *TemplateData
+		dataErr error
+	)
+	return func(ent TrackedEntry) error {
+		if data == nil && dataErr == nil {
+			data, dataErr = l.TemplateData()
+		}
+		if dataErr != nil {
+			return dataErr
+		}
+		out, err := l.renderEntry(ent, data)
+		if err != nil {
+			return err
+		}
+		return l.writeGeneratedFile(ent, out)
+	}
+}
+
+// AddTemplate 将文件作为模板存入仓库，原位置链接到渲染结果
+func (l *Lnk) AddTemplate(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("文件路径列表不能为空")
+	}
+	data, err := l.TemplateData()
+	if err != nil {
+		return err
+	}
+	return l.addGenerated(filePaths, LinkTypeTemplate, func(path string, src []byte) ([]byte, []byte, error) {
+		out, err := renderTemplate(path, src, data)
+		if err != nil {
+			return nil, nil, WrapError(err, ErrCodeTemplate, "文件不是有效的模板", SeverityError).
+				WithContext("file", path).
+				WithSuggestion("文件中的 {{ 需要写成 {{\"{{\"}}")
+		}
+		return src, out, nil
+	})
+}
+
+// RenderTemplates 重新渲染当前主机的所有模板，返回内容发生变化的条目
+func (l *Lnk) RenderTemplates() ([]string, error) {
+	if !l.IsInitialized() {
+		return nil, &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	entries, err := l.readTrackingEntries()
+	if err != nil {
+		return nil, err
+	}
+	data, err := l.TemplateData()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, ent := range entries {
+		if ent.Type != LinkTypeTemplate {
+			continue
+		}
+		out, err := l.renderEntry(ent, data)
+		if err != nil {
+			return changed, err
+		}
+		if current, err := os.ReadFile(l.entryLinkTarget(ent)); err == nil && bytes.Equal(current, out) {
+			continue
+		}
+		if err := l.writeGeneratedFile(ent, out); err != nil {
+			return changed, err
+		}
+		changed = append(changed, ent.Path)
+	}
+	return changed, nil
+}
+
+// TemplateDiff 对比模板当前的渲染结果与已部署的文件
+func (l *Lnk) TemplateDiff(color bool) (string, error) {
+	if !l.IsInitialized() {
+		return "", &RepoNotInitializedError{RepoPath: l.repoPath}
+	}
+	entries, err := l.readTrackingEntries()
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		data *TemplateData
+		out  strings.Builder
+	)
+	for _, ent := range entries {
+		if ent.Type != LinkTypeTemplate {
+			continue
+		}
+		if data == nil {
+			if data, err = l.TemplateData(); err != nil {
+				return "", err
+			}
+		}
+		rendered, err := l.renderEntry(ent, data)
+		if err != nil {
+			return "", err
+		}
+
+		deployed, err := filepath.EvalSymlinks(trackedToAbsPath(ent.Path))
+		if err != nil {
+			out.WriteString(fmt.Sprintf("未部署: %s\n", trackedToAbsPath(ent.Path)))
+			continue
+		}
+		tmp, err := os.CreateTemp("", "lnk-render-*")
+		if err != nil {
+			return "", fmt.Errorf("创建临时文件失败: %w", err)
+		}
+		_, err = tmp.Write(rendered)
+		_ = tmp.Close()
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+			return "", fmt.Errorf("写入临时文件失败: %w", err)
+		}
+		diff, err := l.git.DiffFiles(deployed, tmp.Name(), color)
+		_ = os.Remove(tmp.Name())
+		if err != nil {
+			return "", fmt.Errorf("对比模板 %s 失败: %w", ent.Path, err)
+		}
+		// 文件路径替换为条目路径，便于阅读
+		label := strings.TrimPrefix(filepath.ToSlash(ent.Path), "/")
+		out.WriteString(strings.NewReplacer(
+			strings.TrimPrefix(filepath.ToSlash(tmp.Name()), "/"), "rendered/"+label,
+			strings.TrimPrefix(filepath.ToSlash(deployed), "/"), "deployed/"+label,
+		).Replace(diff))
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAddTemplateRendersPerHost(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithHost("work"), WithLinkType(LinkTypeTemplate))
+	writeFile(t, filepath.Join(repoDir, ValuesFilename), "email: me@home\nproxy: \"\"\n")
+	if err := os.MkdirAll(filepath.Join(repoDir, "work.lnk"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repoDir, "work.lnk", ValuesFilename), "email: me@work\n")
+
+	target := filepath.Join(t.TempDir(), "gitconfig")
+	writeFile(t, target, "email = {{ .Values.email }}\nos = {{ .OS }}\n")
+	if err := lnk.Add(target); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ent := TrackedEntry{Path: lnk.toTrackingPath(target), Type: LinkTypeTemplate}
+	src, err := os.ReadFile(lnk.entryRepoFilePath(ent))
+	if err != nil || !strings.Contains(string(src), "{{ .Values.email }}") {
+		t.Fatalf("expected template source in repo, got %q (%v)", src, err)
+	}
+	if tracked := runGit(t, repoDir, "ls-files"); strings.Contains(tracked, RenderedDir) {
+		t.Fatalf("rendered files must not be tracked: %q", tracked)
+	}
+
+	want := "email = me@work\nos = " + runtime.GOOS + "\n"
+	if content, _ := os.ReadFile(target); string(content) != want {
+		t.Fatalf("unexpected rendered content %q", content)
+	}
+	if changed, err := lnk.RenderTemplates(); err != nil || len(changed) != 0 {
+		t.Fatalf("expected no changes, got %v (%v)", changed, err)
+	}
+
+	writeFile(t, filepath.Join(repoDir, "work.lnk", ValuesFilename), "email: new@work\n")
+	diff, err := lnk.TemplateDiff(false)
+	if err != nil {
+		t.Fatalf("TemplateDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "-email = me@work") || !strings.Contains(diff, "+email = new@work") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := lnk.RestoreSymlinks(); err != nil {
+		t.Fatalf("RestoreSymlinks failed: %v", err)
+	}
+	if content, _ := os.ReadFile(target); !strings.HasPrefix(string(content), "email = new@work") {
+		t.Fatalf("expected re-rendered content after restore, got %q", content)
+	}
+}
+
+func TestAddTemplateRejectsInvalidTemplate(t *testing.T) {
+	lnk, repoDir := newTestLnk(t, WithLinkType(LinkTypeTemplate))
+	target := filepath.Join(t.TempDir(), "a.conf")
+	writeFile(t, target, "value = {{ .Values.missing }}\n")
+	writeFile(t, filepath.Join(repoDir, ".gitignore"), "*.swp")
+
+	err := lnk.Add(target)
+	se, ok := err.(StructuredError)
+	if !ok || se.Code() != ErrCodeTemplate {
+		t.Fatalf("expected %s error, got %v", ErrCodeTemplate, err)
+	}
+	if lnk.fs.IsSymlink(target) {
+		t.Fatal("file should be left untouched")
+	}
+	assertContent(t, filepath.Join(repoDir, ".gitignore"), "*.swp")
+
+	if err := os.Remove(filepath.Join(repoDir, ".gitignore")); err != nil {
+		t.Fatal(err)
+	}
+	if err := lnk.Add(target); err == nil {
+		t.Fatal("expected invalid template error")
+	}
+	assertNotExists(t, filepath.Join(repoDir, ".gitignore"))
+}
@@ -219,6 +219,18 @@ func (fs *FileSystem) copyFile(src, dst string, mode os.FileMode) error {
 	return out.Chmod(mode.Perm())
 }
 
+// CopyFile 复制文件内容并保留源文件权限
+func (fs *FileSystem) CopyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return &FileNotExistsError{Path: src, Err: err}
+	}
+	if err := fs.copyFile(src, dst, info.Mode()); err != nil {
+		return &FileOperationError{Operation: "copy", Path: dst, Err: err}
+	}
+	return nil
+}
+
 func (fs *FileSystem) copyDir(src, dst string) error {
 	sinfo, err := os.Stat(src)
 	if err != nil {
@@ -15,10 +15,10 @@ type Git struct {
 }
 
 type StatusInfo struct {
-	Ahead  int
-	Behind int
-	Remote string
-	Dirty  bool
+	Ahead  int    `json:"ahead" yaml:"ahead"`
+	Behind int    `json:"behind" yaml:"behind"`
+	Remote string `json:"remote" yaml:"remote"`
+	Dirty  bool   `json:"dirty" yaml:"dirty"`
 }
 
 func New(repoPath string) *Git {
@@ -259,7 +259,8 @@ func (g *Git) Pull() error {
 		return err
 	}
 
-	cmd := exec.Command("git", "pull", remote, branch)
+	// 总是合并，冲突由 lnk 逐个文件解决
+	cmd := exec.Command("git", "pull", "--no-rebase", remote, branch)
 	cmd.Dir = g.repoPath
 
 	output, err := cmd.CombinedOutput()
@@ -287,7 +288,7 @@ func (g *Git) Pull() error {
 		}
 
 		return &GitCommandError{
-			Command: fmt.Sprintf("git pull %s %s", remote, branch),
+			Command: fmt.Sprintf("git pull --no-rebase %s %s", remote, branch),
 			Output:  string(output),
 			Err:     err,
 		}
@@ -686,6 +687,32 @@ func (g *Git) Diff(color bool) (string, error) {
 	return string(output), nil
 }
 
+// DiffFiles 比较任意两个文件（git diff --no-index），无差异时返回空字符串
+func (g *Git) DiffFiles(a, b string, color bool) (string, error) {
+	colorFlag := "--color=never"
+	if color {
+		colorFlag = "--color=always"
+	}
+
+	cmd := exec.Command("git", "diff", "--no-index", colorFlag, "--", a, b)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// 存在差异时退出码为 1
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return string(output), nil
+		}
+		return "", &GitCommandError{
+			Command: fmt.Sprintf("git diff --no-index %s", colorFlag),
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	return string(output), nil
+}
+
 func (g *Git) ListBranches() ([]string, error) {
 	cmd := exec.Command("git", "branch", "--format=%(refname:short)")
 	cmd.Dir = g.repoPath
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CommitInfo struct {
+	Hash      string
+	ShortHash string
+	Author    string
+	Date      time.Time
+	Subject   string
+}
+
+func (g *Git) RevParse(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", &GitCommandError{
+			Command: fmt.Sprintf("git rev-parse --verify %s", ref),
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsAncestor 判断提交 a 是否为 b 的祖先（包括 a 与 b 相同）
+func (g *Git) IsAncestor(a, b string) bool {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", a, b)
+	cmd.Dir = g.repoPath
+	return cmd.Run() == nil
+}
+
+// Log 返回涉及指定路径的提交，单个路径时跟踪重命名，limit 小于等于 0 时不限制数量
+func (g *Git) Log(limit int, paths ...string) ([]CommitInfo, error) {
+	args := []string{"log", "--format=%H%x1f%h%x1f%an%x1f%at%x1f%s"}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	if len(paths) == 1 {
+		args = append(args, "--follow")
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git " + strings.Join(args, " "),
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	var commits []CommitInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, CommitInfo{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Date:      time.Unix(ts, 0),
+			Subject:   fields[4],
+		})
+	}
+
+	return commits, nil
+}
+
+// ShowFile 读取指定版本中的文件内容，path 为仓库内的相对路径
+func (g *Git) ShowFile(rev, path string) ([]byte, error) {
+	spec := fmt.Sprintf("%s:%s", rev, strings.TrimPrefix(path, "./"))
+	cmd := exec.Command("git", "show", spec)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git show " + spec,
+			Err:     err,
+		}
+	}
+
+	return output, nil
+}
+
+// CommitFiles 返回提交修改的文件列表
+func (g *Git) CommitFiles(rev string) ([]string, error) {
+	cmd := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", "--root", rev)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git diff-tree --name-only " + rev,
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// Revert 将提交的反向变更应用到工作区与暂存区，不自动提交，失败时中止
+func (g *Git) Revert(rev string) error {
+	cmd := exec.Command("git", "revert", "--no-commit", rev)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		abort := exec.Command("git", "revert", "--abort")
+		abort.Dir = g.repoPath
+		_ = abort.Run()
+
+		if strings.Contains(string(output), "CONFLICT") {
+			return &MergeConflictError{
+				Files: g.parseConflictFiles(string(output)),
+			}
+		}
+
+		return &GitCommandError{
+			Command: "git revert --no-commit " + rev,
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FetchRemote 从默认远程仓库获取更新
+func (g *Git) FetchRemote() error {
+	remote, err := g.getRemoteName()
+	if err != nil {
+		return err
+	}
+	return g.Fetch(remote)
+}
+
+// MergeRemote 合并远程分支，ffOnly 为 true 时只允许快进，冲突时返回 MergeConflictError
+func (g *Git) MergeRemote(ffOnly bool) error {
+	remote, err := g.getRemoteName()
+	if err != nil {
+		return err
+	}
+	branch, err := g.getCurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"merge", "--no-edit"}
+	if ffOnly {
+		args = append(args, "--ff-only")
+	}
+	args = append(args, fmt.Sprintf("%s/%s", remote, branch))
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "CONFLICT") {
+			return &MergeConflictError{
+				Files: g.parseConflictFiles(string(output)),
+			}
+		}
+		return &GitCommandError{
+			Command: "git " + strings.Join(args, " "),
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+func (g *Git) MergeAbort() error {
+	cmd := exec.Command("git", "merge", "--abort")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return &GitCommandError{
+			Command: "git merge --abort",
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// StagedFiles 返回暂存区中相对 HEAD 有变更的文件
+func (g *Git) StagedFiles() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--name-only")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git diff --cached --name-only",
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// MergeInProgress 是否有未完成的合并
+func (g *Git) MergeInProgress() bool {
+	_, err := os.Stat(filepath.Join(g.repoPath, ".git", "MERGE_HEAD"))
+	return err == nil
+}
+
+// ConflictFiles 返回合并中仍未解决冲突的文件
+func (g *Git) ConflictFiles() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git diff --name-only --diff-filter=U",
+			Output:  string(output),
+			Err:     err,
+		}
+	}
+
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+// ShowStage 读取冲突文件在暂存区中的版本：1 为共同祖先，2 为本地，3 为远程，
+// 该版本不存在（一方删除了文件）时返回错误
+func (g *Git) ShowStage(stage int, path string) ([]byte, error) {
+	spec := fmt.Sprintf(":%d:%s", stage, path)
+	cmd := exec.Command("git", "show", spec)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, &GitCommandError{
+			Command: "git show " + spec,
+			Err:     err,
+		}
+	}
+
+	return output, nil
+}
+
+// MergeFiles 三方合并三个文件的内容，有冲突时结果中包含冲突标记
+func (g *Git) MergeFiles(local, base, remote string) ([]byte, error) {
+	cmd := exec.Command("git", "merge-file", "-p", "-L", "local", "-L", "base", "-L", "remote", local, base, remote)
+	cmd.Dir = g.repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		// 退出码为冲突的数量
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+			return output, nil
+		}
+		return nil, &GitCommandError{
+			Command: "git merge-file -p",
+			Err:     err,
+		}
+	}
+
+	return output, nil
+}
@@ -0,0 +1,43 @@
+package secret
+
+import "fmt"
+
+type KeyNotFoundError struct {
+	Path string
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("加密密钥不存在: %s", e.Path)
+}
+
+type InvalidKeyError struct {
+	Err error
+}
+
+func (e *InvalidKeyError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("无效的加密密钥: %s", e.Err)
+	}
+	return "无效的加密密钥"
+}
+
+func (e *InvalidKeyError) Unwrap() error {
+	return e.Err
+}
+
+type KeyMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *KeyMismatchError) Error() string {
+	return fmt.Sprintf("密钥不匹配: 文件使用密钥 %s 加密，当前密钥为 %s", e.Expected, e.Actual)
+}
+
+type InvalidCiphertextError struct {
+	Reason string
+}
+
+func (e *InvalidCiphertextError) Error() string {
+	return fmt.Sprintf("无效的加密文件: %s", e.Reason)
+}
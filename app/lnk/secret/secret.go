@@ -0,0 +1,170 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KeySize  = chacha20poly1305.KeySize
+	SaltSize = 16
+
+	// KeyFileEnv 指定密钥文件路径的环境变量
+	KeyFileEnv = "LNK_KEY_FILE"
+
+	magic   = "LNKENC1\x00"
+	idSize  = 8
+	hdrSize = len(magic) + idSize + chacha20poly1305.NonceSizeX
+)
+
+// Key 对称加密密钥，随机生成或由口令派生
+type Key []byte
+
+func GenerateKey() (Key, error) {
+	k := make(Key, KeySize)
+	if _, err := rand.Read(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// DeriveKey 使用 scrypt 从口令派生密钥，相同的口令与盐值在不同机器上得到相同的密钥
+func DeriveKey(passphrase string, salt []byte) (Key, error) {
+	if passphrase == "" {
+		return nil, &InvalidKeyError{Err: fmt.Errorf("口令不能为空")}
+	}
+	if len(salt) < SaltSize {
+		return nil, &InvalidKeyError{Err: fmt.Errorf("盐值长度不足")}
+	}
+	k, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, KeySize)
+	if err != nil {
+		return nil, &InvalidKeyError{Err: err}
+	}
+	return k, nil
+}
+
+func ParseKey(s string) (Key, error) {
+	k, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, &InvalidKeyError{Err: err}
+	}
+	if len(k) != KeySize {
+		return nil, &InvalidKeyError{Err: fmt.Errorf("密钥长度应为 %d 字节", KeySize)}
+	}
+	return k, nil
+}
+
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+// ID 密钥指纹，用于识别文件由哪个密钥加密
+func (k Key) ID() string {
+	return hex.EncodeToString(k.id())
+}
+
+func (k Key) id() []byte {
+	sum := sha256.Sum256(k)
+	return sum[:idSize]
+}
+
+func DefaultKeyFile() string {
+	if p := os.Getenv(KeyFileEnv); p != "" {
+		return p
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".config", "lnk.key")
+}
+
+func LoadKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &KeyNotFoundError{Path: path}
+		}
+		return nil, err
+	}
+	return ParseKey(string(data))
+}
+
+func SaveKey(path string, k Key) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(k.String()+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// IsEncrypted 判断内容是否为 Encrypt 生成的密文
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Encrypt 使用 XChaCha20-Poly1305 加密，格式为 魔数 | 密钥指纹 | 随机数 | 密文
+func Encrypt(k Key, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return nil, &InvalidKeyError{Err: err}
+	}
+	out := make([]byte, hdrSize, hdrSize+len(plaintext)+aead.Overhead())
+	copy(out, magic)
+	copy(out[len(magic):], k.id())
+	nonce := out[len(magic)+idSize : hdrSize]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, out[:len(magic)+idSize]), nil
+}
+
+func Decrypt(k Key, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, &InvalidCiphertextError{Reason: "缺少文件头"}
+	}
+	if len(data) < hdrSize {
+		return nil, &InvalidCiphertextError{Reason: "内容被截断"}
+	}
+	if id := data[len(magic) : len(magic)+idSize]; !bytes.Equal(id, k.id()) {
+		return nil, &KeyMismatchError{Expected: hex.EncodeToString(id), Actual: k.ID()}
+	}
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return nil, &InvalidKeyError{Err: err}
+	}
+	plaintext, err := aead.Open(nil, data[len(magic)+idSize:hdrSize], data[hdrSize:], data[:len(magic)+idSize])
+	if err != nil {
+		return nil, &InvalidCiphertextError{Reason: "校验失败，内容可能已被篡改"}
+	}
+	return plaintext, nil
+}
+
+// KeyID 读取密文头部中的密钥指纹
+func KeyID(data []byte) (string, bool) {
+	if !IsEncrypted(data) || len(data) < hdrSize {
+		return "", false
+	}
+	return hex.EncodeToString(data[len(magic) : len(magic)+idSize]), true
+}
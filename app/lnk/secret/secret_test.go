@@ -0,0 +1,77 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Encrypt(key, []byte("token=abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(data) || bytes.Contains(data, []byte("token=abc")) {
+		t.Fatalf("unexpected ciphertext %q", data)
+	}
+	if id, ok := KeyID(data); !ok || id != key.ID() {
+		t.Fatalf("KeyID = %q, %v", id, ok)
+	}
+	plain, err := Decrypt(key, data)
+	if err != nil || string(plain) != "token=abc" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	other, _ := GenerateKey()
+	var mismatch *KeyMismatchError
+	if _, err = Decrypt(other, data); !errors.As(err, &mismatch) {
+		t.Fatalf("expected KeyMismatchError, got %v", err)
+	}
+
+	data[len(data)-1] ^= 1
+	if _, err = Decrypt(key, data); err == nil {
+		t.Fatal("expected error for tampered ciphertext")
+	}
+	var invalid *InvalidCiphertextError
+	if _, err = Decrypt(key, []byte("plain")); !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidCiphertextError, got %v", err)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, SaltSize)
+	a, err := DeriveKey("passphrase", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DeriveKey("passphrase", salt)
+	c, _ := DeriveKey("other", salt)
+	if !bytes.Equal(a, b) || bytes.Equal(a, c) {
+		t.Fatal("expected derivation to be deterministic per passphrase")
+	}
+}
+
+func TestSaveLoadKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dir", "lnk.key")
+	var notFound *KeyNotFoundError
+	if _, err := LoadKey(file); !errors.As(err, &notFound) {
+		t.Fatalf("expected KeyNotFoundError, got %v", err)
+	}
+	key, _ := GenerateKey()
+	if err := SaveKey(file, key); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadKey(file)
+	if err != nil || !bytes.Equal(loaded, key) {
+		t.Fatalf("LoadKey = %v, %v", loaded, err)
+	}
+	parsed, err := ParseKey(key.String())
+	if err != nil || !bytes.Equal(parsed, key) {
+		t.Fatalf("ParseKey = %v, %v", parsed, err)
+	}
+}
@@ -59,9 +59,12 @@ core:
 
 # 监控配置
 monitor:
-  # 使用轮询，开启可以监听挂载目录
+  # 强制全部使用轮询；关闭时会按目录自动检测，网络盘、容器挂载目录等会单独改用轮询
   poll: false
 
+  # 轮询间隔（毫秒）
+  pollInterval: 200
+
   # 要监听的目录，支持通配符*，如“.,*”表示监听当前目录及其所有子目录
   includeDirs:
     - '.,*'
@@ -114,8 +117,49 @@ http:
   # 将非本地文件的请求代理到服务器，主要用于本地跨域问题，留空表示不使用，格式如下：
   # proxy: https://blog.73zls.com
   proxy:
+  # 按路径前缀代理到不同服务，优先匹配最长前缀，支持 WebSocket/SSE 透传
+  # proxyRoutes:
+  #   - path: /api
+  #     target: http://127.0.0.1:8080
+  #     # 转发时去掉路径前缀，/api/user => /user
+  #     stripPrefix: true
+  #     # 保留原始 Host 头，默认改写为目标主机
+  #     keepHost: false
+  #     # 改写请求头，值为空表示删除
+  #     headers:
+  #       X-Env: dev
+  #     # 改写响应头
+  #     responseHeaders:
+  #       Access-Control-Allow-Origin: "*"
+  #   - path: /ws
+  #     target: ws://127.0.0.1:8081
   # 自动打开浏览器
   openBrowser: true
+  # 启用 HTTPS，自动在 ~/.zzz/certs 生成本地根证书并签发证书，需手动信任根证书 ca.pem
+  https: false
+  # 证书额外包含的域名或 IP，默认已包含 localhost、127.0.0.1、::1
+  # httpsHosts:
+  #   - dev.local
+  # 单页应用 history 模式，找不到页面时回退到 index.html
+  spaFallback: false
+  # 目录没有 index.html 时显示文件列表
+  dirListing: false
+  # 开启 brotli/gzip 压缩，按 Accept-Encoding 协商，优先返回已存在的 .br/.gz 预压缩文件
+  compress: false
+  # 自定义响应头，如跨域、CSP，同样作用于代理的响应
+  # headers:
+  #   Access-Control-Allow-Origin: "*"
+  #   Content-Security-Policy: "default-src 'self'"
+  # 模拟接口，优先于静态文件和代理，path 支持通配符 *
+  # mocks:
+  #   - path: /api/user
+  #     method: GET
+  #     file: ./mock/user.json
+  #   - path: /api/items/*
+  #     status: 201
+  #     # 延迟响应（毫秒）
+  #     delay: 500
+  #     body: '{"code":200}'
 
 # 其他
 other:
@@ -123,6 +167,68 @@ other:
   delayMillSecond: 100
 `
 
+var ExampleBuildConfig = `# zzz build 配置 https://github.com/sohaha/zzz
+core:
+  # 配置版本号，勿手动修改
+  version: %v
+
+# 编译配置，使用 zzz build -p 名称 选择，命令行参数优先于配置
+profiles:
+  dev:
+    # 编译标签
+    tags:
+      - dev
+    # 设置包变量，格式：包名.变量=值
+    vars:
+      - main.Env=dev
+
+  release:
+    # 交叉编译的目标系统，同 --os
+    os: win,mac,linux
+    # 输出目录，同 --out
+    out: ./dist
+    # 并发编译的目标数，默认为 CPU 核数，同 --jobs
+    jobs: 4
+    # 输出文件名模板（不含扩展名），可用变量 {{.Name}} {{.OS}} {{.Arch}} {{.Variant}} {{.Profile}}
+    output: "{{.Name}}_{{.OS}}_{{.Arch}}"
+    # 附加 -w -s 压缩参数，同 --pack
+    pack: true
+    # 移除编译产物中的文件系统路径，同 --trimpath
+    trimpath: true
+    # 打包为 名称_版本_系统_架构.tar.gz/.zip，并生成校验文件与 manifest.json，同 --dist
+    dist: true
+    # 打包时附加的文件，支持通配符
+    distFiles:
+      - LICENSE*
+      - README*
+    # 打包静态资源前压缩 HTML/CSS/JS，同 --minify
+    minify: true
+    # 在产物旁生成 SBOM 与 .provenance.json，可选 cyclonedx、spdx，同 --sbom
+    sbom: cyclonedx
+    # 可复现编译，附加 -trimpath、-buildvcs，编译时间取自 SOURCE_DATE_EPOCH 或最后一次提交，同 --reproducible
+    # 使用 --verify 可在临时目录中编译两次并校验产物是否一致
+    reproducible: true
+    # 其他可用参数：cgo、upx、garble、noStatic、hideWinConsole、skipDirs、ldflags、version
+    # 包变量的值支持模板，可用变量 {{.Version}} {{.Semver}} {{.Tag}} {{.Commit}} {{.ShortCommit}}
+    # {{.Dirty}} {{.Date}} {{.Timestamp}} {{.Env.NAME}}，版本号由 git describe 推导，同 --var
+    # 另外会自动注入 main.BUILD_VERSION
+    vars:
+      - main.Env=production
+      - main.Version={{.Version}}
+      - main.Commit={{.ShortCommit}}
+      - main.BuildDate={{.Date}}
+    # 编译时的环境变量
+    env:
+      - GOFLAGS=-mod=mod
+    # 编译前执行的命令
+    pre:
+      - go generate ./...
+    # 编译后执行的命令，可通过环境变量 ZZZ_BUILD_OUTPUTS 获取生成的文件，ZZZ_BUILD_MANIFEST 获取打包清单
+    # 支持不同平台执行不同命令，如 linux@ls -a
+    post:
+      - linux|mac@ls -l ./dist
+`
+
 func GetExampleConfig(version string) string {
 	return fmt.Sprintf(ExampleConfig, version, time.Now().Unix())
 }
@@ -135,6 +241,10 @@ func GetExampleWatchConfig(version string) string {
 	return fmt.Sprintf(ExampleWatchConfig, version, name, name)
 }
 
+func GetExampleBuildConfig(version string) string {
+	return fmt.Sprintf(ExampleBuildConfig, version)
+}
+
 func GetExampleStressConfig(version string) string {
 	return fmt.Sprintf(ExampleStressConfig, version)
 }
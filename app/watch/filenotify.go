@@ -1,6 +1,10 @@
 package watch
 
-import "github.com/fsnotify/fsnotify"
+import (
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
 
 type FileWatcher interface {
 	Events() <-chan fsnotify.Event
@@ -10,17 +14,16 @@ type FileWatcher interface {
 	Close() error
 }
 
-func NewWatcher() (FileWatcher, error) {
-	if watcher, err := NewEventWatcher(); err == nil {
-		return watcher, nil
-	}
-	return NewPollingWatcher(), nil
+// NewWatcher 按目录自动选择系统事件监听或轮询，interval 为轮询间隔
+func NewWatcher(interval ...time.Duration) (FileWatcher, error) {
+	return NewHybridWatcher(pollInterval(interval)), nil
 }
 
-func NewPollingWatcher() FileWatcher {
+func NewPollingWatcher(interval ...time.Duration) FileWatcher {
 	return &filePoller{
-		events: make(chan fsnotify.Event),
-		errors: make(chan error),
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		interval: pollInterval(interval),
 	}
 }
 
@@ -31,3 +34,10 @@ func NewEventWatcher() (FileWatcher, error) {
 	}
 	return &fsNotifyWatcher{watcher}, nil
 }
+
+func pollInterval(interval []time.Duration) time.Duration {
+	if len(interval) > 0 && interval[0] > 0 {
+		return interval[0]
+	}
+	return watchWaitTime
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+// +build darwin
+
+package watch
+
+import "syscall"
+
+var pollFilesystems = map[string]struct{}{
+	"nfs":     {},
+	"smbfs":   {},
+	"afpfs":   {},
+	"webdav":  {},
+	"osxfuse": {},
+	"macfuse": {},
+	"fusefs":  {},
+}
+
+func pollFilesystem(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	b := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	name := string(b)
+	_, ok := pollFilesystems[name]
+	return name, ok
+}
@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import "syscall"
+
+// 这些文件系统上 inotify 无法收到其他主机或宿主机产生的变更
+var pollFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x01021997: "9p",
+	0x786f4256: "vboxsf",
+	0x73757245: "coda",
+	0x5346414f: "afs",
+	0x00c36400: "ceph",
+}
+
+func pollFilesystem(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name, ok := pollFilesystems[uint32(st.Type)]
+	return name, ok
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package watch
+
+func pollFilesystem(_ string) (string, bool) {
+	return "", false
+}
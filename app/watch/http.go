@@ -3,12 +3,9 @@ package watch
 import (
 	"bufio"
 	"encoding/json"
-	"errors"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -42,6 +39,14 @@ func initHTTP() {
 	httpOpenBrowser = v.GetBool("http.openBrowser")
 	v.SetDefault("http.closeLocal", false)
 	httpCloseLocal = v.GetBool("http.closeLocal")
+	httpHTTPS = v.GetBool("http.https")
+	httpHTTPSHosts = v.GetStringSlice("http.httpsHosts")
+	if err := initProxy(); err != nil {
+		util.Log.Fatal(err)
+	}
+	if err := initStatic(); err != nil {
+		util.Log.Fatal(err)
+	}
 	if httpType == "vue-run" {
 		types := v.GetStringSlice("monitor.types")
 		ignoreFormat = []string{".vue", ".css", ".html", ".js", ".es6"}
@@ -62,18 +67,48 @@ func httpRun() {
 		return
 	}
 
+	service := newHTTPService()
+	host := ":" + ztype.ToString(port)
+	if httpHTTPS {
+		certFile, keyFile, err := ensureCert(certDir(), httpHTTPSHosts)
+		if err != nil {
+			util.Log.Fatal("生成 HTTPS 证书失败:", err)
+		}
+		service.SetAddr(host, znet.TlsCfg{Cert: certFile, Key: keyFile})
+	} else {
+		service.SetAddr(host)
+	}
+	domain := httpScheme() + "127.0.0.1" + host
+	// util.Log.Printf("WebServe: %v", domain)
+	if httpOpenBrowser {
+		_ = openBrowser(domain)
+	}
+	znet.Run()
+}
+
+// wsPath 注入脚本连接的热更新 WebSocket 地址
+const wsPath = "/___ZWatchWs___"
+
+func newHTTPService() *znet.Engine {
 	ws = melody.New()
 	service := znet.New()
 	// service.SetMode(znet.DebugMode)
 	service.Log.ResetFlags(0)
 	service.Log.SetPrefix("")
+	if httpCompress {
+		service.Use(compressMiddleware)
+	}
 	service.NotFoundHandler(func(c *znet.Context) {
 		httpEntrance(c)
 		_ = c.PrevContent()
 	})
 
+	service.GET(wsPath, func(c *znet.Context) {
+		_ = ws.HandleRequest(c.Writer, c.Request)
+	})
 	service.GET("/", func(c *znet.Context) {
-		if c.IsWebsocket() {
+		// 兼容连接根路径的旧脚本，有代理路由时交给上游
+		if c.IsWebsocket() && matchProxyRoute("/") == nil {
 			_ = ws.HandleRequest(c.Writer, c.Request)
 		} else {
 			httpEntrance(c)
@@ -87,14 +122,7 @@ func httpRun() {
 		// util.Log.Println(msg)
 		_ = ws.Broadcast(data)
 	})
-	host := ":" + ztype.ToString(port)
-	service.SetAddr(host)
-	domain := "http://127.0.0.1" + host
-	// util.Log.Printf("WebServe: %v", domain)
-	if httpOpenBrowser {
-		_ = openBrowser(domain)
-	}
-	znet.Run()
+	return service
 }
 
 func sendChang(data *changedFile) {
@@ -107,6 +135,30 @@ func sendChang(data *changedFile) {
 	send(_json)
 }
 
+type buildEvent struct {
+	Event    string
+	Command  string `json:",omitempty"`
+	ExitCode int    `json:",omitempty"`
+	Stderr   string `json:",omitempty"`
+}
+
+// sendBuildError 通知浏览器显示构建失败信息
+func sendBuildError(command string, exitCode int, stderr string) {
+	_json, _ := json.Marshal(&buildEvent{
+		Event:    "build-error",
+		Command:  command,
+		ExitCode: exitCode,
+		Stderr:   stderr,
+	})
+	send(_json)
+}
+
+// sendBuildOK 通知浏览器移除构建失败信息
+func sendBuildOK() {
+	_json, _ := json.Marshal(&buildEvent{Event: "build-ok"})
+	send(_json)
+}
+
 func send(msg []byte) {
 	if ws != nil {
 		go func() {
@@ -118,34 +170,60 @@ func send(msg []byte) {
 }
 
 func httpEntrance(c *znet.Context) {
-	var err error
 	method := c.Request.Method
-	urlPath := c.Request.URL.Path
+	urlPath := path.Clean("/" + c.Request.URL.Path)
 
 	c.SetHeader("cache-control", "no-store")
-	if urlPath == "/" || urlPath == "" {
-		urlPath = "/index.html"
+	applyHeaders(c)
+	if m := matchMock(method, urlPath); m != nil {
+		m.serve(c)
+		return
+	}
+	if method == http.MethodOptions && len(httpHeaders) > 0 {
+		c.Abort(http.StatusNoContent)
+		return
+	}
+
+	proxyPath := cleanProxyPath(c.Request.URL.Path)
+	route := matchProxyRoute(proxyPath)
+	if route != nil && c.IsWebsocket() {
+		serveProxy(c, route, proxyPath)
+		return
 	}
 
 	pullPath := httpPath + urlPath
-	if !httpCloseLocal && zfile.FileExist(pullPath) {
-		ext := path.Ext(pullPath)
-		ext = strings.ToLower(ext)
-		switch ext {
-		case ".html":
-			if method == "GET" {
-				c.HTML(200, injectingCode(pullPath))
+	if !httpCloseLocal {
+		if zfile.FileExist(pullPath) {
+			serveLocalFile(c, pullPath)
+			return
+		}
+		if zfile.DirExist(pullPath) {
+			if index := path.Join(pullPath, "index.html"); zfile.FileExist(index) {
+				serveLocalFile(c, index)
+				return
+			}
+			if httpDirListing {
+				serveDirListing(c, pullPath, urlPath)
 				return
 			}
 		}
-		c.File(pullPath)
+	}
+
+	if route != nil && route.Path != "/" {
+		serveProxy(c, route, proxyPath)
 		return
 	}
-	if err = proxy(pullPath, c.Writer, c.Request); err != nil {
-		c.String(404, "file not found")
-	} else {
-		c.Abort(200)
+	if httpSPAFallback && !httpCloseLocal && isNavigation(c.Request) {
+		if index := httpPath + "/index.html"; zfile.FileExist(index) {
+			serveLocalFile(c, index)
+			return
+		}
 	}
+	if route != nil {
+		serveProxy(c, route, proxyPath)
+		return
+	}
+	c.String(404, "file not found")
 }
 
 func injectingCode(file string) (data string) {
@@ -170,87 +248,12 @@ func injectingCode(file string) (data string) {
 		}
 	}
 	html.WriteString("<script>")
-
-	switch httpType {
-	case "web":
-		html.WriteString(webJs)
-	case "vue-spa":
-		html.WriteString(vueSpaJs)
-	case "vue-run":
-		html.WriteString(vueHotReload)
-		// html.WriteString(vueRunExport)
-	}
+	html.WriteString(getInjectJS())
 	html.WriteString("</script>")
 	data = html.String()
 	return
 }
 
-func proxy(_ string, w http.ResponseWriter, r *http.Request) (err error) {
-	host, scheme := urlParse(httpProxy)
-	if host == "" {
-		err = errors.New("404")
-		return
-	}
-
-	targetURL := &url.URL{
-		Scheme:   scheme,
-		Host:     host,
-		Path:     r.URL.Path,
-		RawQuery: r.URL.RawQuery,
-	}
-
-	proxyReq, err := http.NewRequest(r.Method, targetURL.String(), r.Body)
-	if err != nil {
-		return err
-	}
-
-	for name, values := range r.Header {
-		for _, value := range values {
-			proxyReq.Header.Add(name, value)
-		}
-	}
-
-	proxyReq.Host = host
-
-	client := &http.Client{}
-	resp, err := client.Do(proxyReq)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	for name, values := range resp.Header {
-		for _, value := range values {
-			w.Header().Add(name, value)
-		}
-	}
-
-	contentType := resp.Header.Get("Content-Type")
-	shouldInject := httpType == "web" && strings.Contains(strings.ToLower(contentType), "text/html")
-
-	if shouldInject {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		htmlContent := string(body)
-		injectedHTML := injectJavaScriptToHTML(htmlContent, `var zWatchAll=true;`+getInjectJS())
-
-		w.Header().Del("Content-Length")
-
-		w.WriteHeader(resp.StatusCode)
-
-		_, err = w.Write([]byte(injectedHTML))
-		return err
-	} else {
-		w.WriteHeader(resp.StatusCode)
-
-		_, err = io.Copy(w, resp.Body)
-		return err
-	}
-}
-
 func injectJavaScriptToHTML(htmlContent, injectJS string) string {
 	lowerHTML := strings.ToLower(htmlContent)
 
@@ -267,24 +270,11 @@ func injectJavaScriptToHTML(htmlContent, injectJS string) string {
 
 func getInjectJS() string {
 	switch httpType {
-	case "web":
-		return webJs
 	case "vue-spa":
-		return vueSpaJs
+		return errorOverlayJs + reloadScheme(vueSpaJs)
 	case "vue-run":
-		return vueHotReload
+		return errorOverlayJs + reloadScheme(vueHotReload)
 	default:
-		return webJs
-	}
-}
-
-func urlParse(httpProxy string) (string, string) {
-	var host, scheme string
-	p, err := url.Parse(httpProxy)
-	if err != nil {
-		log.Println(err)
-	} else {
-		host, scheme = p.Host, p.Scheme
+		return errorOverlayJs + reloadScheme(webJs)
 	}
-	return host, scheme
 }
@@ -6,11 +6,14 @@
  */
 package watch
 
-const webJs = `var zWatchJs=[],zWatchCss={},zwatchWs;!function(){document.addEventListener("DOMContentLoaded",function(t){var e=document.createElement("a"),o="";document.querySelectorAll("script").forEach(function(t){t.src&&(e.href=t.src,o=e.href,zWatchJs.push(o.replace(location.origin+"/","")))}),document.querySelectorAll("link").forEach(function(t){t.href&&(e.href=t.href,zWatchCss[e.pathname.substring(1)]=t)})});var t=function(){try{var e=location.host;zwatchWs=new WebSocket("ws://"+e+"/"),zwatchWs.onopen=function(t){},zwatchWs.onmessage=function(t){if(window.zWatchAll){return location.reload()};var e=JSON.parse(t.data);console.log("update: ",e.Name);for(var o=0,a=zWatchJs.length;o<a;o++)if(zWatchJs[o]===e.Name)return void location.reload();for(var c in zWatchCss)if(c===e.Name&&zWatchCss.hasOwnProperty(c)){var n=zWatchCss[c].href;return zWatchCss[c].href="",void(zWatchCss[c].href=n)}location.pathname==="/"+e.Name&&location.reload()},zwatchWs.onclose=function(e){console.warn("Disconnect from zwatch."),setTimeout(function(){t()},300)}}catch(t){}};t()}();`
+const webJs = `var zWatchJs=[],zWatchCss={},zwatchWs;!function(){document.addEventListener("DOMContentLoaded",function(t){var e=document.createElement("a"),o="";document.querySelectorAll("script").forEach(function(t){t.src&&(e.href=t.src,o=e.href,zWatchJs.push(o.replace(location.origin+"/","")))}),document.querySelectorAll("link").forEach(function(t){t.href&&(e.href=t.href,zWatchCss[e.pathname.substring(1)]=t)})});var t=function(){try{var e=location.host;zwatchWs=new WebSocket("ws://"+e+"/___ZWatchWs___"),zwatchWs.onopen=function(t){},zwatchWs.onmessage=function(t){var e=JSON.parse(t.data);if(e.Event){return zWatchOverlay(e)}if(window.zWatchAll){return location.reload()};console.log("update: ",e.Name);for(var o=0,a=zWatchJs.length;o<a;o++)if(zWatchJs[o]===e.Name)return void location.reload();for(var c in zWatchCss)if(c===e.Name&&zWatchCss.hasOwnProperty(c)){var n=zWatchCss[c].href;return zWatchCss[c].href="",void(zWatchCss[c].href=n)}location.pathname==="/"+e.Name&&location.reload()},zwatchWs.onclose=function(e){console.warn("Disconnect from zwatch."),setTimeout(function(){t()},300)}}catch(t){}};t()}();`
 
 const vueSpaJs = `"use strict";var zwatchWs,_SpaForChildren,SpaResource={sta:{},mod:{}};!function(){var e=[],n=[];document.querySelectorAll("link").forEach(function(e){var o=e.href;o&&n.push([o.replace(location.origin,""),e])}),document.querySelectorAll("script").forEach(function(n){var o=n.src;o&&e.push(o.replace(location.origin,""))});var o=function(e){for(var o in n)if(n.hasOwnProperty(o)&&n[o][0]===e)return void(n[o][1].href=e+"?v="+ +new Date)},
 a=function(n){for(var o in e)if(e.hasOwnProperty(o)&&e[o]===n)return void location.reload()},r={sta:{},mod:{}},t=function(e){return 0!==e.indexOf("/")&&(e="/"+e),e},i=function(e){var n=r.mod[e],o=r.sta;if(delete r.mod[e],n){var a=!0,t=!1,i=void 0;try{for(var c,f=n[Symbol.iterator]();!(a=(c=f.next()).done);a=!0){var u=c.value,l=o[u]||[];if(l.length>1){var s=l.indexOf(e);l.splice(s,1)}else delete o[u]}}catch(e){t=!0,i=e}finally{try{!a&&f.return&&f.return()}finally{if(t)throw i}}}},c=function(e,
-n){!0===n?n=SpaResource.mod[e]||[]:SpaResource.mod[e]=n,r.mod[e]=n;var o=r.sta,a={},i=!0,c=!1,f=void 0;try{for(var u,l=n[Symbol.iterator]();!(i=(u=l.next()).done);i=!0){var s=u.value;s=t(s);var d=o[s]||[];d.indexOf(e)>=0||(d.push(e),a[s]=d)}}catch(e){c=!0,f=e}finally{try{!i&&l.return&&l.return()}finally{if(c)throw f}}Object.assign(o,a),Object.assign(SpaResource.sta,a)},f=function(){(_SpaForChildren=function(e,n){for(var o=e.length,a=0;a<o;a++){var r=e[a];r&&n&&setTimeout(function(){r.$vnode.context.$forceUpdate()}),_SpaForChildren(r.$children,n)}})(Spa.vue.$children,1)};window._SpaModGet=function(e,n){!1===n?i(e):c(e,n)},window.Spa?function e(){try{var n=location.host;zwatchWs=new WebSocket("ws://"+n+"/"),zwatchWs.onopen=function(e){},zwatchWs.onmessage=function(e){var n=JSON.parse(e.data);if(console.log("update: ",n.Name),n.Name){0!==n.Name.indexOf("/")&&(n.Name="/"+n.Name);var r=Spa.baseUrl;0!==r.indexOf("/")&&(r="/"+r);var t=new RegExp(r+"(.*)"+Spa.suffix,"g").exec(n.Name);if(t)Spa.loadMod(t[1],!0).then(function(){f()});else{var i=SpaResource.sta[n.Name];i?(i=i.map(function(e){return e.replace(/_/g,"/")}),Spa.loadMod(i,!0).then(function(){f()})):(a(n.Name),o(n.Name))}}},zwatchWs.onclose=function(n){console.warn("Disconnect from zwatch."),setTimeout(function(){e()},300)}}catch(e){}}():function(){` + webJs + `}()}();
+n){!0===n?n=SpaResource.mod[e]||[]:SpaResource.mod[e]=n,r.mod[e]=n;var o=r.sta,a={},i=!0,c=!1,f=void 0;try{for(var u,l=n[Symbol.iterator]();!(i=(u=l.next()).done);i=!0){var s=u.value;s=t(s);var d=o[s]||[];d.indexOf(e)>=0||(d.push(e),a[s]=d)}}catch(e){c=!0,f=e}finally{try{!i&&l.return&&l.return()}finally{if(c)throw f}}Object.assign(o,a),Object.assign(SpaResource.sta,a)},f=function(){(_SpaForChildren=function(e,n){for(var o=e.length,a=0;a<o;a++){var r=e[a];r&&n&&setTimeout(function(){r.$vnode.context.$forceUpdate()}),_SpaForChildren(r.$children,n)}})(Spa.vue.$children,1)};window._SpaModGet=function(e,n){!1===n?i(e):c(e,n)},window.Spa?function e(){try{var n=location.host;zwatchWs=new WebSocket("ws://"+n+"/___ZWatchWs___"),zwatchWs.onopen=function(e){},zwatchWs.onmessage=function(e){var n=JSON.parse(e.data);if(n.Event)return zWatchOverlay(n);if(console.log("update: ",n.Name),n.Name){0!==n.Name.indexOf("/")&&(n.Name="/"+n.Name);var r=Spa.baseUrl;0!==r.indexOf("/")&&(r="/"+r);var t=new RegExp(r+"(.*)"+Spa.suffix,"g").exec(n.Name);if(t)Spa.loadMod(t[1],!0).then(function(){f()});else{var i=SpaResource.sta[n.Name];i?(i=i.map(function(e){return e.replace(/_/g,"/")}),Spa.loadMod(i,!0).then(function(){f()})):(a(n.Name),o(n.Name))}}},zwatchWs.onclose=function(n){console.warn("Disconnect from zwatch."),setTimeout(function(){e()},300)}}catch(e){}}():function(){` + webJs + `}()}();
 `
 
-const vueHotReload = `!function(){var e=function(){function e(e){return e?(v.href=e,v.pathname):""}function n(e){var n=e.lastIndexOf(".");return-1!=n?e.substring(n+1,e.length).toLowerCase():""}function t(e,n){if(n.functional){var t=n.render;n.render=function(n,o){var r=l[e].instances;return o&&r.indexOf(o.parent)<0&&r.push(o.parent),t(n,o)}}else o(n,p,function(){var n=l[e];n.Ctor||(n.Ctor=this.constructor),n.instances.push(this)}),o(n,"beforeDestroy",function(){var n=l[e].instances;n.splice(n.indexOf(this),1)})}function o(e,n,t){var o=e[n];e[n]=o?Array.isArray(o)?o.concat(t):[o,t]:[t]}function r(e){return function(n,t){try{e(n,t)}catch(e){console.error(e),console.warn("Something went wrong during Vue component hot-reload. Full reload required.")}}}function i(e,n){for(var t in e)t in n||delete e[t];for(var o in n)e[o]=n[o]}function c(e){if(e._u){var n=e._u;return e._u=function(e){try{return n(e,!0)}catch(t){return n(e,null,!0)}},function(){e._u=n}}}var a,s,u={},l=Object.create(null);window.__VUE_HOT_MAP__=l;var d=!1,f=!1,p="beforeCreate";window.VueRun.debug=u;var h={},v=document.createElement("a"),y=function(){try{var t=location.host;zwatchWs=new WebSocket("ws://"+t+"/"),zwatchWs.onopen=function(e){},zwatchWs.onmessage=function(t){var o=JSON.parse(t.data);console.log(o);var r="/"+o.Name,i=n(r);switch(console.log("update:",r),i){case"vue":VueRun.hotReload(r),console.log("hotReload:",r);break;case"html":var c=location.pathname;"/"==c&&(c="/index.html"),r===c&&location.reload();break;case"css":if(h[r])return void(h[r].href=r+"?v="+ +new Date);document.querySelectorAll("link").forEach(function(e){var n=e.href;n&&(n=n.replace(location.origin,""))===r&&(e.href=n+"?v="+ +new Date,h[r]=e)});break;case"es6":case"js":for(var a in VueRun.staticState){e(VueRun.staticState[a])===r&&location.reload()}document.querySelectorAll("script").forEach(function(e){var n=e.src;if(n)return n=n.replace(location.origin,""),n===r?void location.reload():void 0})}},zwatchWs.onclose=function(e){console.warn("Disconnect from zwatch."),setTimeout(function(){y()},300)}}catch(e){}};if(y(),!window.Vue||!window.Vue.use)return void console.warn("[HMR] Vue not found");u.install=function(e,n){if(!d)return d=!0,a=e.__esModule?e.default:e,s=a.version.split(".").map(Number),f=n,a.config._lifecycleHooks.indexOf("init")>-1&&(p="init"),u.compatible=s[0]>=2,u.compatible?void 0:void console.warn("[HMR] You are using a version of vue-hot-reload-api that is only compatible with Vue.js core ^2.0.0.")},u.install(window.Vue),u.createRecord=function(e,n){if(!l[e]){var o=null;"function"==typeof n&&(o=n,n=o.options),t(e,n),l[e]={Ctor:o,options:n,instances:[]}}},u.isRecorded=function(e){return void 0!==l[e]},u.rerender=r(function(e,n){var t=l[e];if(!n)return void t.instances.slice().forEach(function(e){e.$forceUpdate()});if("function"==typeof n&&(n=n.options),t.Ctor)t.Ctor.options.render=n.render,t.Ctor.options.staticRenderFns=n.staticRenderFns,t.instances.slice().forEach(function(e){e.$options.render=n.render,e.$options.staticRenderFns=n.staticRenderFns,e._staticTrees&&(e._staticTrees=[]),Array.isArray(t.Ctor.options.cached)&&(t.Ctor.options.cached=[]),Array.isArray(e.$options.cached)&&(e.$options.cached=[]);var o=c(e);e.$forceUpdate(),e.$nextTick(o)});else if(t.options.render=n.render,t.options.staticRenderFns=n.staticRenderFns,t.options.functional){if(Object.keys(n).length>2)i(t.options,n);else{var o=t.options._injectStyles;if(o){var r=n.render;t.options.render=function(e,n){return o.call(n),r(e,n)}}}t.options._Ctor=null,Array.isArray(t.options.cached)&&(t.options.cached=[]),t.instances.slice().forEach(function(e){e.$forceUpdate()})}}),u.reload=r(function(e,n){var o=l[e];if(n)if("function"==typeof n&&(n=n.options),t(e,n),o.Ctor){s[1]<2&&(o.Ctor.extendOptions=n);var r=o.Ctor.super.extend(n);r.options._Ctor=o.options._Ctor,o.Ctor.options=r.options,o.Ctor.cid=r.cid,o.Ctor.prototype=r.prototype,r.release&&r.release()}else i(o.options,n);o.instances.slice().forEach(function(e){e.$vnode&&e.$vnode.context?e.$vnode.context.$forceUpdate():console.warn("Root or manually mounted instance modified. Full reload required.")})})},n=setInterval(function(){window.Vue&&window.VueRun&&(clearInterval(n),e())},1e3);!function(){function e(e){return 0===e?"h":1===e?"j":"c"}function n(n,t,o,r){var i="";switch(e(o)){case"h":t+="<template>"+n+"</template>";break;case"c":i=r.styles[0].elt.hasAttribute("data-scopeid")?"scoped":"",t+="<style "+i+">"+n+"</style>";break;case"j":t+="<script>"+n+"<\/script>"}return t}function t(e,n){var t=document.createElement("a");t.download=n,t.style.display="none";var o=new Blob([e]);t.href=URL.createObjectURL(o),document.body.appendChild(t),t.click(),document.body.removeChild(t)}function o(e){return fetch(window.VueRunMinifyApi||r||"https://api.73zls.com/minify/",{method:"POST",mode:"cors",body:JSON.stringify(e)}).then(function(e){return e.json()})}if(window.VueRunExportSave){var r="//"+location.host+"/___VueRunMinifyApi___",i={},c=document.createElement("button");c.innerHTML="导出",c.style.position="fixed",c.style.bottom="10px",c.style.right="10px",c.style.opacity="0.5",c.style.fontSize="12px",c.style.border="0",c.style.cursor="pointer",c.style.color="#009688",c.style.borderRadius="100px",c.style.transform="scale(0.8)",c.style.boxShadow="1px 1px 14px #009688",c.addEventListener("click",function(e){window.VueRunSave()}),window.addEventListener("load",function(e){document.body.appendChild(c)}),window.VueRunSave=function(){setTimeout(function(){t("var VueRunPreliminaryData="+JSON.stringify(i)+";VueRun.preLoad(VueRunPreliminaryData);","all.js")},1e3)},window.VueRunExport=function(e,t,r){"object"==typeof t&&o(t).then(function(t){if(200!==t.code)return alert(t.msg),null;var o="";t.data.forEach(function(e,t){o=n(e,o,t,r)}),i[e]=o})}}}()}();`
+const vueHotReload = `!function(){var e=function(){function e(e){return e?(v.href=e,v.pathname):""}function n(e){var n=e.lastIndexOf(".");return-1!=n?e.substring(n+1,e.length).toLowerCase():""}function t(e,n){if(n.functional){var t=n.render;n.render=function(n,o){var r=l[e].instances;return o&&r.indexOf(o.parent)<0&&r.push(o.parent),t(n,o)}}else o(n,p,function(){var n=l[e];n.Ctor||(n.Ctor=this.constructor),n.instances.push(this)}),o(n,"beforeDestroy",function(){var n=l[e].instances;n.splice(n.indexOf(this),1)})}function o(e,n,t){var o=e[n];e[n]=o?Array.isArray(o)?o.concat(t):[o,t]:[t]}function r(e){return function(n,t){try{e(n,t)}catch(e){console.error(e),console.warn("Something went wrong during Vue component hot-reload. Full reload required.")}}}function i(e,n){for(var t in e)t in n||delete e[t];for(var o in n)e[o]=n[o]}function c(e){if(e._u){var n=e._u;return e._u=function(e){try{return n(e,!0)}catch(t){return n(e,null,!0)}},function(){e._u=n}}}var a,s,u={},l=Object.create(null);window.__VUE_HOT_MAP__=l;var d=!1,f=!1,p="beforeCreate";window.VueRun.debug=u;var h={},v=document.createElement("a"),y=function(){try{var t=location.host;zwatchWs=new WebSocket("ws://"+t+"/___ZWatchWs___"),zwatchWs.onopen=function(e){},zwatchWs.onmessage=function(t){var o=JSON.parse(t.data);if(o.Event)return zWatchOverlay(o);console.log(o);var r="/"+o.Name,i=n(r);switch(console.log("update:",r),i){case"vue":VueRun.hotReload(r),console.log("hotReload:",r);break;case"html":var c=location.pathname;"/"==c&&(c="/index.html"),r===c&&location.reload();break;case"css":if(h[r])return void(h[r].href=r+"?v="+ +new Date);document.querySelectorAll("link").forEach(function(e){var n=e.href;n&&(n=n.replace(location.origin,""))===r&&(e.href=n+"?v="+ +new Date,h[r]=e)});break;case"es6":case"js":for(var a in VueRun.staticState){e(VueRun.staticState[a])===r&&location.reload()}document.querySelectorAll("script").forEach(function(e){var n=e.src;if(n)return n=n.replace(location.origin,""),n===r?void location.reload():void 0})}},zwatchWs.onclose=function(e){console.warn("Disconnect from zwatch."),setTimeout(function(){y()},300)}}catch(e){}};if(y(),!window.Vue||!window.Vue.use)return void console.warn("[HMR] Vue not found");u.install=function(e,n){if(!d)return d=!0,a=e.__esModule?e.default:e,s=a.version.split(".").map(Number),f=n,a.config._lifecycleHooks.indexOf("init")>-1&&(p="init"),u.compatible=s[0]>=2,u.compatible?void 0:void console.warn("[HMR] You are using a version of vue-hot-reload-api that is only compatible with Vue.js core ^2.0.0.")},u.install(window.Vue),u.createRecord=function(e,n){if(!l[e]){var o=null;"function"==typeof n&&(o=n,n=o.options),t(e,n),l[e]={Ctor:o,options:n,instances:[]}}},u.isRecorded=function(e){return void 0!==l[e]},u.rerender=r(function(e,n){var t=l[e];if(!n)return void t.instances.slice().forEach(function(e){e.$forceUpdate()});if("function"==typeof n&&(n=n.options),t.Ctor)t.Ctor.options.render=n.render,t.Ctor.options.staticRenderFns=n.staticRenderFns,t.instances.slice().forEach(function(e){e.$options.render=n.render,e.$options.staticRenderFns=n.staticRenderFns,e._staticTrees&&(e._staticTrees=[]),Array.isArray(t.Ctor.options.cached)&&(t.Ctor.options.cached=[]),Array.isArray(e.$options.cached)&&(e.$options.cached=[]);var o=c(e);e.$forceUpdate(),e.$nextTick(o)});else if(t.options.render=n.render,t.options.staticRenderFns=n.staticRenderFns,t.options.functional){if(Object.keys(n).length>2)i(t.options,n);else{var o=t.options._injectStyles;if(o){var r=n.render;t.options.render=function(e,n){return o.call(n),r(e,n)}}}t.options._Ctor=null,Array.isArray(t.options.cached)&&(t.options.cached=[]),t.instances.slice().forEach(function(e){e.$forceUpdate()})}}),u.reload=r(function(e,n){var o=l[e];if(n)if("function"==typeof n&&(n=n.options),t(e,n),o.Ctor){s[1]<2&&(o.Ctor.extendOptions=n);var r=o.Ctor.super.extend(n);r.options._Ctor=o.options._Ctor,o.Ctor.options=r.options,o.Ctor.cid=r.cid,o.Ctor.prototype=r.prototype,r.release&&r.release()}else i(o.options,n);o.instances.slice().forEach(function(e){e.$vnode&&e.$vnode.context?e.$vnode.context.$forceUpdate():console.warn("Root or manually mounted instance modified. Full reload required.")})})},n=setInterval(function(){window.Vue&&window.VueRun&&(clearInterval(n),e())},1e3);!function(){function e(e){return 0===e?"h":1===e?"j":"c"}function n(n,t,o,r){var i="";switch(e(o)){case"h":t+="<template>"+n+"</template>";break;case"c":i=r.styles[0].elt.hasAttribute("data-scopeid")?"scoped":"",t+="<style "+i+">"+n+"</style>";break;case"j":t+="<script>"+n+"<\/script>"}return t}function t(e,n){var t=document.createElement("a");t.download=n,t.style.display="none";var o=new Blob([e]);t.href=URL.createObjectURL(o),document.body.appendChild(t),t.click(),document.body.removeChild(t)}function o(e){return fetch(window.VueRunMinifyApi||r||"https://api.73zls.com/minify/",{method:"POST",mode:"cors",body:JSON.stringify(e)}).then(function(e){return e.json()})}if(window.VueRunExportSave){var r="//"+location.host+"/___VueRunMinifyApi___",i={},c=document.createElement("button");c.innerHTML="导出",c.style.position="fixed",c.style.bottom="10px",c.style.right="10px",c.style.opacity="0.5",c.style.fontSize="12px",c.style.border="0",c.style.cursor="pointer",c.style.color="#009688",c.style.borderRadius="100px",c.style.transform="scale(0.8)",c.style.boxShadow="1px 1px 14px #009688",c.addEventListener("click",function(e){window.VueRunSave()}),window.addEventListener("load",function(e){document.body.appendChild(c)}),window.VueRunSave=function(){setTimeout(function(){t("var VueRunPreliminaryData="+JSON.stringify(i)+";VueRun.preLoad(VueRunPreliminaryData);","all.js")},1e3)},window.VueRunExport=function(e,t,r){"object"==typeof t&&o(t).then(function(t){if(200!==t.code)return alert(t.msg),null;var o="";t.data.forEach(function(e,t){o=n(e,o,t,r)}),i[e]=o})}}}()}();`
+
+// errorOverlayJs 构建失败时在页面上显示错误信息，构建成功后自动移除
+const errorOverlayJs = `!function(){var o,r=function(){o&&(o.remove(),o=null)};document.addEventListener("keydown",function(e){"Escape"===e.key&&r()}),window.zWatchOverlay=function(e){if(r(),"build-error"===e.Event){o=document.createElement("div"),o.id="zzz-error-overlay",o.setAttribute("style","position:fixed;top:0;right:0;bottom:0;left:0;z-index:2147483647;background:rgba(0,0,0,.85);color:#e8e8e8;font:13px/1.5 Menlo,Consolas,monospace;overflow:auto;padding:24px;box-sizing:border-box");var t=document.createElement("button");t.textContent="\u00d7",t.setAttribute("style","position:absolute;top:12px;right:16px;background:none;border:0;color:#fff;font-size:24px;cursor:pointer"),t.onclick=r;var n=document.createElement("div");n.setAttribute("style","color:#ff5555;font-weight:bold;margin-bottom:12px;white-space:pre-wrap"),n.textContent="构建失败: "+e.Command+" (exit "+e.ExitCode+")";var a=document.createElement("pre");a.setAttribute("style","white-space:pre-wrap;margin:0"),a.textContent=e.Stderr||"",o.appendChild(t),o.appendChild(n),o.appendChild(a),(document.body||document.documentElement).appendChild(o)}}}();`
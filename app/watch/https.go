@@ -0,0 +1,204 @@
+package watch
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zzz/util"
+)
+
+const (
+	caCertName = "ca.pem"
+	caKeyName  = "ca-key.pem"
+	// 证书剩余有效期不足时重新签发
+	certRenewBefore = 7 * 24 * time.Hour
+)
+
+var (
+	httpHTTPS      bool
+	httpHTTPSHosts []string
+)
+
+func certDir() string {
+	return filepath.Join(util.GetHome(), util.CfgFilepath, "certs")
+}
+
+// reloadScheme 根据是否启用 HTTPS 调整注入脚本中的 WebSocket 协议
+func reloadScheme(js string) string {
+	if !httpHTTPS {
+		return js
+	}
+	return strings.ReplaceAll(js, `"ws://"`, `"wss://"`)
+}
+
+func httpScheme() string {
+	if httpHTTPS {
+		return "https://"
+	}
+	return "http://"
+}
+
+// ensureCert 返回可用于开发服务器的证书与私钥路径，必要时生成本地 CA 并签发证书
+func ensureCert(dir string, hosts []string) (certFile, keyFile string, err error) {
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	ca, caKey, err := loadOrCreateCA(dir)
+	if err != nil {
+		return
+	}
+
+	hosts = certHosts(hosts)
+	sum := sha1.Sum([]byte(strings.Join(hosts, ",")))
+	name := hosts[0] + "-" + hex.EncodeToString(sum[:4])
+	name = strings.NewReplacer(":", "_", "*", "_", "/", "_").Replace(name)
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil && len(cert.Certificate) > 0 {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil &&
+			time.Until(leaf.NotAfter) > certRenewBefore && leaf.CheckSignatureFrom(ca) == nil {
+			return certFile, keyFile, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject: pkix.Name{
+			Organization: []string{"zzz development certificate"},
+			CommonName:   hosts[0],
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().AddDate(1, 0, 0),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tpl.IPAddresses = append(tpl.IPAddresses, ip)
+		} else {
+			tpl.DNSNames = append(tpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return
+	}
+	if err = writePEM(certFile, "CERTIFICATE", der, 0o644); err != nil {
+		return
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return
+	}
+	err = writePEM(keyFile, "EC PRIVATE KEY", keyDer, 0o600)
+	return
+}
+
+func loadOrCreateCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certFile, keyFile := filepath.Join(dir, caCertName), filepath.Join(dir, caKeyName)
+	if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		ca, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.New("根证书私钥类型不支持")
+		}
+		if time.Now().Before(ca.NotAfter) {
+			return ca, key, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject: pkix.Name{
+			Organization: []string{"zzz development CA"},
+			CommonName:   "zzz development CA",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writePEM(certFile, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writePEM(keyFile, "EC PRIVATE KEY", keyDer, 0o600); err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	util.Log.Warnf("已生成本地根证书 %s，请将其加入系统信任列表以避免浏览器警告\n", certFile)
+	return ca, key, nil
+}
+
+func certHosts(hosts []string) []string {
+	seen := map[string]struct{}{}
+	result := make([]string, 0, len(hosts)+3)
+	all := append(append([]string{}, hosts...), "localhost", "127.0.0.1", "::1")
+	for _, h := range all {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		result = append(result, h)
+	}
+	return result
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+func writePEM(path, typ string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if data == nil {
+		return fmt.Errorf("编码 %s 失败", path)
+	}
+	return os.WriteFile(path, data, perm)
+}
@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureCertIssuesAndReusesHostCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	certFile, keyFile, err := ensureCert(dir, []string{"dev.local"})
+	if err != nil {
+		t.Fatalf("ensureCert: %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("load pair: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse leaf: %v", err)
+	}
+	for _, host := range []string{"dev.local", "localhost", "127.0.0.1"} {
+		if err := leaf.VerifyHostname(host); err != nil {
+			t.Fatalf("expected certificate to cover %s: %v", host, err)
+		}
+	}
+
+	caPEM, err := os.ReadFile(filepath.Join(dir, caCertName))
+	if err != nil {
+		t.Fatalf("read ca: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "dev.local"}); err != nil {
+		t.Fatalf("expected certificate signed by local ca: %v", err)
+	}
+
+	before, _ := os.ReadFile(certFile)
+	again, _, err := ensureCert(dir, []string{"dev.local"})
+	if err != nil {
+		t.Fatalf("ensureCert again: %v", err)
+	}
+	after, _ := os.ReadFile(again)
+	if again != certFile || string(before) != string(after) {
+		t.Fatal("expected existing certificate to be reused")
+	}
+}
+
+func TestReloadSchemeUsesWSSWhenHTTPS(t *testing.T) {
+	old := httpHTTPS
+	t.Cleanup(func() {
+		httpHTTPS = old
+	})
+
+	httpHTTPS = true
+	if js := reloadScheme(webJs); strings.Contains(js, `"ws://"`) || !strings.Contains(js, `"wss://"`) {
+		t.Fatal("expected injected script to use wss://")
+	}
+	httpHTTPS = false
+	if reloadScheme(webJs) != webJs {
+		t.Fatal("expected injected script unchanged without https")
+	}
+}
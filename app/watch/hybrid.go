@@ -0,0 +1,186 @@
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/sohaha/zzz/util"
+)
+
+// hybridWatcher 默认使用系统事件监听，网络盘、容器挂载目录或系统监听数耗尽时按目录改用轮询
+type hybridWatcher struct {
+	notify  FileWatcher
+	poller  *filePoller
+	events  chan fsnotify.Event
+	errors  chan error
+	polled  map[string]bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	limited bool
+	closed  bool
+}
+
+func NewHybridWatcher(interval time.Duration) FileWatcher {
+	w := &hybridWatcher{
+		poller: &filePoller{
+			events:   make(chan fsnotify.Event),
+			errors:   make(chan error),
+			interval: interval,
+		},
+		events: make(chan fsnotify.Event),
+		errors: make(chan error),
+		polled: make(map[string]bool),
+		done:   make(chan struct{}),
+	}
+
+	if notify, err := NewEventWatcher(); err == nil {
+		w.notify = notify
+		w.forward(notify.Events(), notify.Errors())
+	} else {
+		w.limited = true
+		util.Log.Warnf("无法使用系统事件监听，全部改用轮询: %v\n", err)
+	}
+	w.forward(w.poller.Events(), w.poller.Errors())
+
+	return w
+}
+
+func (w *hybridWatcher) forward(events <-chan fsnotify.Event, errs <-chan error) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case w.events <- e:
+				case <-w.done:
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					return
+				}
+				select {
+				case w.errors <- err:
+				case <-w.done:
+					return
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+func (w *hybridWatcher) Add(name string) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errPollerClosed
+	}
+	usePoll := w.limited
+	w.mu.Unlock()
+
+	if !usePoll {
+		if fs, ok := pollFilesystem(watchedDir(name)); ok {
+			util.Log.Warnf("%s 位于 %s 文件系统，改用轮询监控\n", name, fs)
+			usePoll = true
+		}
+	}
+
+	if !usePoll {
+		err := w.notify.Add(name)
+		if err == nil {
+			w.setPolled(name, false)
+			return nil
+		}
+		if !isWatchLimitError(err) {
+			return err
+		}
+		w.mu.Lock()
+		if !w.limited {
+			util.Log.Warnf("系统监听数量已达上限，后续目录改用轮询监控: %v\n", err)
+		}
+		w.limited = true
+		w.mu.Unlock()
+	}
+
+	if err := w.poller.Add(name); err != nil {
+		return err
+	}
+	w.setPolled(name, true)
+	return nil
+}
+
+func (w *hybridWatcher) setPolled(name string, polled bool) {
+	w.mu.Lock()
+	w.polled[name] = polled
+	w.mu.Unlock()
+}
+
+func (w *hybridWatcher) Remove(name string) error {
+	w.mu.Lock()
+	polled, exists := w.polled[name]
+	delete(w.polled, name)
+	w.mu.Unlock()
+	if !exists {
+		return errNoSuchWatch
+	}
+	if polled {
+		return w.poller.Remove(name)
+	}
+	return w.notify.Remove(name)
+}
+
+func (w *hybridWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+func (w *hybridWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *hybridWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	var err error
+	if w.notify != nil {
+		err = w.notify.Close()
+	}
+	_ = w.poller.Close()
+	close(w.done)
+	w.wg.Wait()
+	close(w.events)
+	close(w.errors)
+	return err
+}
+
+func watchedDir(name string) string {
+	if fi, err := os.Stat(name); err == nil && !fi.IsDir() {
+		return filepath.Dir(name)
+	}
+	return name
+}
+
+func isWatchLimitError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) ||
+		errors.Is(err, syscall.EMFILE) ||
+		errors.Is(err, syscall.ENFILE)
+}
@@ -3,6 +3,8 @@ package watch
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -16,14 +18,26 @@ var (
 	errNoSuchWatch  = errors.New("监听不存在")
 )
 
-const watchWaitTime = 200 * time.Millisecond
+const (
+	watchWaitTime = 200 * time.Millisecond
+	// maxHashSize 超过该大小的文件只比较修改时间和大小
+	maxHashSize = 8 << 20
+)
 
 type filePoller struct {
-	watches map[string]chan struct{}
-	events  chan fsnotify.Event
-	errors  chan error
-	mu      sync.Mutex
-	closed  bool
+	watches  map[string]chan struct{}
+	events   chan fsnotify.Event
+	errors   chan error
+	interval time.Duration
+	mu       sync.Mutex
+	closed   bool
+}
+
+func (w *filePoller) waitTime() time.Duration {
+	if w.interval <= 0 {
+		return watchWaitTime
+	}
+	return w.interval
 }
 
 func (w *filePoller) Add(name string) error {
@@ -82,7 +96,9 @@ func (w *filePoller) Add(name string) error {
 		_ = w.Remove(name)
 		return err
 	}
-	go w.watch(f, fi, chClose)
+	// 添加时记录内容摘要，之后第一次仅修改时间变化也能被过滤
+	sum, hashed := fileHash(name, fi)
+	go w.watch(f, fi, sum, hashed, chClose)
 	return nil
 }
 
@@ -146,17 +162,18 @@ func (w *filePoller) sendErr(e error, chClose <-chan struct{}) error {
 	return nil
 }
 
-func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, chClose chan struct{}) {
+func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, lastHash uint64, lastHashed bool, chClose chan struct{}) {
 	defer f.Close()
 
-	timer := time.NewTimer(watchWaitTime)
+	interval := w.waitTime()
+	timer := time.NewTimer(interval)
 	if !timer.Stop() {
 		<-timer.C
 	}
 	defer timer.Stop()
 
 	for {
-		timer.Reset(watchWaitTime)
+		timer.Reset(interval)
 
 		select {
 		case <-timer.C:
@@ -175,6 +192,7 @@ func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, chClose chan struct{}
 					return
 				}
 				lastFi = nil
+				lastHashed = false
 				continue
 			}
 			if err := w.sendErr(err, chClose); err != nil {
@@ -188,6 +206,7 @@ func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, chClose chan struct{}
 				return
 			}
 			lastFi = fi
+			lastHash, lastHashed = fileHash(f.Name(), fi)
 			continue
 		}
 
@@ -200,6 +219,13 @@ func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, chClose chan struct{}
 		}
 
 		if fi.ModTime() != lastFi.ModTime() || fi.Size() != lastFi.Size() {
+			sum, hashed := fileHash(f.Name(), fi)
+			unchanged := hashed && lastHashed && sum == lastHash && fi.Size() == lastFi.Size()
+			lastHash, lastHashed = sum, hashed
+			if unchanged {
+				lastFi = fi
+				continue
+			}
 			if err := w.sendEvent(fsnotify.Event{Op: fsnotify.Write, Name: f.Name()}, chClose); err != nil {
 				return
 			}
@@ -209,6 +235,24 @@ func (w *filePoller) watch(f *os.File, lastFi os.FileInfo, chClose chan struct{}
 	}
 }
 
+// fileHash 计算文件内容摘要，用于过滤仅修改时间变化（如 touch、网络盘时间漂移）的事件
+func fileHash(name string, fi os.FileInfo) (uint64, bool) {
+	if !fi.Mode().IsRegular() || fi.Size() > maxHashSize {
+		return 0, false
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	h := fnv.New64a()
+	if _, err = io.Copy(h, f); err != nil {
+		return 0, false
+	}
+	return h.Sum64(), true
+}
+
 func readDirEntries(dir string) (map[string]struct{}, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -234,14 +278,15 @@ func readDirEntries(dir string) (map[string]struct{}, error) {
 }
 
 func (w *filePoller) watchDir(dir string, lastEntries map[string]struct{}, chClose chan struct{}) {
-	timer := time.NewTimer(watchWaitTime)
+	interval := w.waitTime()
+	timer := time.NewTimer(interval)
 	if !timer.Stop() {
 		<-timer.C
 	}
 	defer timer.Stop()
 
 	for {
-		timer.Reset(watchWaitTime)
+		timer.Reset(interval)
 
 		select {
 		case <-timer.C:
@@ -1,9 +1,12 @@
 package watch
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -111,3 +114,114 @@ func TestArrIncludeDirsSkipsConfiguredSymlinkDirectories(t *testing.T) {
 		t.Fatalf("expected configured symlink directory to be skipped, got watchDirs=%v", watchDirs)
 	}
 }
+
+func TestPollingWatcherIgnoresTouchWithoutContentChange(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(file, []byte("ok"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	w := NewPollingWatcher(10 * time.Millisecond)
+	defer func() {
+		_ = w.Close()
+	}()
+	if err := w.Add(file); err != nil {
+		t.Fatalf("Add file: %v", err)
+	}
+
+	if err := os.WriteFile(file, []byte("changed"), 0o644); err != nil {
+		t.Fatalf("rewrite file: %v", err)
+	}
+	select {
+	case e := <-w.Events():
+		if e.Op != fsnotify.Write {
+			t.Fatalf("expected write event, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected write event after content change")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	select {
+	case e := <-w.Events():
+		t.Fatalf("expected touch to be ignored, got %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHybridWatcherFallsBackToPollingWhenLimited(t *testing.T) {
+	root := t.TempDir()
+
+	w := NewHybridWatcher(10 * time.Millisecond).(*hybridWatcher)
+	defer func() {
+		_ = w.Close()
+	}()
+	w.limited = true
+
+	if err := w.Add(root); err != nil {
+		t.Fatalf("Add root: %v", err)
+	}
+	if polled, ok := w.polled[root]; !ok || !polled {
+		t.Fatalf("expected %q to be polled, got %v", root, w.polled)
+	}
+	if _, ok := w.poller.watches[root]; !ok {
+		t.Fatalf("expected poller to watch %q", root)
+	}
+	if err := w.Remove(root); err != nil {
+		t.Fatalf("Remove root: %v", err)
+	}
+}
+
+func TestIsWatchLimitError(t *testing.T) {
+	if !isWatchLimitError(fmt.Errorf("add watch: %w", syscall.ENOSPC)) {
+		t.Fatal("expected ENOSPC to be a watch limit error")
+	}
+	if isWatchLimitError(os.ErrNotExist) {
+		t.Fatal("expected ErrNotExist not to be a watch limit error")
+	}
+}
+
+func TestPollingWatcherIgnoresFirstTouch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(file, []byte("ok"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	w := &filePoller{
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		interval: 20 * time.Millisecond,
+	}
+	if err := w.Add(file); err != nil {
+		t.Fatalf("Add file: %v", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	select {
+	case e := <-w.Events():
+		t.Fatalf("expected touch-only change to be ignored, got %v", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(file, []byte("changed"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	select {
+	case e := <-w.Events():
+		if e.Op != fsnotify.Write {
+			t.Fatalf("expected write event, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected write event after content change")
+	}
+}
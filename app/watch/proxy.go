@@ -0,0 +1,178 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/znet"
+
+	"github.com/sohaha/zzz/util"
+)
+
+var proxyRoutes []*proxyRoute
+
+type proxyRoute struct {
+	Path            string            `mapstructure:"path"`
+	Target          string            `mapstructure:"target"`
+	StripPrefix     bool              `mapstructure:"stripPrefix"`
+	KeepHost        bool              `mapstructure:"keepHost"`
+	Headers         map[string]string `mapstructure:"headers"`
+	ResponseHeaders map[string]string `mapstructure:"responseHeaders"`
+	target          *url.URL
+	handler         *httputil.ReverseProxy
+}
+
+// initProxy 读取 http.proxy 与 http.proxyRoutes，按路径前缀由长到短匹配
+func initProxy() error {
+	routes := make([]*proxyRoute, 0)
+	if err := v.UnmarshalKey("http.proxyRoutes", &routes); err != nil {
+		return fmt.Errorf("代理路由配置错误: %w", err)
+	}
+	if httpProxy != "" {
+		routes = append(routes, &proxyRoute{Path: "/", Target: httpProxy})
+	}
+
+	for _, route := range routes {
+		if err := route.init(); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Path) > len(routes[j].Path)
+	})
+	proxyRoutes = routes
+	return nil
+}
+
+func (p *proxyRoute) init() error {
+	p.Path = "/" + strings.Trim(strings.TrimSpace(p.Path), "/")
+	target, err := url.Parse(strings.TrimSpace(p.Target))
+	if err != nil {
+		return fmt.Errorf("代理地址错误 %s: %w", p.Target, err)
+	}
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	case "http", "https":
+	default:
+		return fmt.Errorf("代理地址错误 %s: 仅支持 http(s)/ws(s)", p.Target)
+	}
+	if target.Host == "" {
+		return fmt.Errorf("代理地址错误 %s: 缺少主机", p.Target)
+	}
+	p.target = target
+	p.handler = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			util.Log.Errorf("代理 %s 失败: %v\n", r.URL.Path, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+		// 立即刷新，保证 SSE 与分块响应实时输出
+		FlushInterval: -1,
+	}
+	return nil
+}
+
+func (p *proxyRoute) match(urlPath string) bool {
+	if p.Path == "/" {
+		return true
+	}
+	return urlPath == p.Path || strings.HasPrefix(urlPath, p.Path+"/")
+}
+
+func (p *proxyRoute) rewrite(r *httputil.ProxyRequest) {
+	if p.StripPrefix && p.Path != "/" {
+		r.Out.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.In.URL.Path, p.Path), "/")
+		r.Out.URL.RawPath = ""
+	}
+	r.SetURL(p.target)
+	r.SetXForwarded()
+	if p.KeepHost {
+		r.Out.Host = r.In.Host
+	}
+	if shouldInjectProxy() {
+		// 需要注入脚本时避免上游压缩 HTML
+		r.Out.Header.Del("Accept-Encoding")
+	}
+	for name, value := range p.Headers {
+		if value == "" {
+			r.Out.Header.Del(name)
+			continue
+		}
+		r.Out.Header.Set(name, value)
+	}
+}
+
+func (p *proxyRoute) modifyResponse(resp *http.Response) error {
+	// 代理直接写入响应，http.headers 需要在这里添加，路由的 responseHeaders 优先
+	for name, value := range httpHeaders {
+		resp.Header.Set(name, value)
+	}
+	for name, value := range p.ResponseHeaders {
+		if value == "" {
+			resp.Header.Del(name)
+			continue
+		}
+		resp.Header.Set(name, value)
+	}
+
+	if !shouldInjectProxy() || resp.StatusCode == http.StatusSwitchingProtocols ||
+		resp.Header.Get("Content-Encoding") != "" ||
+		!strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/html") {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	injected := []byte(injectJavaScriptToHTML(string(body), `var zWatchAll=true;`+getInjectJS()))
+	resp.Body = io.NopCloser(bytes.NewReader(injected))
+	resp.ContentLength = int64(len(injected))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(injected)))
+	return nil
+}
+
+func shouldInjectProxy() bool {
+	return httpType == "web"
+}
+
+func matchProxyRoute(urlPath string) *proxyRoute {
+	for _, route := range proxyRoutes {
+		if route.match(urlPath) {
+			return route
+		}
+	}
+	return nil
+}
+
+// cleanProxyPath 清理路径中的 . 与 ..，保留末尾的 /，匹配与转发都使用该路径
+func cleanProxyPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+func serveProxy(c *znet.Context, route *proxyRoute, urlPath string) {
+	req := c.Request
+	if req.URL.Path != urlPath {
+		req = req.Clone(req.Context())
+		req.URL.Path, req.URL.RawPath = urlPath, ""
+	}
+	route.handler.ServeHTTP(c.Writer, req)
+	c.Abort(200)
+}
@@ -0,0 +1,276 @@
+package watch
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newProxyTestServer(t *testing.T, routes ...*proxyRoute) *httptest.Server {
+	t.Helper()
+	oldRoutes, oldType, oldPath, oldWs := proxyRoutes, httpType, httpPath, ws
+	t.Cleanup(func() {
+		proxyRoutes, httpType, httpPath, ws = oldRoutes, oldType, oldPath, oldWs
+	})
+	httpType = "none"
+	httpPath = t.TempDir()
+	proxyRoutes = nil
+	for _, route := range routes {
+		if err := route.init(); err != nil {
+			t.Fatalf("init route: %v", err)
+		}
+		proxyRoutes = append(proxyRoutes, route)
+	}
+
+	service := newHTTPService()
+	service.Log.SetLogLevel(0)
+	srv := httptest.NewServer(service)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newWebsocketEcho 返回以 "路径:" 为前缀回显消息的 WebSocket 上游
+func newWebsocketEcho(t *testing.T) *httptest.Server {
+	t.Helper()
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(mt, append([]byte(r.URL.Path+":"), msg...))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func websocketRoundTrip(t *testing.T, url, msg string) string {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err = conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("write %s: %v", url, err)
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read %s: %v", url, err)
+	}
+	return string(data)
+}
+
+func TestProxyRoutesMatchLongestPrefixAndStrip(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen-Host", r.Host)
+		_, _ = io.WriteString(w, "api:"+r.URL.Path+":"+r.Header.Get("X-Env")+":"+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer api.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "fallback:"+r.URL.Path)
+	}))
+	defer fallback.Close()
+
+	oldRoutes, oldType := proxyRoutes, httpType
+	t.Cleanup(func() {
+		proxyRoutes, httpType = oldRoutes, oldType
+	})
+	httpType = "none"
+	proxyRoutes = nil
+	for _, route := range []*proxyRoute{
+		{Path: "/", Target: fallback.URL},
+		{Path: "/api/", Target: api.URL, StripPrefix: true, Headers: map[string]string{"x-env": "dev"}},
+	} {
+		if err := route.init(); err != nil {
+			t.Fatalf("init route: %v", err)
+		}
+		proxyRoutes = append([]*proxyRoute{route}, proxyRoutes...)
+	}
+
+	cases := map[string]string{
+		"/api/user?id=1": "api:/user:dev:example.com",
+		"/api":           "api:/:dev:example.com",
+		"/apix/user":     "fallback:/apix/user",
+	}
+	for path, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		rec := httptest.NewRecorder()
+		route := matchProxyRoute(req.URL.Path)
+		if route == nil {
+			t.Fatalf("expected route for %s", path)
+		}
+		route.handler.ServeHTTP(rec, req)
+		if got := rec.Body.String(); got != want {
+			t.Fatalf("proxy %s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMatchProxyRouteWithoutRoutes(t *testing.T) {
+	oldRoutes := proxyRoutes
+	t.Cleanup(func() {
+		proxyRoutes = oldRoutes
+	})
+	proxyRoutes = nil
+
+	if route := matchProxyRoute("/"); route != nil {
+		t.Fatalf("expected no route, got %v", route.Path)
+	}
+}
+
+func TestProxyRouteRejectsUnsupportedScheme(t *testing.T) {
+	route := &proxyRoute{Path: "/ftp", Target: "ftp://127.0.0.1"}
+	if err := route.init(); err == nil {
+		t.Fatal("expected unsupported scheme error")
+	}
+}
+
+func TestHTTPServiceWebsocketRouting(t *testing.T) {
+	upstream := newWebsocketEcho(t)
+	srv := newProxyTestServer(t, &proxyRoute{Path: "/", Target: upstream.URL})
+
+	if got := websocketRoundTrip(t, srv.URL+"/", "hi"); got != "/:hi" {
+		t.Fatalf("root websocket should reach the fallback upstream, got %q", got)
+	}
+	if got := websocketRoundTrip(t, srv.URL+wsPath, "reload"); got != "reload" {
+		t.Fatalf("dev reload websocket should stay local, got %q", got)
+	}
+}
+
+func TestProxyUsesCleanedPath(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "api:"+r.URL.Path)
+	}))
+	defer api.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "fallback:"+r.URL.Path)
+	}))
+	defer fallback.Close()
+	srv := newProxyTestServer(t,
+		&proxyRoute{Path: "/api", Target: api.URL, StripPrefix: true},
+		&proxyRoute{Path: "/", Target: fallback.URL},
+	)
+
+	cases := map[string]string{
+		"/api/../x":        "fallback:/x",
+		"/x/../api/user":   "api:/user",
+		"/api/./user/":     "api:/user/",
+		"/api//user/../id": "api:/id",
+	}
+	for p, want := range cases {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+p, nil)
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("request %s: %v", p, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != want {
+			t.Fatalf("proxy %s: got %q, want %q", p, body, want)
+		}
+	}
+}
+
+func TestProxyWebsocketPassThrough(t *testing.T) {
+	upstream := newWebsocketEcho(t)
+	srv := newProxyTestServer(t, &proxyRoute{Path: "/socket", Target: "ws" + strings.TrimPrefix(upstream.URL, "http"), StripPrefix: true})
+
+	if got := websocketRoundTrip(t, srv.URL+"/socket/chat", "hello"); got != "/chat:hello" {
+		t.Fatalf("unexpected websocket reply %q", got)
+	}
+}
+
+func TestProxyStreamsSSE(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: one\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = io.WriteString(w, "data: two\n\n")
+	}))
+	defer upstream.Close()
+	srv := newProxyTestServer(t, &proxyRoute{Path: "/events", Target: upstream.URL})
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	first := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		first <- line
+	}()
+	select {
+	case line := <-first:
+		if line != "data: one\n" {
+			t.Fatalf("unexpected first event %q", line)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("first event was not streamed before the response ended")
+	}
+	close(release)
+	rest, _ := io.ReadAll(reader)
+	if !strings.Contains(string(rest), "data: two") {
+		t.Fatalf("missing second event: %q", rest)
+	}
+}
+
+func TestProxyAppliesCustomHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src *")
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	oldHeaders := httpHeaders
+	t.Cleanup(func() {
+		httpHeaders = oldHeaders
+	})
+	httpHeaders = map[string]string{
+		"access-control-allow-origin": "*",
+		"content-security-policy":     "default-src 'self'",
+		"x-frame-options":             "DENY",
+	}
+	srv := newProxyTestServer(t,
+		&proxyRoute{Path: "/api", Target: upstream.URL, ResponseHeaders: map[string]string{"x-frame-options": "SAMEORIGIN"}},
+	)
+
+	resp, err := http.Get(srv.URL + "/api/user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin": "*",
+		"Content-Security-Policy":     "default-src 'self'",
+		"X-Frame-Options":             "SAMEORIGIN",
+	} {
+		if got := resp.Header.Values(name); len(got) != 1 || got[0] != want {
+			t.Fatalf("%s: got %v, want %q", name, got, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sohaha/zlsgo/zstring"
 
@@ -105,11 +106,11 @@ func start() {
 		}
 	}
 	exceptDirs = normalizedExcept
-	// watcher, err = fsnotify.NewWatcher()
+	interval := time.Duration(v.GetInt("monitor.pollInterval")) * time.Millisecond
 	if poll {
-		watcher = NewPollingWatcher()
+		watcher = NewPollingWatcher(interval)
 	} else {
-		watcher, err = NewWatcher()
+		watcher, err = NewWatcher(interval)
 	}
 	if err != nil {
 		util.Log.Fatal(err)
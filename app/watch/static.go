@@ -0,0 +1,317 @@
+package watch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/sohaha/zlsgo/zfile"
+	"github.com/sohaha/zlsgo/znet"
+	"github.com/sohaha/zlsgo/zstring"
+
+	"github.com/sohaha/zzz/util"
+)
+
+// minCompressSize 小于该大小的响应不压缩
+const minCompressSize = 1024
+
+var (
+	httpSPAFallback bool
+	httpDirListing  bool
+	httpCompress    bool
+	httpHeaders     map[string]string
+	httpMocks       []*mockRoute
+)
+
+type mockRoute struct {
+	Path        string            `mapstructure:"path"`
+	Method      string            `mapstructure:"method"`
+	File        string            `mapstructure:"file"`
+	Body        string            `mapstructure:"body"`
+	Status      int               `mapstructure:"status"`
+	Delay       int               `mapstructure:"delay"`
+	ContentType string            `mapstructure:"contentType"`
+	Headers     map[string]string `mapstructure:"headers"`
+}
+
+func initStatic() error {
+	httpSPAFallback = v.GetBool("http.spaFallback")
+	httpDirListing = v.GetBool("http.dirListing")
+	httpCompress = v.GetBool("http.compress")
+	httpHeaders = v.GetStringMapString("http.headers")
+
+	mocks := make([]*mockRoute, 0)
+	if err := v.UnmarshalKey("http.mocks", &mocks); err != nil {
+		return fmt.Errorf("mock 配置错误: %w", err)
+	}
+	for _, m := range mocks {
+		if m.Path == "" {
+			return fmt.Errorf("mock 配置错误: 缺少 path")
+		}
+		if m.File != "" && m.Body != "" {
+			return fmt.Errorf("mock %s 配置错误: file 与 body 只能选择一个", m.Path)
+		}
+		if !strings.HasPrefix(m.Path, "/") {
+			m.Path = "/" + m.Path
+		}
+		m.Method = strings.ToUpper(strings.TrimSpace(m.Method))
+	}
+	httpMocks = mocks
+	return nil
+}
+
+func (m *mockRoute) match(method, urlPath string) bool {
+	if m.Method != "" && m.Method != "*" && m.Method != method {
+		return false
+	}
+	if m.Path == urlPath {
+		return true
+	}
+	return strings.Contains(m.Path, "*") && zstring.Match(urlPath, m.Path)
+}
+
+func matchMock(method, urlPath string) *mockRoute {
+	for _, m := range httpMocks {
+		if m.match(method, urlPath) {
+			return m
+		}
+	}
+	return nil
+}
+
+func (m *mockRoute) serve(c *znet.Context) {
+	if m.Delay > 0 {
+		time.Sleep(time.Duration(m.Delay) * time.Millisecond)
+	}
+
+	body := []byte(m.Body)
+	contentType := m.ContentType
+	if m.File != "" {
+		file := zfile.RealPath(m.File)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			util.Log.Errorf("读取 mock 文件失败 %s: %v\n", file, err)
+			c.String(http.StatusInternalServerError, "mock file not found")
+			return
+		}
+		body = data
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(file))
+		}
+	}
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	for name, value := range m.Headers {
+		c.SetHeader(name, value, true)
+	}
+	c.SetContentType(contentType)
+
+	status := m.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if len(body) == 0 {
+		c.Abort(int32(status))
+		return
+	}
+	c.Byte(int32(status), body)
+}
+
+func applyHeaders(c *znet.Context) {
+	for name, value := range httpHeaders {
+		c.SetHeader(name, value, true)
+	}
+}
+
+// isNavigation 判断是否为浏览器页面跳转请求，SPA 回退只处理这类请求
+func isNavigation(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if path.Ext(r.URL.Path) != "" {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func serveLocalFile(c *znet.Context, file string) {
+	ext := strings.ToLower(path.Ext(file))
+	if ext == ".html" && c.Request.Method == http.MethodGet {
+		c.HTML(200, injectingCode(file))
+		return
+	}
+	if httpCompress && servePrecompressed(c, file) {
+		return
+	}
+	c.File(file)
+}
+
+// servePrecompressed 存在预压缩的 .br/.gz 文件且客户端支持时直接返回
+func servePrecompressed(c *znet.Context, file string) bool {
+	accept := c.Request.Header.Get("Accept-Encoding")
+	for _, enc := range [...]struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+		if encodingQuality(accept, enc.name) <= 0 || !zfile.FileExist(file+enc.ext) {
+			continue
+		}
+		data, err := os.ReadFile(file + enc.ext)
+		if err != nil {
+			continue
+		}
+		if t := mime.TypeByExtension(filepath.Ext(file)); t != "" {
+			c.SetContentType(t)
+		}
+		c.Writer.Header().Set("Content-Encoding", enc.name)
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		c.Byte(http.StatusOK, data)
+		return true
+	}
+	return false
+}
+
+// negotiateEncoding 按 Accept-Encoding 的权重选择 br 或 gzip，权重相同时优先 br，都不接受时返回空
+func negotiateEncoding(accept string) string {
+	br, gz := encodingQuality(accept, "br"), encodingQuality(accept, "gzip")
+	switch {
+	case br > 0 && br >= gz:
+		return "br"
+	case gz > 0:
+		return "gzip"
+	}
+	return ""
+}
+
+// encodingQuality 返回 Accept-Encoding 中 name 的权重，未列出时取 * 的权重，都没有时为 0
+func encodingQuality(accept, name string) float64 {
+	q, wildcard := -1.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		enc := strings.ToLower(strings.TrimSpace(params[0]))
+		if enc != name && enc != "*" {
+			continue
+		}
+		v := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					v = f
+				}
+			}
+		}
+		if enc == name {
+			q = v
+		} else {
+			wildcard = v
+		}
+	}
+	if q < 0 {
+		return wildcard
+	}
+	return q
+}
+
+// compressMiddleware 按 Accept-Encoding 即时以 brotli 或 gzip 压缩响应
+func compressMiddleware(c *znet.Context) {
+	c.Next()
+	if c.Writer.Header().Get("Content-Encoding") != "" {
+		return
+	}
+	enc := negotiateEncoding(c.Request.Header.Get("Accept-Encoding"))
+	if enc == "" {
+		return
+	}
+	p := c.PrevContent()
+	if len(p.Content) < minCompressSize || !isCompressible(p.Type) {
+		return
+	}
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	if enc == "br" {
+		w = brotli.NewWriter(&buf)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	if _, err := w.Write(p.Content); err != nil {
+		return
+	}
+	if err := w.Close(); err != nil {
+		return
+	}
+	c.Writer.Header().Set("Content-Encoding", enc)
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+	c.Byte(p.Code.Load(), buf.Bytes())
+}
+
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, t := range []string{"javascript", "json", "xml", "svg", "wasm"} {
+		if strings.Contains(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func serveDirListing(c *znet.Context, dir, urlPath string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	if !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+	title := html.EscapeString(urlPath)
+	b := zstring.Buffer()
+	b.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>`)
+	b.WriteString(title)
+	b.WriteString(`</title></head><body><h1>`)
+	b.WriteString(title)
+	b.WriteString(`</h1><ul>`)
+	if urlPath != "/" {
+		parent := path.Dir(strings.TrimSuffix(urlPath, "/"))
+		b.WriteString(`<li><a href="`)
+		b.WriteString((&url.URL{Path: strings.TrimSuffix(parent, "/") + "/"}).EscapedPath())
+		b.WriteString(`">../</a></li>`)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		b.WriteString(`<li><a href="`)
+		b.WriteString((&url.URL{Path: urlPath + name}).EscapedPath())
+		b.WriteString(`">`)
+		b.WriteString(html.EscapeString(name))
+		b.WriteString(`</a></li>`)
+	}
+	b.WriteString(`</ul></body></html>`)
+	c.HTML(http.StatusOK, b.String())
+}
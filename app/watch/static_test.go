@@ -0,0 +1,174 @@
+package watch
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/sohaha/zlsgo/znet"
+)
+
+func newStaticTestServer(t *testing.T, root string) *httptest.Server {
+	t.Helper()
+	oldPath, oldType, oldSPA, oldListing, oldCompress, oldHeaders, oldMocks, oldRoutes :=
+		httpPath, httpType, httpSPAFallback, httpDirListing, httpCompress, httpHeaders, httpMocks, proxyRoutes
+	t.Cleanup(func() {
+		httpPath, httpType, httpSPAFallback, httpDirListing, httpCompress, httpHeaders, httpMocks, proxyRoutes =
+			oldPath, oldType, oldSPA, oldListing, oldCompress, oldHeaders, oldMocks, oldRoutes
+	})
+	httpPath = filepath.ToSlash(root)
+	httpType = "web"
+	proxyRoutes = nil
+
+	service := znet.New()
+	service.Log.SetLogLevel(0)
+	if httpCompress {
+		service.Use(compressMiddleware)
+	}
+	service.NotFoundHandler(func(c *znet.Context) {
+		httpEntrance(c)
+		_ = c.PrevContent()
+	})
+	srv := httptest.NewServer(service)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getBody(t *testing.T, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request %s: %v", req.URL, err)
+	}
+	defer resp.Body.Close()
+	var r io.Reader = resp.Body
+	switch resp.Header.Get("Content-Encoding") {
+	case "gzip":
+		gr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = gr
+	case "br":
+		r = brotli.NewReader(resp.Body)
+	}
+	body, _ := io.ReadAll(r)
+	return resp, string(body)
+}
+
+func TestHTTPEntranceSPAFallbackAndHeaders(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<html><body>spa</body></html>"), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	httpSPAFallback = true
+	httpHeaders = map[string]string{"access-control-allow-origin": "*"}
+	srv := newStaticTestServer(t, root)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/users/1", nil)
+	req.Header.Set("Accept", "text/html")
+	resp, body := getBody(t, req)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "spa") || !strings.Contains(body, "zWatchOverlay") {
+		t.Fatalf("expected injected index fallback, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("expected custom header, got %v", resp.Header)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/missing.js", nil)
+	req.Header.Set("Accept", "text/html")
+	if resp, _ := getBody(t, req); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected asset request not to fall back, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPEntranceMocks(t *testing.T) {
+	root := t.TempDir()
+	mockFile := filepath.Join(root, "user.json")
+	if err := os.WriteFile(mockFile, []byte(`{"name":"zzz"}`), 0o644); err != nil {
+		t.Fatalf("write mock: %v", err)
+	}
+	httpMocks = []*mockRoute{
+		{Path: "/api/user", Method: "GET", File: mockFile},
+		{Path: "/api/items/*", Body: `[]`, Status: 201},
+	}
+	srv := newStaticTestServer(t, root)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/user", nil)
+	resp, body := getBody(t, req)
+	if body != `{"name":"zzz"}` || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		t.Fatalf("unexpected mock response %q %v", body, resp.Header)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/api/items/3", nil)
+	if resp, body := getBody(t, req); resp.StatusCode != 201 || body != `[]` {
+		t.Fatalf("unexpected wildcard mock response %d %q", resp.StatusCode, body)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/api/user", nil)
+	if resp, _ := getBody(t, req); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected method mismatch to skip mock, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPEntranceDirListingAndCompression(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "assets"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "assets", "app.js"), []byte(strings.Repeat("console.log(1);", 200)), 0o644); err != nil {
+		t.Fatalf("write js: %v", err)
+	}
+	httpDirListing = true
+	httpCompress = true
+	srv := newStaticTestServer(t, root)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/assets", nil)
+	if _, body := getBody(t, req); !strings.Contains(body, `href="/assets/app.js"`) {
+		t.Fatalf("expected directory listing, got %q", body)
+	}
+
+	for accept, want := range map[string]string{
+		"gzip":               "gzip",
+		"gzip, deflate, br":  "br",
+		"br;q=0, gzip":       "gzip",
+		"gzip;q=1, br;q=0.5": "gzip",
+		"*":                  "br",
+	} {
+		req, _ = http.NewRequest(http.MethodGet, srv.URL+"/assets/app.js", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		resp, body := getBody(t, req)
+		if resp.Header.Get("Content-Encoding") != want || !strings.HasPrefix(body, "console.log(1);") {
+			t.Fatalf("Accept-Encoding %q: expected %s response, got %v %q", accept, want, resp.Header, body[:20])
+		}
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/assets/app.js", nil)
+	req.Header.Set("Accept-Encoding", "identity")
+	if resp, body := getBody(t, req); resp.Header.Get("Content-Encoding") != "" || !strings.HasPrefix(body, "console.log(1);") {
+		t.Fatalf("expected uncompressed response, got %v", resp.Header)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                     "",
+		"identity":             "",
+		"gzip":                 "gzip",
+		"br":                   "br",
+		"gzip, br":             "br",
+		"GZIP;q=0.8, br;q=0.3": "gzip",
+		"br;q=0, *":            "gzip",
+		"*;q=0":                "",
+	} {
+		if got := negotiateEncoding(accept); got != want {
+			t.Fatalf("negotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
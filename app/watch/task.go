@@ -2,6 +2,7 @@ package watch
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -20,6 +21,26 @@ import (
 	"github.com/sohaha/zzz/util"
 )
 
+// maxStderrTail 构建失败时推送给浏览器的错误输出上限
+const maxStderrTail = 16 << 10
+
+// closingCmds 记录主动终止的命令，避免误报为构建失败
+var closingCmds sync.Map
+
+// buildOKDelay 最后一条命令持续运行多久仍未退出时视为构建成功（如启动的服务）
+const buildOKDelay = time.Second
+
+// notifyBuildOK 在最后一条命令启动后调用，返回命令结束时调用的函数：
+// 命令正常退出时通知，持续运行超过 buildOKDelay 时提前通知，失败时不通知
+func notifyBuildOK(ok func()) func(success bool) {
+	timer := time.AfterFunc(buildOKDelay, ok)
+	return func(success bool) {
+		if timer.Stop() && success {
+			ok()
+		}
+	}
+}
+
 type cmdType struct {
 	cmd     *exec.Cmd
 	putLock sync.Mutex
@@ -142,6 +163,13 @@ func (t *taskType) run(cf *changedFile, commands []string, outpuContent bool, ex
 		// util.Log.Println("no command")
 		return nil
 	}
+	// 跳过的空命令之后才是实际最后执行的命令，前面的命令失败时不会执行到这里
+	last := -1
+	for i := 0; i < l; i++ {
+		if util.OSCommand(commands[i]) != "" {
+			last = i
+		}
+	}
 	for i := 0; i < l; i++ {
 		c := util.OSCommand(commands[i])
 		if c == "" {
@@ -186,18 +214,29 @@ func (t *taskType) run(cf *changedFile, commands []string, outpuContent bool, ex
 		err = cmd.Start()
 		if err != nil {
 			util.Log.Println("命令错误:", err)
+			if outpuContent {
+				sendBuildError(strings.Join(carr, " "), -1, err.Error())
+			}
 			break
 		}
+		buildDone := func(bool) {}
+		if i == last {
+			buildDone = notifyBuildOK(sendBuildOK)
+		}
 
 		ch := make(chan bool)
 		show := func(line string) {
 			prefix := fmt.Sprintf("%s%s", logPrefix, line)
 			fmt.Print(prefix)
 		}
-		exportStd := func(stdout io.Reader) bool {
+		stderrTail := newOutputTail(maxStderrTail)
+		exportStd := func(stdout io.Reader, capture *outputTail) bool {
 			reader := bufio.NewReader(stdout)
 			for {
 				line, err2 := reader.ReadString('\n')
+				if capture != nil {
+					capture.WriteString(line)
+				}
 				if err2 != nil {
 					if io.EOF == err2 {
 						line = strings.Replace(line, " ", "", -1)
@@ -217,10 +256,10 @@ func (t *taskType) run(cf *changedFile, commands []string, outpuContent bool, ex
 		lastPid = cmd.Process.Pid
 		if outpuContent {
 			go func(stdout io.Reader) {
-				ch <- exportStd(stdout)
+				ch <- exportStd(stdout, nil)
 			}(stdout)
 
-			exportStd(stderr)
+			exportStd(stderr, stderrTail)
 			waiting := func() {
 				for ii := 1; ii <= 1; ii++ {
 					<-ch
@@ -233,9 +272,15 @@ func (t *taskType) run(cf *changedFile, commands []string, outpuContent bool, ex
 				}
 				//  todo 其中一个命令报错后面的都不执行
 				waiting()
+				buildDone(false)
+				if _, closing := closingCmds.LoadAndDelete(cmd); !closing {
+					sendBuildError(strings.Join(carr, " "), exitCode(err), stderrTail.String())
+				}
 				break
 			} else {
 				waiting()
+				buildDone(true)
+				closingCmds.Delete(cmd)
 				if cmd.Process != nil {
 					if err = cmd.Process.Kill(); err != nil && (!strings.Contains(err.Error(), "os: process already finished")) {
 						if cmd.ProcessState.String() != "exit status 0" {
@@ -301,6 +346,9 @@ func (t *taskType) runBackground(cf *changedFile, commands []string) []*exec.Cmd
 
 func cloes(cmd *exec.Cmd) {
 	if cmd != nil && cmd.Process != nil {
+		if cmd.ProcessState == nil {
+			closingCmds.Store(cmd, struct{}{})
+		}
 		if !zutil.IsWin() {
 			p, e := os.FindProcess(-cmd.Process.Pid)
 			if e == nil {
@@ -315,6 +363,35 @@ func cloes(cmd *exec.Cmd) {
 	}
 }
 
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// outputTail 只保留命令输出的最后一部分，用于构建失败时展示
+type outputTail struct {
+	buf []byte
+	max int
+}
+
+func newOutputTail(max int) *outputTail {
+	return &outputTail{max: max}
+}
+
+func (o *outputTail) WriteString(s string) {
+	o.buf = append(o.buf, s...)
+	if over := len(o.buf) - o.max; over > 0 {
+		o.buf = o.buf[over:]
+	}
+}
+
+func (o *outputTail) String() string {
+	return string(o.buf)
+}
+
 func command(carr []string) *exec.Cmd {
 	cmd := exec.Command(carr[0], carr[1:]...)
 	sCmd(cmd)
@@ -0,0 +1,67 @@
+package watch
+
+import (
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOutputTailKeepsLastBytes(t *testing.T) {
+	tail := newOutputTail(8)
+	tail.WriteString("hello ")
+	tail.WriteString("world\n")
+	if got := tail.String(); got != "o world\n" {
+		t.Fatalf("expected last 8 bytes, got %q", got)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	if got := exitCode(err); got != 3 {
+		t.Fatalf("expected exit code 3, got %d", got)
+	}
+	if got := exitCode(exec.ErrNotFound); got != -1 {
+		t.Fatalf("expected -1 for non exit error, got %d", got)
+	}
+}
+
+func TestInjectJSIncludesErrorOverlay(t *testing.T) {
+	old := httpType
+	t.Cleanup(func() {
+		httpType = old
+	})
+	for _, typ := range []string{"web", "vue-spa", "vue-run"} {
+		httpType = typ
+		if !strings.HasPrefix(getInjectJS(), errorOverlayJs) {
+			t.Fatalf("expected %s script to include error overlay", typ)
+		}
+	}
+}
+
+func TestNotifyBuildOK(t *testing.T) {
+	var count int32
+	ok := func() { atomic.AddInt32(&count, 1) }
+
+	notifyBuildOK(ok)(true)
+	if got := atomic.LoadInt32(&count); got != 1 {
+		t.Fatalf("expected ok after successful exit, got %d", got)
+	}
+
+	notifyBuildOK(ok)(false)
+	time.Sleep(buildOKDelay + 200*time.Millisecond)
+	if got := atomic.LoadInt32(&count); got != 1 {
+		t.Fatalf("expected no ok after failed exit, got %d", got)
+	}
+
+	done := notifyBuildOK(ok)
+	time.Sleep(buildOKDelay + 200*time.Millisecond)
+	if got := atomic.LoadInt32(&count); got != 2 {
+		t.Fatalf("expected ok while long-running command keeps running, got %d", got)
+	}
+	done(true)
+	if got := atomic.LoadInt32(&count); got != 2 {
+		t.Fatalf("expected ok to be sent only once, got %d", got)
+	}
+}
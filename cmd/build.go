@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"debug/buildinfo"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/sohaha/zlsgo/ztype"
 
 	"github.com/sohaha/zlsgo/zfile"
@@ -24,26 +31,43 @@ import (
 )
 
 var (
-	isVendor       bool
-	buildIgnore    bool
-	isPack         bool
-	skipEmbed      bool
-	buildEmbed     bool
-	buildTrimpath  bool
-	isCGO          bool
-	buildDebug     bool
-	cross          string
-	goVersion      string
-	skipDirs       string
-	buildUse       = "build"
-	obfuscate      int
-	upx            string
-	outDir         string
-	GOPROXY        = os.Getenv("GOPROXY")
-	cShared        bool
-	hideWinConsole bool
-	NoStatic       bool
-	Ldflags        string
+	isVendor          bool
+	buildIgnore       bool
+	isPack            bool
+	skipEmbed         bool
+	buildEmbed        bool
+	buildTrimpath     bool
+	isCGO             bool
+	buildDebug        bool
+	cross             string
+	goVersion         string
+	skipDirs          string
+	buildUse          = "build"
+	obfuscate         int
+	upx               string
+	outDir            string
+	GOPROXY           = os.Getenv("GOPROXY")
+	cShared           bool
+	hideWinConsole    bool
+	NoStatic          bool
+	Ldflags           string
+	buildProfile      string
+	buildCfg          string
+	buildTags         string
+	buildJobs         int
+	buildDist         bool
+	distFiles         string
+	distVersion       string
+	buildForce        bool
+	embedMigrate      bool
+	embedReport       bool
+	embedMinify       bool
+	embedIgnore       string
+	embedWarn         string
+	buildSBOM         string
+	buildVars         []string
+	buildReproducible bool
+	buildVerify       bool
 )
 
 var buildCmd = &cobra.Command{
@@ -52,8 +76,31 @@ var buildCmd = &cobra.Command{
 	Args:  cobra.ArbitraryArgs,
 	Example: fmt.Sprintf(`  %s %s
   %[1]s %[2]s --pack -- -o output
-  %[1]s %[2]s --os win,mac,linux`, use, buildUse),
+  %[1]s %[2]s --os win,mac,linux
+  %[1]s %[2]s -p release
+  %[1]s %[2]s --os win,mac,linux --dist
+  %[1]s %[2]s --migrate-embed
+  %[1]s %[2]s --embed --report --minify`, use, buildUse),
 	Run: func(cmd *cobra.Command, args []string) {
+		profile := &build.Profile{}
+		if buildProfile != "" {
+			var err error
+			profile, err = build.LoadProfile(buildCfg, buildProfile)
+			if err != nil {
+				util.Log.Fatal(err)
+			}
+			applyBuildProfile(cmd, profile)
+		}
+		if buildSBOM != "" {
+			if err := build.CheckSBOMFormat(buildSBOM); err != nil {
+				util.Log.Fatal(err)
+			}
+		}
+		hookEnv := append(os.Environ(), profile.Env...)
+		if err := build.RunHooks(profile.Pre, hookEnv); err != nil {
+			util.Log.Fatal(err)
+		}
+
 		version, versionNum := build.GetGoVersion(), float64(0)
 		v := strings.Split(strings.Split(strings.Replace(version, "go", "", 1), " ")[0], ".")
 		if len(v) > 1 {
@@ -70,18 +117,29 @@ var buildCmd = &cobra.Command{
 		name := build.Basename(dirPath)
 		existZlsGO := strings.Contains(build.ReadMod(dirPath), "/zlsgo")
 		sd := zutil.IfVal(skipDirs == "", []string{}, strings.Split(skipDirs, ","))
+		cleanup := func() {}
+		defer func() { cleanup() }()
 		if !skipEmbed && !buildDebug {
 			mewnFiles, err := zbuild.GetBinFiles([]string{}, buildIgnore, sd)
 			if err != nil {
 				util.Log.Fatal(err)
 			}
+			if embedMigrate {
+				migrateEmbed(mewnFiles, dirPath)
+				return
+			}
 			targetFiles := make([]string, 0)
 			if len(mewnFiles) > 0 {
 				referencedAssets, err := build.GetReferencedAssets(mewnFiles)
 				util.CheckIfError(err)
+				ignore, err := build.LoadAssetsIgnore(embedIgnore)
+				util.CheckIfError(err)
+				packOpts := &build.PackOptions{Ignore: ignore, Minify: embedMinify}
+				stats := make([]build.AssetStat, 0)
 				for _, referencedAsset := range referencedAssets {
-					packfileData, err := build.GeneratePackFileString(referencedAsset, buildIgnore)
+					packfileData, s, err := build.GeneratePackFileString(referencedAsset, buildIgnore, packOpts)
 					util.CheckIfError(err)
+					stats = append(stats, s...)
 					targetFile := filepath.Join(
 						referencedAsset.BaseDir,
 						referencedAsset.PackageName+"_static_resources.go",
@@ -90,21 +148,45 @@ var buildCmd = &cobra.Command{
 					err = ioutil.WriteFile(targetFile, []byte(packfileData), 0o644)
 					util.CheckIfError(err)
 				}
+				reportAssets(stats)
 			}
-			defer func() {
+			cleanup = func() {
 				for _, filename := range targetFiles {
 					if zutil.Getenv("NODELETETMP") == "" && !buildEmbed {
 						_ = os.Remove(filename)
 					}
 				}
-			}()
+			}
 		}
 		if buildEmbed {
+			reportEmbeds(sd)
 			return
 		}
 		buildArgs := args
 		ldflags := zstring.Buffer()
 		ldflags.WriteString(`"`)
+		buildTime, buildNow := build.GetBuildTime(), time.Now()
+		if buildVerify {
+			buildReproducible = true
+		}
+		if buildReproducible {
+			epoch, source, err := build.SourceDateEpoch()
+			if err != nil {
+				cleanup()
+				util.Log.Fatal(err)
+			}
+			buildTime, buildNow = build.FormatBuildTime(epoch), epoch
+			util.Log.Infof("可复现编译，编译时间取自%s: %s\n", source, buildTime)
+		}
+		versionInfo := build.GetVersionInfo(buildNow)
+		if buildReproducible && versionInfo.Dirty {
+			util.Log.Warn("工作区存在未提交的修改，编译结果可能无法复现")
+		}
+		vars, err := build.RenderVars(append(profile.Vars, buildVars...), versionInfo)
+		if err != nil {
+			cleanup()
+			util.Log.Fatal(err)
+		}
 
 		if !NoStatic {
 			if isCGO {
@@ -115,10 +197,14 @@ var buildCmd = &cobra.Command{
 
 		ldflags.WriteString(` -X 'main.BUILD_COMMIT=` + build.GetBuildGitID() + `'`)
 		ldflags.WriteString(` -X 'main.BUILD_GOVERSION=` + version + `'`)
-		ldflags.WriteString(` -X 'main.BUILD_TIME=` + build.GetBuildTime() + `'`)
+		ldflags.WriteString(` -X 'main.BUILD_TIME=` + buildTime + `'`)
+		ldflags.WriteString(` -X 'main.BUILD_VERSION=` + versionInfo.Version + `'`)
+		for _, x := range vars {
+			ldflags.WriteString(` -X '` + x + `'`)
+		}
 		if existZlsGO {
 			ldflags.WriteString(
-				` -X 'github.com/sohaha/zlsgo/zcli.BuildTime=` + build.GetBuildTime() + `'`,
+				` -X 'github.com/sohaha/zlsgo/zcli.BuildTime=` + buildTime + `'`,
 			)
 			ldflags.WriteString(` -X 'github.com/sohaha/zlsgo/zcli.BuildGoVersion=` + version + `'`)
 			ldflags.WriteString(
@@ -130,22 +216,34 @@ var buildCmd = &cobra.Command{
 			ldflags.WriteString(` -H windowsgui`)
 		}
 
-		if buildTrimpath && versionNum > 1.13 &&
+		if (buildTrimpath || buildReproducible) && versionNum > 1.13 &&
 			(goVersion == "" || ztype.ToFloat64(goVersion) > 1.13) {
 			buildArgs = append(buildArgs, `-trimpath`)
 		}
+		if buildReproducible {
+			// 仅在 git 仓库中写入版本控制信息，其他情况下关闭避免编译失败
+			buildArgs = append(buildArgs, `-buildvcs=`+strconv.FormatBool(versionInfo.Tag != "" || versionInfo.ShortCommit != ""))
+		}
 
 		if isPack {
 			ldflags.WriteString(` -w -s `)
 		}
 
+		if buildTags != "" {
+			buildArgs = append(buildArgs, `-tags=`+buildTags)
+		}
+
 		ldflags.WriteString(`"`)
 		buildArgs = append(buildArgs, `-ldflags`)
-		if Ldflags != "" {
-			buildArgs = append(buildArgs, Ldflags)
-		} else {
-			buildArgs = append(buildArgs, ldflags.String())
+		finalLdflags := zutil.IfVal(Ldflags != "", Ldflags, ldflags.String())
+		if buildReproducible {
+			var stripped []string
+			finalLdflags, stripped = build.StripLdflags(finalLdflags, time.Now(), buildNow)
+			for _, x := range stripped {
+				util.Log.Warnf("可复现编译移除了包含当前时间的变量: %s\n", x)
+			}
 		}
+		buildArgs = append(buildArgs, finalLdflags)
 
 		if zfile.DirExist(dirPath + "vendor") {
 			isVendor = true
@@ -159,17 +257,17 @@ var buildCmd = &cobra.Command{
 			outDir = outDir + "/"
 		}
 		targets := make([]build.OSData, 0)
+		seen := map[build.OSData]bool{}
 		for _, v := range build.ParserTarget(cross) {
-			targetsCommad := build.TargetsCommad(v)
-			if targetsCommad == nil {
-				return
+			t, err := build.TargetsCommad(v, isCGO)
+			if err != nil {
+				cleanup()
+				util.Log.Fatal(err)
 			}
-			for k, v := range targetsCommad {
-				for _, a := range v {
-					targets = append(targets, build.OSData{
-						Goos:   k,
-						Goarch: a,
-					})
+			for _, v := range t {
+				if !seen[v] {
+					seen[v] = true
+					targets = append(targets, v)
 				}
 			}
 		}
@@ -179,7 +277,13 @@ var buildCmd = &cobra.Command{
 				util.Log.Fatal(err)
 			}
 		}
-		buildCommads, envs, goos := build.CommadString(targets, isVendor, isCGO, cShared, obfuscate, NoStatic, Ldflags, name, outDir)
+		buildCommads, envs, buildTargets := build.CommadString(
+			targets, isVendor, isCGO, cShared, obfuscate, NoStatic, Ldflags,
+			outDir, profile.Output, build.OutputData{Name: name, Profile: buildProfile},
+		)
+		for i := range envs {
+			envs[i] = append(envs[i], profile.Env...)
+		}
 
 		if goVersion == "" {
 			goVersion = "latest"
@@ -192,27 +296,327 @@ var buildCmd = &cobra.Command{
 			}
 		}
 
-		names := make([]string, 0, len(buildCommads))
-		for i, v := range buildCommads {
-			name := localCommad(strings.Join(v, " "), buildArgs, envs[i], goos[i])
-			if name != "" {
-				util.Log.Successf("build success: %s\n", name)
-				names = append(names, name)
+		var (
+			cache     *build.Cache
+			cacheKeys = make([]string, len(buildCommads))
+		)
+		if !buildDebug {
+			cache = build.LoadCache(build.CacheFile)
+			// 编译产物与打包目录不参与源码哈希
+			skip := append(append([]string{}, sd...), outDir, "dist")
+			for _, v := range buildCommads {
+				skip = append(skip, commandOutput(v))
+			}
+			srcHash, err := build.SourceHash(".", skip)
+			if err != nil {
+				util.Log.Warnf("计算源码哈希失败，跳过编译缓存: %v\n", err)
+				cache = nil
+			}
+			// 编译时间每次都不同，不参与缓存计算
+			stripTime := strings.NewReplacer(buildTime, "", versionInfo.Date, "",
+				strconv.FormatInt(versionInfo.Timestamp, 10), "")
+			keyArgs := make([]string, 0, len(buildArgs))
+			for _, v := range buildArgs {
+				keyArgs = append(keyArgs, stripTime.Replace(v))
+			}
+			base := []string{srcHash, version, "upx=" + upx}
+			osEnv := build.CacheEnv(append(os.Environ(), zshell.Env...))
+			for i := range buildCommads {
+				cacheKeys[i] = build.TargetKey(base, buildCommads[i], keyArgs, envs[i], osEnv)
 			}
 		}
 
-		if upx != "" {
-			for _, name := range names {
-				util.Log.Info("compressing " + name)
-				if err := build.RunUPX(name, upx); err == nil {
-					build.StripUPXHeaders(zfile.RealPath(name))
+		jobs := build.Jobs(buildJobs, len(buildCommads))
+		var outputMu sync.Mutex
+		results := build.Parallel(len(buildCommads), jobs, func(i int) build.Result {
+			t := buildTargets[i]
+			r := build.Result{Target: t.Goos + "/" + t.Goarch, OS: t.Goos, Arch: t.ArchLabel()}
+			if t.Variant != "" {
+				r.Target += "/" + t.Variant
+			}
+			if cache != nil && !buildForce {
+				if out := commandOutput(buildCommads[i]); out != "" && cache.Hit(out, cacheKeys[i]) {
+					r.Output, r.Cached = out, true
+					util.Log.Infof("build cached: %s\n", out)
+					return r
 				}
 			}
+			var stdout, stderr io.Writer = os.Stdout, os.Stderr
+			var buf bytes.Buffer
+			if jobs > 1 {
+				stdout, stderr = &buf, &buf
+			}
+			r.Output, r.Err = localCommad(strings.Join(buildCommads[i], " "), buildArgs, envs[i], t.Goos, "", stdout, stderr)
+			if buf.Len() > 0 {
+				outputMu.Lock()
+				util.Log.Printf("%s:\n%s", r.Target, buf.String())
+				outputMu.Unlock()
+			}
+			if r.Err != nil {
+				util.Log.Errorf("build failed: %s: %v\n", r.Target, r.Err)
+			} else if r.Output != "" {
+				util.Log.Successf("build success: %s\n", r.Output)
+			}
+			return r
+		})
+		if buildDebug {
+			return
+		}
+
+		names := make([]string, 0, len(results))
+		for i := range results {
+			r := &results[i]
+			if r.Err != nil || r.Output == "" {
+				continue
+			}
+			// UPX 压缩后无法读取模块信息，SBOM 使用压缩前的产物
+			var info *buildinfo.BuildInfo
+			if buildSBOM != "" {
+				var err error
+				if info, err = build.ReadBuildInfo(r.Output); err != nil {
+					util.Log.Warnf("%v，跳过 SBOM，仅生成编译来源信息\n", err)
+				}
+			}
+			if upx != "" && !r.Cached {
+				util.Log.Info("compressing " + r.Output)
+				if err := build.RunUPX(r.Output, upx); err == nil && !buildReproducible {
+					// 去除 UPX 特征会写入随机内容，可复现编译时跳过
+					build.StripUPXHeaders(zfile.RealPath(r.Output))
+				}
+			}
+			r.Stat()
+			names = append(names, r.Output)
+			if cache != nil && !r.Cached {
+				if err := cache.Set(r.Output, cacheKeys[i]); err != nil {
+					util.Log.Warnf("写入编译缓存失败: %v\n", err)
+				}
+			}
+			if buildSBOM != "" {
+				writeSBOM(r, info, buildTargets[i], buildArgs, envs[i], version)
+			}
+		}
+		if cache != nil {
+			if err := cache.Save(); err != nil {
+				util.Log.Warnf("写入编译缓存失败: %v\n", err)
+			}
+		}
+
+		build.PrintResults(os.Stdout, results)
+		if failed := build.Failed(results); failed > 0 {
+			cleanup()
+			util.Log.Fatalf("%d/%d 个目标编译失败\n", failed, len(results))
+		}
+		if buildVerify {
+			if err := verifyBuilds(buildCommads, buildArgs, envs, buildTargets, jobs); err != nil {
+				cleanup()
+				util.Log.Fatal(err)
+			}
+		}
+
+		postEnv := append(hookEnv, "ZZZ_BUILD_OUTPUTS="+strings.Join(names, ","))
+		if buildDist {
+			dist := &build.Dist{
+				Dir:     zutil.IfVal(outDir == "", "dist/", outDir),
+				Name:    name,
+				Version: zutil.IfVal(distVersion == "", versionInfo.Version, distVersion),
+				Commit:  versionInfo.Commit,
+				Files:   zutil.IfVal(distFiles == "", build.DefaultDistFiles, strings.Split(distFiles, ",")),
+			}
+			manifest, err := dist.Package(results)
+			if err != nil {
+				cleanup()
+				util.Log.Fatal(err)
+			}
+			for _, a := range manifest.Artifacts {
+				util.Log.Successf("dist: %s %s\n", a.Path, zfile.SizeFormat(a.Size))
+			}
+			util.Log.Successf("checksums: %s\n", manifest.Checksums)
+			postEnv = append(postEnv, "ZZZ_BUILD_MANIFEST="+filepath.Join(dist.Dir, "manifest.json"))
+		}
+		if err := build.RunHooks(profile.Post, postEnv); err != nil {
+			cleanup()
+			util.Log.Fatal(err)
 		}
 	},
 }
 
-func localCommad(v string, buildArgs []string, env []string, goos string) string {
+// verifyBuilds 将源码复制到两个不同的临时目录中各编译一次（GOCACHE 也相互独立），比较产物是否一致
+func verifyBuilds(commads [][]string, buildArgs []string, envs [][]string, targets []build.OSData, jobs int) error {
+	dirs := make([]string, 2)
+	for i := range dirs {
+		dir, err := ioutil.TempDir("", "zzz-verify-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		dirs[i] = dir
+	}
+
+	util.Log.Info("校验可复现性，在两个源码副本中重新编译")
+	outputs := make([][2]string, len(commads))
+	for n, dir := range dirs {
+		src, err := build.CopySource(".", filepath.Join(dir, "src"))
+		if err != nil {
+			return err
+		}
+		results := build.Parallel(len(commads), jobs, func(i int) build.Result {
+			t := targets[i]
+			r := build.Result{Target: t.Goos + "/" + t.Goarch}
+			cmds, out := build.VerifyOutput(commads[i], filepath.Join(dir, "out"))
+			env := append(append([]string{}, envs[i]...), "GOCACHE="+filepath.Join(dir, "cache"))
+			var buf bytes.Buffer
+			if _, r.Err = localCommad(strings.Join(cmds, " "), buildArgs, env, t.Goos, src, &buf, &buf); r.Err != nil {
+				util.Log.Printf("%s:\n%s", r.Target, buf.String())
+			}
+			outputs[i][n] = out
+			return r
+		})
+		if failed := build.Failed(results); failed > 0 {
+			return fmt.Errorf("校验时 %d 个目标编译失败", failed)
+		}
+	}
+
+	results := make([]build.VerifyResult, 0, len(commads))
+	differ := 0
+	for i, t := range targets {
+		target := t.Goos + "/" + t.Goarch
+		if t.Variant != "" {
+			target += "/" + t.Variant
+		}
+		r := build.CompareBuilds(target, outputs[i][0], outputs[i][1])
+		if !r.Same() {
+			differ++
+		}
+		results = append(results, r)
+	}
+	build.PrintVerify(os.Stdout, results)
+	if differ > 0 {
+		return fmt.Errorf("%d/%d 个目标两次编译结果不一致", differ, len(results))
+	}
+	util.Log.Success("两次编译结果一致")
+	return nil
+}
+
+// writeSBOM 在产物旁生成 SBOM 与编译来源信息，info 为 nil（无法读取模块信息）时只生成编译来源信息
+func writeSBOM(r *build.Result, info *buildinfo.BuildInfo, t build.OSData, buildArgs, env []string, goVersion string) {
+	now := time.Now()
+	var file string
+	if info != nil {
+		var err error
+		if file, err = build.WriteSBOM(r.Output, info, buildSBOM, now); err != nil {
+			util.Log.Warn(err)
+		}
+	}
+	provenance, err := build.WriteProvenance(r.Output, info, &build.Provenance{
+		Artifact:  r.Output,
+		Target:    build.ProvenanceTarget{OS: t.Goos, Arch: t.Goarch, Variant: t.Variant},
+		Commit:    build.GetBuildGitID(),
+		GoVersion: goVersion,
+		Flags:     buildArgs,
+		Env:       build.CacheEnv(env),
+		BuiltAt:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		util.Log.Warn(err)
+		return
+	}
+	if file == "" {
+		util.Log.Successf("provenance: %s\n", provenance)
+		return
+	}
+	util.Log.Successf("sbom: %s, %s\n", file, provenance)
+}
+
+// reportAssets 输出静态资源统计，总大小超过阈值时提示
+func reportAssets(stats []build.AssetStat) {
+	if embedReport && len(stats) > 0 {
+		build.PrintAssetsReport(os.Stdout, stats)
+	}
+	if embedWarn == "" || embedWarn == "0" {
+		return
+	}
+	limit, err := humanize.ParseBytes(embedWarn)
+	if err != nil {
+		util.Log.Warnf("资源大小阈值格式错误: %s\n", embedWarn)
+		return
+	}
+	if _, compressed := build.AssetsTotal(stats); uint64(compressed) > limit {
+		util.Log.Warnf("嵌入的静态资源共 %s，超过阈值 %s\n", zfile.SizeFormat(compressed), embedWarn)
+	}
+}
+
+func migrateEmbed(files []string, rootDir string) {
+	results, err := build.MigrateToEmbed(files, rootDir, true)
+	if err != nil {
+		util.Log.Fatal(err)
+	}
+	total := 0
+	for _, r := range results {
+		if r.Rewrites > 0 {
+			total += r.Rewrites
+			util.Log.Successf("%s: 改写 %d 处调用，//go:embed %s -> %s\n", r.File, r.Rewrites, strings.Join(r.Patterns, " "), r.Var)
+		}
+		for _, m := range r.Manual {
+			util.Log.Warnf("需要手动迁移 %s\n", m)
+		}
+	}
+	if total == 0 {
+		util.Log.Info("没有可以自动迁移的 zstatic 调用")
+	}
+}
+
+// reportEmbeds 输出各包通过 //go:embed 嵌入的文件
+func reportEmbeds(skipDirs []string) {
+	goFiles, err := zbuild.FindGoFiles(zfile.RealPath("."), skipDirs)
+	if err != nil {
+		util.Log.Warn(err)
+		return
+	}
+	pkgs, err := build.GetEmbeddedFiles(goFiles)
+	if err != nil {
+		util.Log.Warn(err)
+		return
+	}
+	rootDir := filepath.ToSlash(zfile.RealPath(".", true))
+	for _, pkg := range pkgs {
+		dir := strings.TrimSuffix(strings.TrimPrefix(pkg.Dir+"/", rootDir), "/")
+		util.Log.Infof("go:embed %s (%s): %d 个文件\n", zutil.IfVal(dir == "", ".", dir), pkg.PackageName, len(pkg.Files))
+		for _, f := range pkg.Files {
+			util.Log.Printf("  %s\n", f)
+		}
+	}
+}
+
+// applyBuildProfile 将编译配置写入未在命令行显式指定的参数
+func applyBuildProfile(cmd *cobra.Command, p *build.Profile) {
+	flags := cmd.Flags()
+	set := func(name string, fn func()) {
+		if !flags.Changed(name) {
+			fn()
+		}
+	}
+	set("os", func() { cross = p.OS })
+	set("out", func() { outDir = p.Out })
+	set("skip-dirs", func() { skipDirs = p.SkipDirs })
+	set("ldflags", func() { Ldflags = p.Ldflags })
+	set("upx", func() { upx = p.Upx })
+	set("garble", func() { obfuscate = p.Garble })
+	set("cgo", func() { isCGO = p.CGO })
+	set("pack", func() { isPack = p.Pack })
+	set("trimpath", func() { buildTrimpath = p.Trimpath })
+	set("no-static", func() { NoStatic = p.NoStatic })
+	set("hide-win-console", func() { hideWinConsole = p.HideWinConsole })
+	set("tags", func() { buildTags = strings.Join(p.Tags, ",") })
+	set("jobs", func() { buildJobs = p.Jobs })
+	set("dist", func() { buildDist = p.Dist })
+	set("dist-files", func() { distFiles = strings.Join(p.DistFiles, ",") })
+	set("dist-version", func() { distVersion = p.Version })
+	set("minify", func() { embedMinify = p.Minify })
+	set("sbom", func() { buildSBOM = p.SBOM })
+	set("reproducible", func() { buildReproducible = p.Reproducible })
+}
+
+func localCommad(v string, buildArgs []string, env []string, goos string, dir string, stdout, stderr io.Writer) (string, error) {
 	v = strings.Trim(v, " ")
 	osEnv := os.Environ()
 	envs := strings.Split(v, " ")
@@ -224,13 +628,7 @@ func localCommad(v string, buildArgs []string, env []string, goos string) string
 		osEnv = append(osEnv, vv)
 	}
 
-	oldEnv := zshell.Env
-	defer func() {
-		zshell.Env = oldEnv
-	}()
-
-	zshell.Env = append(zshell.Env, osEnv...)
-	zshell.Env = append(zshell.Env, env...)
+	cmdEnv := append(osEnv, env...)
 	cmd := strings.Split(v, " ")
 	if goos != "windows" {
 		for _, v := range buildArgs {
@@ -249,17 +647,23 @@ func localCommad(v string, buildArgs []string, env []string, goos string) string
 	if buildDebug {
 		util.Log.Println(strings.Join(env, " "))
 		util.Log.Println(strings.Join(cmd, " "))
-		return ""
+		return "", nil
 	}
 
-	e := zshell.Env
-	_, _, _, err := zshell.ExecCommand(context.Background(), cmds, nil, os.Stdout, os.Stderr)
-	zshell.Env = e
+	// 并发编译时不能修改全局的 zshell.Env，通过选项传入环境变量
+	_, _, _, err := zshell.ExecCommand(context.Background(), cmds, nil, stdout, stderr, func(o *zshell.Options) {
+		o.Env = cmdEnv
+		o.Dir = dir
+	})
 	if err != nil {
-		util.Log.Fatalf("%v\n", err)
-		return ""
+		return "", err
 	}
 
+	return commandOutput(cmds), nil
+}
+
+// commandOutput 从编译命令中解析输出文件
+func commandOutput(cmds []string) string {
 	name, err := zstring.RegexExtractAll(`-o(=| )([\w\\\/\-\_\.]*) `, strings.Join(cmds, " ")+" ")
 	if err == nil && len(name) > 0 {
 		return name[len(name)-1][2]
@@ -274,12 +678,17 @@ func init() {
 	buildCmd.Flags().
 		BoolVarP(&isPack, "pack", "P", false, "与编译一致，但会附加 '-w -s' 压缩参数")
 	buildCmd.Flags().
-		StringVarP(&cross, "os", "O", "", "交叉编译到指定系统，多个值用英文逗号分隔")
+		StringVarP(&cross, "os", "O", "", "交叉编译到指定系统，多个值用英文逗号分隔，如 linux/amd64/v3,linux/armv7,freebsd,wasip1,linux/*")
 	buildCmd.Flags().StringVarP(&outDir, "out", "", "", "输出目录")
 	buildCmd.Flags().BoolVarP(&isCGO, "cgo", "C", false, "开启 CGO_ENABLED，需要预装 zig")
 	buildCmd.Flags().BoolVarP(&buildIgnore, "ignoreE", "I", false, "忽略不存在的文件")
 	buildCmd.Flags().BoolVar(&buildDebug, "debug", false, "打印执行命令，不实际编译")
 	buildCmd.Flags().BoolVar(&buildEmbed, "embed", false, "仅编译静态资源文件")
+	buildCmd.Flags().BoolVar(&embedReport, "report", false, "输出静态资源打包统计")
+	buildCmd.Flags().BoolVar(&embedMinify, "minify", false, "打包前压缩 HTML/CSS/JS 静态资源")
+	buildCmd.Flags().StringVar(&embedIgnore, "embed-ignore", build.DefaultAssetsIgnoreFile, "静态资源忽略规则文件")
+	buildCmd.Flags().StringVar(&embedWarn, "embed-warn", "20MB", "静态资源打包后超过该大小时提示，0 为不提示")
+	buildCmd.Flags().BoolVar(&embedMigrate, "migrate-embed", false, "将 zstatic 资源调用改写为 //go:embed 与 embed.FS")
 	buildCmd.Flags().
 		BoolVarP(&buildTrimpath, "trimpath", "T", false, "移除编译产物中的文件系统路径")
 	buildCmd.Flags().StringVar(&skipDirs, "skip-dirs", "", "静态资源分析时跳过的目录")
@@ -289,4 +698,17 @@ func init() {
 	buildCmd.Flags().StringVar(&upx, "upx", "", "使用 UPX 压缩可执行文件，需要安装 upx")
 	buildCmd.Flags().BoolVar(&NoStatic, "no-static", false, "不进行静态链接")
 	buildCmd.Flags().StringVar(&Ldflags, "ldflags", "", "自定义 ldflags 参数")
+	buildCmd.Flags().StringVar(&buildTags, "tags", "", "编译标签，多个值用英文逗号分隔")
+	buildCmd.Flags().IntVarP(&buildJobs, "jobs", "j", 0, "并发编译的目标数，默认为 CPU 核数")
+	buildCmd.Flags().BoolVar(&buildDist, "dist", false, "打包编译产物为压缩包，并生成校验文件与清单")
+	buildCmd.Flags().StringVar(&distFiles, "dist-files", "", "打包时附加的文件，支持通配符，默认 LICENSE*,README*")
+	buildCmd.Flags().StringVar(&distVersion, "dist-version", "", "打包使用的版本号，默认由 git describe 推导")
+	buildCmd.Flags().StringVar(&buildSBOM, "sbom", "", "在产物旁生成 SBOM（cyclonedx 或 spdx）与编译来源信息")
+	buildCmd.Flags().StringArrayVar(&buildVars, "var", nil, "设置包变量，格式：包名.变量=值，值支持 {{.Version}} {{.Commit}} {{.Date}} {{.Env.NAME}} 等模板变量")
+	buildCmd.Flags().BoolVar(&buildReproducible, "reproducible", false, "可复现编译，使用 SOURCE_DATE_EPOCH 或最后一次提交的时间作为编译时间")
+	buildCmd.Flags().BoolVar(&buildVerify, "verify", false, "可复现编译后将源码复制到两个临时目录各编译一次，校验产物是否一致")
+	buildCmd.Flags().BoolVar(&buildForce, "force", false, "忽略编译缓存，重新编译所有目标")
+	buildCmd.Flags().StringVarP(&buildProfile, "profile", "p", "", "使用编译配置文件中的指定配置")
+	buildCmd.PersistentFlags().StringVar(&buildCfg, "cfg", build.DefaultProfileFile, "编译配置文件路径")
+	build.InitCmd(buildCmd)
 }
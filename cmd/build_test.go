@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sohaha/zzz/app/build"
+)
+
+func TestWriteSBOMWithoutBuildInfo(t *testing.T) {
+	old := buildSBOM
+	buildSBOM = build.SBOMCycloneDX
+	defer func() { buildSBOM = old }()
+
+	bin := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(bin, []byte("UPX!packed"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeSBOM(&build.Result{Output: bin}, nil, build.OSData{Goos: "linux", Goarch: "amd64"}, nil, nil, "go1.22")
+
+	if _, err := os.Stat(bin + ".provenance.json"); err != nil {
+		t.Fatalf("provenance should be written without build info: %v", err)
+	}
+	if _, err := os.Stat(bin + ".cdx.json"); !os.IsNotExist(err) {
+		t.Fatalf("sbom should be skipped without build info: %v", err)
+	}
+}
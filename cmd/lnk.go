@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/sohaha/zzz/app/lnk/core"
 	"github.com/sohaha/zzz/util"
@@ -31,6 +34,9 @@ var lnkCmd = &cobra.Command{
   # 递归添加目录中的所有文件
   zzz lnk add ~/.config --recursive
 
+  # 加密添加敏感文件
+  zzz lnk key init && zzz lnk add ~/.netrc --encrypt
+
   # 查看管理的文件列表
   zzz lnk list
 
@@ -92,6 +98,13 @@ func init() {
 	lnkCmd.AddCommand(newPullCmd())
 	lnkCmd.AddCommand(newBootstrapCmd())
 	lnkCmd.AddCommand(newCleanupCmd())
+	lnkCmd.AddCommand(newKeyCmd())
+	lnkCmd.AddCommand(newRenderCmd())
+	lnkCmd.AddCommand(newResolveCmd())
+	lnkCmd.AddCommand(newLogCmd())
+	lnkCmd.AddCommand(newRestoreCmd())
+	lnkCmd.AddCommand(newUndoCmd())
+	lnkCmd.AddCommand(newSyncCmd())
 }
 
 func newInitCmd() *cobra.Command {
@@ -146,7 +159,12 @@ func newAddCmd() *cobra.Command {
 		recursive bool
 		host      string
 		hard      bool
+		encrypt   bool
+		tmpl      bool
+		copyMode  bool
 		linkType  string
+		force     bool
+		maxSize   string
 	)
 
 	cmd := &cobra.Command{
@@ -166,21 +184,56 @@ func newAddCmd() *cobra.Command {
   # 使用硬链接添加
   zzz lnk add ~/.bashrc --hard
 
+  # 加密后添加，仓库中只保存密文
+  zzz lnk add ~/.netrc --encrypt
+
+  # 作为模板添加，按主机渲染后再链接
+  zzz lnk add ~/.gitconfig --template
+
+  # 以复制方式添加，适用于不支持链接的程序
+  zzz lnk add ~/.config/app/settings.json --copy
+
   # 为特定主机添加配置
-  zzz lnk add ~/.bashrc --host workstation`,
+  zzz lnk add ~/.bashrc --host workstation
+
+  # 递归添加目录，跳过 .lnkignore 中的文件与超过 512K 的文件
+  zzz lnk add -r ~/.config/Code/User --max-size 512K`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// 解析链接类型
 			var extra []core.Option
-			if hard {
+			modes := 0
+			for _, set := range []bool{hard, encrypt, tmpl, copyMode} {
+				if set {
+					modes++
+				}
+			}
+			if modes > 1 {
+				return fmt.Errorf("--hard、--encrypt、--template 与 --copy 只能指定一个")
+			}
+			if copyMode {
+				extra = append(extra, core.WithLinkType(core.LinkTypeCopy))
+			} else if encrypt {
+				extra = append(extra, core.WithLinkType(core.LinkTypeEncrypted))
+			} else if tmpl {
+				extra = append(extra, core.WithLinkType(core.LinkTypeTemplate))
+			} else if hard {
 				extra = append(extra, core.WithLinkType(core.LinkTypeHard))
 			} else if linkType != "" {
-				if linkType != core.LinkTypeSoft && linkType != core.LinkTypeHard {
-					return fmt.Errorf("无效的链接类型: %s，可选: soft|hard", linkType)
+				switch linkType {
+				case core.LinkTypeSoft, core.LinkTypeHard, core.LinkTypeEncrypted, core.LinkTypeTemplate, core.LinkTypeCopy:
+				default:
+					return fmt.Errorf("无效的链接类型: %s，可选: soft|hard|encrypted|template|copy", linkType)
 				}
 				extra = append(extra, core.WithLinkType(linkType))
 			}
 
+			size, err := parseSize(maxSize)
+			if err != nil {
+				return err
+			}
+			extra = append(extra, core.WithMaxFileSize(size), core.WithAllowSecrets(force))
+
 			lnk := createLnkInstanceWith(host, extra...)
 
 			if !lnk.IsInitialized() {
@@ -192,11 +245,15 @@ func newAddCmd() *cobra.Command {
 					util.Log.Printf("正在处理 (%d/%d): %s\n", current, total, currentFile)
 				}
 
-				if err := lnk.AddRecursiveWithProgress(args, progressCallback); err != nil {
+				report, err := lnk.AddRecursiveWithReport(args, progressCallback)
+				if report != nil {
+					printSkippedFiles(report.Skipped)
+				}
+				if err != nil {
 					return err
 				}
 
-				util.Log.Successf("成功递归添加文件到 lnk 管理\n")
+				util.Log.Successf("成功递归添加 %d 个文件到 lnk 管理\n", len(report.Added))
 			} else if len(args) > 1 {
 				if err := lnk.AddMultiple(args); err != nil {
 					return err
@@ -218,11 +275,59 @@ func newAddCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "递归添加目录中的所有文件")
 	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
 	cmd.Flags().BoolVar(&hard, "hard", false, "使用硬链接添加文件")
-	cmd.Flags().StringVar(&linkType, "link-type", "", "指定链接类型: soft|hard（优先级低于 --hard）")
+	cmd.Flags().BoolVar(&encrypt, "encrypt", false, "加密文件后再添加，需要先运行 'zzz lnk key init'")
+	cmd.Flags().BoolVar(&tmpl, "template", false, "作为 Go 模板添加，变量见 'zzz lnk render --help'")
+	cmd.Flags().BoolVar(&copyMode, "copy", false, "以复制方式添加，status/push/pull 时按内容双向同步")
+	cmd.Flags().StringVar(&linkType, "link-type", "", "指定链接类型: soft|hard|encrypted|template|copy（优先级低于 --hard）")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "递归添加时即使检测到私钥或令牌也继续添加")
+	cmd.Flags().StringVar(&maxSize, "max-size", "1M", "递归添加时单个文件的大小上限，如 512K、2M，0 表示不限制")
 
 	return cmd
 }
 
+// parseSize 解析带 K/M/G 单位的大小
+func parseSize(size string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+	if s == "" {
+		return 0, nil
+	}
+	unit := int64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		unit = 1 << 10
+	case 'M':
+		unit = 1 << 20
+	case 'G':
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小: %s", size)
+	}
+	return n * unit, nil
+}
+
+func printSkippedFiles(skipped []core.SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+	reasons := map[string]string{
+		core.SkipIgnored:  "被 " + core.IgnoreFilename + " 忽略",
+		core.SkipManaged:  "已被管理",
+		core.SkipInvalid:  "无法读取",
+		core.SkipSpecial:  "套接字、管道或设备文件",
+		core.SkipTooLarge: "超过大小上限",
+		core.SkipBinary:   "二进制文件",
+	}
+	util.Log.Warnf("跳过 %d 个文件:\n", len(skipped))
+	for _, f := range skipped {
+		util.Log.Printf("  %s (%s)\n", f.Path, reasons[f.Reason])
+	}
+}
+
 func newRmCmd() *cobra.Command {
 	var (
 		host  string
@@ -311,8 +416,9 @@ func newRmCmd() *cobra.Command {
 
 func newListCmd() *cobra.Command {
 	var (
-		all  bool
-		host string
+		all    bool
+		host   string
+		output string
 	)
 
 	cmd := &cobra.Command{
@@ -327,9 +433,23 @@ func newListCmd() *cobra.Command {
   zzz lnk list --all
 
   # 显示特定主机的配置文件
-  zzz lnk list --host workstation`,
+  zzz lnk list --host workstation
+
+  # 显示组合主机的配置文件及来源层，组合定义在仓库根目录的 ` + core.ProfilesFilename + `，
+  # 后面的层覆盖前面的同名文件，general 表示通用配置:
+  #   laptop: [general, linux, work]
+  zzz lnk list --host laptop
+
+  # 以 JSON 输出各文件的仓库路径、链接类型、所属主机与健康状态
+  zzz lnk list -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			lnk := createLnkInstance(host)
+			lnk := createOutputLnkInstance(host, output)
+
+			if output != "" {
+				return writeOutput(cmd, output, func() (interface{}, error) {
+					return listOutput(lnk, all)
+				})
+			}
 
 			if !lnk.IsInitialized() {
 				return fmt.Errorf("lnk 仓库未初始化，请先运行 'zzz lnk init'")
@@ -377,10 +497,22 @@ func newListCmd() *cobra.Command {
 					}
 				}
 			} else {
-				files, err := lnk.List()
+				entries, err := lnk.LayeredEntries()
 				if err != nil {
 					return fmt.Errorf("获取文件列表失败: %w", err)
 				}
+				layers, err := lnk.ProfileLayers()
+				if err != nil {
+					return err
+				}
+				files := make([]string, 0, len(entries))
+				for _, e := range entries {
+					if len(layers) > 1 {
+						files = append(files, fmt.Sprintf("%s  [%s]", e.Path, e.Layer))
+					} else {
+						files = append(files, e.Path)
+					}
+				}
 
 				if len(files) == 0 {
 					if host != "" {
@@ -391,7 +523,9 @@ func newListCmd() *cobra.Command {
 					return nil
 				}
 
-				if host != "" {
+				if len(layers) > 1 {
+					util.Log.Printf("主机 %s 由 %s 组合 (共 %d 个文件):\n", lnk.GetHost(), strings.Join(layers, " + "), len(files))
+				} else if host != "" {
 					util.Log.Printf("主机 %s 管理的文件列表 (共 %d 个文件):\n", host, len(files))
 				} else {
 					util.Log.Printf("当前主机管理的文件列表 (共 %d 个文件):\n", len(files))
@@ -408,20 +542,63 @@ func newListCmd() *cobra.Command {
 
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "显示所有主机的配置文件")
 	cmd.Flags().StringVarP(&host, "host", "H", "", "显示特定主机的配置文件")
+	addOutputFlag(cmd, &output)
 
 	return cmd
 }
 
+type listResult struct {
+	Host    string             `json:"host,omitempty" yaml:"host,omitempty"`
+	Layers  []string           `json:"layers,omitempty" yaml:"layers,omitempty"`
+	Entries []core.EntryDetail `json:"entries" yaml:"entries"`
+}
+
+func listOutput(lnk *core.Lnk, all bool) (*listResult, error) {
+	if all {
+		entries, err := lnk.AllEntryDetails()
+		if err != nil {
+			return nil, err
+		}
+		return &listResult{Entries: entries}, nil
+	}
+	entries, err := lnk.EntryDetails()
+	if err != nil {
+		return nil, err
+	}
+	layers, err := lnk.ProfileLayers()
+	if err != nil {
+		return nil, err
+	}
+	return &listResult{Host: lnk.GetHost(), Layers: layers, Entries: entries}, nil
+}
+
 func newStatusCmd() *cobra.Command {
+	var (
+		host   string
+		output string
+	)
+
 	cmd := &cobra.Command{
 		Use:          "status",
 		Short:        "显示仓库状态",
 		Long:         `显示 lnk 仓库的当前状态`,
 		SilenceUsage: true,
 		Example: `  # 显示仓库状态
-  zzz lnk status`,
+  zzz lnk status
+
+  # 显示组合主机的状态及各文件的来源层
+  zzz lnk status --host laptop
+
+  # 以 JSON 输出，便于脚本处理
+  zzz lnk status -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			lnk := createLnkInstance("")
+			lnk := createOutputLnkInstance(host, output)
+
+			if output != "" {
+				return writeOutput(cmd, output, func() (interface{}, error) {
+					return lnk.Status()
+				})
+			}
 
 			if !lnk.IsInitialized() {
 				return fmt.Errorf("lnk 仓库未初始化，请先运行 'zzz lnk init'")
@@ -435,6 +612,9 @@ func newStatusCmd() *cobra.Command {
 			util.Log.Println("lnk 仓库状态:")
 			util.Log.Printf("仓库路径: %s\n", status.RepoPath)
 			util.Log.Printf("当前主机: %s\n", status.Host)
+			if len(status.Layers) > 1 {
+				util.Log.Printf("组合层: %s\n", strings.Join(status.Layers, " + "))
+			}
 
 			if status.GitStatus != nil {
 				if status.GitStatus.Remote != "" {
@@ -463,6 +643,27 @@ func newStatusCmd() *cobra.Command {
 			}
 
 			util.Log.Printf("\n管理文件统计: 共 %d 个文件\n", status.ManagedFiles)
+			if len(status.Layers) > 1 {
+				for _, e := range status.Entries {
+					util.Log.Printf("  %s  [%s]\n", e.Path, e.Layer)
+				}
+			}
+
+			for _, c := range status.CopyChanges {
+				switch c.Action {
+				case core.CopyToRepo:
+					util.Log.Printf("已同步到仓库: %s\n", c.Path)
+				case core.CopyToTarget:
+					util.Log.Printf("已从仓库更新: %s\n", c.Path)
+				}
+			}
+			if len(status.CopyConflicts) > 0 {
+				util.Log.Warnf("\n复制文件冲突 (%d 个)，本地与仓库都被修改:\n", len(status.CopyConflicts))
+				for _, f := range status.CopyConflicts {
+					util.Log.Warnf("  - %s\n", f)
+				}
+				util.Log.Println("使用 'zzz lnk resolve <file> --keep local|repo' 选择保留的版本")
+			}
 
 			if len(status.BrokenLinks) > 0 {
 				util.Log.Warnf("\n损坏的符号链接 (%d 个):\n", len(status.BrokenLinks))
@@ -477,6 +678,9 @@ func newStatusCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	addOutputFlag(cmd, &output)
+
 	return cmd
 }
 
@@ -533,18 +737,24 @@ func newPushCmd() *cobra.Command {
 }
 
 func newPullCmd() *cobra.Command {
-	var host string
+	var (
+		host   string
+		prefer string
+	)
 
 	cmd := &cobra.Command{
 		Use:          "pull [flags]",
 		Short:        "从远程仓库拉取变更",
-		Long:         `从远程仓库拉取最新变更`,
+		Long:         `从远程仓库拉取最新变更，合并冲突时在终端中逐个文件选择保留的版本或编辑合并结果，跟踪文件自动合并`,
 		SilenceUsage: true,
 		Example: `  # 拉取变更
   zzz lnk pull
 
   # 拉取特定主机的变更
-  zzz lnk pull --host workstation`,
+  zzz lnk pull --host workstation
+
+  # 冲突时总是保留远程版本（适用于脚本）
+  zzz lnk pull --prefer remote`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			lnk := createLnkInstance(host)
 
@@ -552,9 +762,24 @@ func newPullCmd() *cobra.Command {
 				return fmt.Errorf("lnk 仓库未初始化，请先运行 'zzz lnk init'")
 			}
 
-			if err := lnk.Pull(); err != nil {
+			var resolve core.ConflictResolver
+			if prefer != "" {
+				r, err := core.PreferResolver(prefer)
+				if err != nil {
+					return err
+				}
+				resolve = r
+			} else if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+				resolve = interactiveResolver(lnk)
+			}
+
+			resolved, err := lnk.PullWithResolver(resolve)
+			if err != nil {
 				return fmt.Errorf("拉取失败: %w", err)
 			}
+			if len(resolved) > 0 {
+				util.Log.Successf("已解决 %d 个冲突: %s\n", len(resolved), strings.Join(resolved, ", "))
+			}
 
 			if host != "" {
 				if err := lnk.RestoreSymlinksForHost(host); err != nil {
@@ -570,40 +795,77 @@ func newPullCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	cmd.Flags().StringVar(&prefer, "prefer", "", "冲突时保留的版本: local|remote，不指定时在终端中交互选择")
 
 	return cmd
 }
 
 func newBootstrapCmd() *cobra.Command {
+	var (
+		host   string
+		dryRun bool
+		yes    bool
+		force  bool
+	)
+
 	cmd := &cobra.Command{
-		Use:          "bootstrap",
-		Short:        "运行引导脚本",
-		Long:         `手动运行 bootstrap.sh 引导脚本`,
+		Use:   "bootstrap",
+		Short: "运行引导清单或引导脚本",
+		Long: `执行仓库根目录的 ` + core.BootstrapManifest + ` 引导清单，不存在时运行 bootstrap.sh 引导脚本
+
+引导清单中的每个步骤可以包含:
+  apt/brew/pacman/go/npm  需要安装的软件包，只安装缺失的包，不可用的包管理器会被跳过
+  run                     执行的命令，没有 check 时只在首次或步骤变更后执行
+  check                   检查命令，执行成功时跳过该步骤
+  os/hosts                执行条件，hosts 匹配当前主机、组合的各层或系统主机名
+
+已执行的步骤记录在仓库的 ` + core.StateDir + ` 目录中（不会提交）`,
 		SilenceUsage: true,
-		Example: `  # 运行引导脚本
+		Example: `  # 引导清单示例
+  steps:
+    - name: 基础工具
+      apt: [git, curl, zsh]
+      brew: [git, zsh]
+    - name: gopls
+      go: [golang.org/x/tools/gopls@latest]
+      hosts: [work]
+    - name: oh-my-zsh
+      os: [linux, darwin]
+      check: test -d ~/.oh-my-zsh
+      run: sh -c "$(curl -fsSL https://raw.githubusercontent.com/ohmyzsh/ohmyzsh/master/tools/install.sh)" "" --unattended
+
+  # 查看执行计划
+  zzz lnk bootstrap --dry-run
+
+  # 运行引导
   zzz lnk bootstrap`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			lnk := createLnkInstance("")
+			lnk := createLnkInstance(host)
 
 			if !lnk.IsInitialized() {
 				return fmt.Errorf("lnk 仓库未初始化，请先运行 'zzz lnk init'")
 			}
 
+			if _, err := lnk.FindBootstrapManifest(); err == nil {
+				return runBootstrapManifest(lnk, dryRun, yes, force)
+			}
+
 			scriptPath, err := lnk.FindBootstrapScript()
 			if err != nil {
 				return fmt.Errorf("未找到 bootstrap 脚本: %w", err)
 			}
 
 			util.Log.Printf("找到 bootstrap 脚本: %s\n", scriptPath)
-			util.Log.Warn("即将执行 bootstrap 脚本，此操作可能会修改系统配置或安装软件包")
-			util.Log.Warn("是否继续执行? (y/N)")
-
-			var response string
-			fmt.Scanln(&response)
-			if response != "y" && response != "Y" && response != "yes" && response != "Yes" {
-				util.Log.Println("操作已取消")
+			if dryRun {
 				return nil
 			}
+			if !yes {
+				util.Log.Warn("即将执行 bootstrap 脚本，此操作可能会修改系统配置或安装软件包")
+				if !confirm() {
+					util.Log.Println("操作已取消")
+					return nil
+				}
+			}
 
 			util.Log.Println("正在执行 bootstrap 脚本...")
 			if err := lnk.RunBootstrapScript(); err != nil {
@@ -615,9 +877,67 @@ func newBootstrapCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示执行计划，不执行")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "忽略执行记录，重新执行没有 check 的步骤")
+
 	return cmd
 }
 
+func runBootstrapManifest(lnk *core.Lnk, dryRun, yes, force bool) error {
+	plan, err := lnk.PlanBootstrap(force)
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	util.Log.Println("执行计划:")
+	for _, act := range plan {
+		if act.Action != core.BootstrapRun {
+			line := fmt.Sprintf("  - %s: 跳过（%s）", act.Step, act.Reason)
+			if !act.LastRun.IsZero() {
+				line += fmt.Sprintf("，上次执行于 %s", act.LastRun.Format("2006-01-02 15:04:05"))
+			}
+			util.Log.Println(line)
+			continue
+		}
+		pending++
+		util.Log.Printf("  + %s\n", act.Step)
+		for _, c := range act.Commands {
+			util.Log.Printf("      $ %s\n", c)
+		}
+	}
+
+	if pending == 0 {
+		util.Log.Successf("所有步骤均已完成\n")
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+	if !yes {
+		util.Log.Warnf("即将执行 %d 个步骤，此操作可能会修改系统配置或安装软件包\n", pending)
+		if !confirm() {
+			util.Log.Println("操作已取消")
+			return nil
+		}
+	}
+
+	if err := lnk.RunBootstrap(plan); err != nil {
+		return err
+	}
+	util.Log.Successf("引导完成，执行了 %d 个步骤\n", pending)
+	return nil
+}
+
+func confirm() bool {
+	util.Log.Warn("是否继续执行? (y/N)")
+	var response string
+	fmt.Scanln(&response)
+	return response == "y" || response == "Y" || response == "yes" || response == "Yes"
+}
+
 func newCleanupCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "cleanup",
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sohaha/zzz/util"
+	"github.com/spf13/cobra"
+)
+
+func newResolveCmd() *cobra.Command {
+	var (
+		host string
+		keep string
+	)
+	cmd := &cobra.Command{
+		Use:          "resolve <file>...",
+		Short:        "解决复制文件的冲突",
+		Long:         "--copy 添加的文件在本地与仓库中都被修改时，选择保留本地（local）或仓库（repo）版本",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(1),
+		Example: `  # 保留本地修改，下次 push 时提交
+  zzz lnk resolve ~/.config/app/settings.json --keep local
+
+  # 使用仓库中的版本覆盖本地文件
+  zzz lnk resolve ~/.config/app/settings.json --keep repo`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keep != "local" && keep != "repo" {
+				return fmt.Errorf("请使用 --keep local|repo 指定保留的版本")
+			}
+			lnk := createLnkInstance(host)
+			for _, f := range args {
+				if err := lnk.ResolveCopyConflict(f, keep); err != nil {
+					return err
+				}
+				util.Log.Successf("已解决冲突: %s\n", f)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keep, "keep", "", "保留的版本: local|repo")
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
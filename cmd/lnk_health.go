@@ -11,31 +11,74 @@ import (
 )
 
 func newDiffCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		host   string
+		output string
+	)
+	cmd := &cobra.Command{
 		Use:          "diff",
 		Short:        "查看仓库未提交差异",
-		Long:         "显示 lnk 仓库当前未提交的差异内容（等同在仓库目录执行 git diff）",
+		Long:         "显示 lnk 仓库当前未提交的差异内容（等同在仓库目录执行 git diff），以及模板渲染结果与已部署文件的差异",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			lnk := createLnkInstance("")
-			output, err := lnk.Diff(isTerminal())
+			lnk := createOutputLnkInstance(host, output)
+			if output != "" {
+				return writeOutput(cmd, output, func() (interface{}, error) {
+					return diffOutput(lnk)
+				})
+			}
+			diff, err := lnk.Diff(isTerminal())
+			if err != nil {
+				return err
+			}
+			rendered, err := lnk.TemplateDiff(isTerminal())
 			if err != nil {
 				return err
 			}
-			if strings.TrimSpace(output) == "" {
+			if strings.TrimSpace(diff) == "" && strings.TrimSpace(rendered) == "" {
 				util.Log.Successf("当前无未提交变更\n")
 				return nil
 			}
-			cmd.Print(output)
+			cmd.Print(diff)
+			if strings.TrimSpace(rendered) != "" {
+				util.Log.Warn("以下模板的渲染结果与已部署文件不同，运行 'zzz lnk render' 更新:")
+				cmd.Print(rendered)
+			}
 			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+type diffResult struct {
+	Clean        bool   `json:"clean" yaml:"clean"`
+	Diff         string `json:"diff" yaml:"diff"`
+	TemplateDiff string `json:"template_diff" yaml:"template_diff"`
+}
+
+func diffOutput(lnk *core.Lnk) (*diffResult, error) {
+	diff, err := lnk.Diff(false)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := lnk.TemplateDiff(false)
+	if err != nil {
+		return nil, err
+	}
+	return &diffResult{
+		Clean:        strings.TrimSpace(diff) == "" && strings.TrimSpace(rendered) == "",
+		Diff:         diff,
+		TemplateDiff: rendered,
+	}, nil
 }
 
 func newDoctorCmd() *cobra.Command {
 	var (
 		host   string
 		dryRun bool
+		output string
 	)
 
 	cmd := &cobra.Command{
@@ -44,7 +87,13 @@ func newDoctorCmd() *cobra.Command {
 		Long:         "检查并修复无效跟踪条目和损坏符号链接，支持 --dry-run 预览",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			lnk := createLnkInstance(host)
+			// 修复会修改仓库，先校验输出格式
+			if output != "" {
+				if err := checkOutputFormat(output); err != nil {
+					return err
+				}
+			}
+			lnk := createOutputLnkInstance(host, output)
 			var (
 				result *core.DoctorResult
 				err    error
@@ -55,6 +104,24 @@ func newDoctorCmd() *cobra.Command {
 			} else {
 				result, err = lnk.Doctor()
 			}
+			if output != "" {
+				return writeOutput(cmd, output, func() (interface{}, error) {
+					if err != nil {
+						return nil, err
+					}
+					entries, err := lnk.EntryDetails()
+					if err != nil {
+						return nil, err
+					}
+					return &doctorResult{
+						DryRun:       dryRun,
+						Healthy:      !result.HasIssues(),
+						TotalIssues:  result.TotalIssues(),
+						DoctorResult: *result,
+						Entries:      entries,
+					}, nil
+				})
+			}
 			if err != nil {
 				return err
 			}
@@ -66,9 +133,19 @@ func newDoctorCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
 	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "仅预览将修复的问题，不执行修改")
+	addOutputFlag(cmd, &output)
 	return cmd
 }
 
+// doctorResult 结构化输出，Entries 为检查（或修复）后各条目的状态
+type doctorResult struct {
+	DryRun            bool `json:"dry_run" yaml:"dry_run"`
+	Healthy           bool `json:"healthy" yaml:"healthy"`
+	TotalIssues       int  `json:"total_issues" yaml:"total_issues"`
+	core.DoctorResult `yaml:",inline"`
+	Entries           []core.EntryDetail `json:"entries" yaml:"entries"`
+}
+
 func printDoctorResult(result *core.DoctorResult, host string, dryRun bool) {
 	hostText := ""
 	if host != "" {
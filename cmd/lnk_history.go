@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sohaha/zzz/util"
+	"github.com/spf13/cobra"
+)
+
+func newLogCmd() *cobra.Command {
+	var (
+		host  string
+		limit int
+	)
+	cmd := &cobra.Command{
+		Use:          "log <file>",
+		Short:        "查看被管理文件的提交记录",
+		Long:         "根据部署位置找到文件在仓库中的路径，显示涉及该文件的提交",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `  # 查看文件的修改记录
+  zzz lnk log ~/.bashrc
+
+  # 只显示最近 5 条
+  zzz lnk log ~/.bashrc -n 5`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance(host)
+			commits, relPath, err := lnk.FileLog(args[0], limit)
+			if err != nil {
+				return err
+			}
+			util.Log.Printf("仓库文件: %s\n", relPath)
+			if len(commits) == 0 {
+				util.Log.Println("没有提交记录")
+				return nil
+			}
+			for _, c := range commits {
+				util.Log.Printf("%s  %s  %s  %s\n", c.ShortHash, c.Date.Format("2006-01-02 15:04"), c.Author, c.Subject)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "number", "n", 20, "显示的提交数量，0 表示全部")
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	var (
+		host string
+		rev  string
+	)
+	cmd := &cobra.Command{
+		Use:          "restore <file>",
+		Short:        "将被管理文件恢复到指定版本",
+		Long:         "从仓库历史中取出文件在指定版本的内容并提交，其他文件不受影响",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `  # 查看可用的版本
+  zzz lnk log ~/.bashrc
+
+  # 恢复到指定提交
+  zzz lnk restore ~/.bashrc --rev a1b2c3d
+
+  # 恢复到上一个版本
+  zzz lnk restore ~/.bashrc --rev HEAD~1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rev == "" {
+				return fmt.Errorf("请使用 --rev 指定版本")
+			}
+			lnk := createLnkInstance(host)
+			if err := lnk.RestoreFile(args[0], rev); err != nil {
+				return err
+			}
+			util.Log.Successf("已将 %s 恢复到 %s\n", args[0], rev)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&rev, "rev", "r", "", "版本（提交哈希、标签或 HEAD~n）")
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
+
+func newUndoCmd() *cobra.Command {
+	var host string
+	cmd := &cobra.Command{
+		Use:          "undo",
+		Short:        "撤销最近一次 lnk 操作",
+		Long:         "以反向提交撤销本机最近一次 add/rm/push/restore 操作，包括跟踪文件的变更；撤销添加时文件恢复为普通文件，撤销移除时重新链接",
+		SilenceUsage: true,
+		Example: `  # 撤销最近一次操作
+  zzz lnk undo`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance(host)
+			result, err := lnk.Undo()
+			if result != nil {
+				util.Log.Printf("已撤销 %s: %s\n", result.Op, result.Title)
+				if result.Pushed {
+					util.Log.Println("撤销已推送到远程仓库")
+				}
+			}
+			if err != nil {
+				return err
+			}
+			util.Log.Successf("撤销完成\n")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
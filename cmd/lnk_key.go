@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/sohaha/zzz/app/lnk/secret"
+	"github.com/sohaha/zzz/util"
+	"github.com/spf13/cobra"
+)
+
+func newKeyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "key",
+		Short:        "管理加密文件使用的密钥",
+		Long:         "管理 --encrypt 添加的文件所使用的密钥，密钥默认保存在 ~/.config/lnk.key，可通过环境变量 " + secret.KeyFileEnv + " 指定",
+		SilenceUsage: true,
+		Example: `  # 生成随机密钥
+  zzz lnk key init
+
+  # 使用口令生成密钥，其他机器输入相同口令即可解密
+  zzz lnk key init --passphrase
+
+  # 导出密钥并在其他机器导入
+  zzz lnk key export
+  zzz lnk key import <key>
+
+  # 轮换密钥并重新加密所有文件
+  zzz lnk key rotate`,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newKeyInitCmd())
+	cmd.AddCommand(newKeyImportCmd())
+	cmd.AddCommand(newKeyExportCmd())
+	cmd.AddCommand(newKeyRotateCmd())
+	cmd.AddCommand(newKeyStatusCmd())
+	return cmd
+}
+
+func newKeyInitCmd() *cobra.Command {
+	var (
+		passphrase bool
+		force      bool
+	)
+	cmd := &cobra.Command{
+		Use:          "init",
+		Short:        "生成加密密钥",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance("")
+			var phrase string
+			if passphrase {
+				var err error
+				if phrase, err = readPassphrase(true); err != nil {
+					return err
+				}
+			}
+			info, err := lnk.InitKey(phrase, force)
+			if err != nil {
+				return err
+			}
+			util.Log.Successf("已生成密钥 %s: %s\n", info.KeyID, info.KeyFile)
+			if !passphrase {
+				util.Log.Warn("请妥善备份密钥（zzz lnk key export），丢失后将无法解密仓库中的文件")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&passphrase, "passphrase", "p", false, "从口令派生密钥（从标准输入读取）")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "覆盖已存在的密钥")
+	return cmd
+}
+
+func newKeyImportCmd() *cobra.Command {
+	var passphrase bool
+	cmd := &cobra.Command{
+		Use:          "import [key]",
+		Short:        "导入密钥",
+		Long:         "导入 'zzz lnk key export' 输出的密钥，或使用 --passphrase 输入口令；未指定参数时从标准输入读取",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance("")
+			var value, phrase string
+			var err error
+			switch {
+			case passphrase:
+				phrase, err = readPassphrase(false)
+			case len(args) == 1:
+				value = args[0]
+			default:
+				value, err = readLine("请输入密钥: ")
+			}
+			if err != nil {
+				return err
+			}
+			info, err := lnk.ImportKey(value, phrase)
+			if err != nil {
+				return err
+			}
+			util.Log.Successf("已导入密钥 %s: %s\n", info.KeyID, info.KeyFile)
+			if len(info.Mismatched) > 0 {
+				util.Log.Warnf("%d 个加密文件不是使用该密钥加密的:\n  %s\n", len(info.Mismatched), strings.Join(info.Mismatched, "\n  "))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&passphrase, "passphrase", "p", false, "从口令派生密钥（从标准输入读取）")
+	return cmd
+}
+
+func newKeyExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "export",
+		Short:        "输出当前密钥",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := createLnkInstance("").ExportKey()
+			if err != nil {
+				return err
+			}
+			cmd.Println(key)
+			return nil
+		},
+	}
+}
+
+func newKeyRotateCmd() *cobra.Command {
+	var passphrase bool
+	cmd := &cobra.Command{
+		Use:          "rotate",
+		Short:        "轮换密钥并重新加密所有文件",
+		Long:         "生成新密钥并重新加密所有主机中的加密文件，旧密钥备份为 <密钥文件>.old，完成后需要 push 并在其他机器导入新密钥",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance("")
+			var phrase string
+			if passphrase {
+				var err error
+				if phrase, err = readPassphrase(true); err != nil {
+					return err
+				}
+			}
+			n, err := lnk.RotateKey(phrase)
+			if err != nil {
+				return err
+			}
+			util.Log.Successf("密钥已轮换，重新加密了 %d 个文件\n", n)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&passphrase, "passphrase", "p", false, "从新口令派生密钥（从标准输入读取）")
+	return cmd
+}
+
+func newKeyStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "status",
+		Short:        "查看密钥状态",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := createLnkInstance("").KeyStatus()
+			if err != nil {
+				return err
+			}
+			printKeyInfo(info)
+			return nil
+		},
+	}
+}
+
+func printKeyInfo(info *core.KeyInfo) {
+	util.Log.Printf("密钥文件: %s\n", info.KeyFile)
+	if info.Exists {
+		util.Log.Printf("密钥 ID: %s\n", info.KeyID)
+	} else {
+		util.Log.Warn("密钥不存在，请运行 'zzz lnk key init' 或 'zzz lnk key import'")
+	}
+	if info.Passphrase {
+		util.Log.Printf("密钥来源: 口令\n")
+	}
+	util.Log.Printf("加密文件: %d\n", len(info.EncryptedFiles))
+	for _, f := range info.EncryptedFiles {
+		util.Log.Printf("  %s\n", f)
+	}
+	if len(info.Mismatched) > 0 {
+		util.Log.Warnf("%d 个加密文件无法使用当前密钥解密:\n  %s\n", len(info.Mismatched), strings.Join(info.Mismatched, "\n  "))
+	}
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func readLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdinReader.ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if err != nil && line == "" {
+		return "", fmt.Errorf("读取输入失败: %w", err)
+	}
+	return line, nil
+}
+
+func readPassphrase(confirm bool) (string, error) {
+	phrase, err := readLine("请输入口令: ")
+	if err != nil {
+		return "", err
+	}
+	if phrase == "" {
+		return "", fmt.Errorf("口令不能为空")
+	}
+	// 管道输入时不需要确认
+	if fi, err := os.Stdin.Stat(); confirm && err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		again, err := readLine("请再次输入口令: ")
+		if err != nil {
+			return "", err
+		}
+		if again != phrase {
+			return "", fmt.Errorf("两次输入的口令不一致")
+		}
+	}
+	return phrase, nil
+}
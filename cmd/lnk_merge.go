@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/sohaha/zzz/util"
+)
+
+// interactiveResolver 在终端中逐个显示冲突文件的差异，由用户选择保留的版本或编辑合并结果
+func interactiveResolver(lnk *core.Lnk) core.ConflictResolver {
+	return func(c *core.MergeConflict) ([]byte, error) {
+		util.Log.Warnf("冲突文件: %s\n", c.Path)
+		if c.Target != "" {
+			util.Log.Printf("部署位置: %s (%s)\n", c.Target, c.Type)
+		}
+		if c.Encrypted {
+			util.Log.Println("加密文件，以下为解密后的内容，保存时会重新加密")
+		}
+		showConflictDiff(lnk, c)
+
+		for {
+			util.Log.Println("[l] 保留本地  [r] 保留远程  [e] 编辑合并结果  [d] 查看差异  [a] 中止合并")
+			var choice string
+			fmt.Scanln(&choice)
+			switch strings.ToLower(strings.TrimSpace(choice)) {
+			case "l", "local":
+				return c.Local, nil
+			case "r", "remote":
+				return c.Remote, nil
+			case "d", "diff":
+				showConflictDiff(lnk, c)
+			case "e", "edit":
+				merged, err := editMerged(c)
+				if err != nil {
+					util.Log.Errorf("编辑失败: %v\n", err)
+					continue
+				}
+				if bytes.Contains(merged, []byte("<<<<<<<")) || bytes.Contains(merged, []byte(">>>>>>>")) {
+					util.Log.Warn("合并结果中仍有冲突标记，是否仍然使用? (y/N)")
+					var response string
+					fmt.Scanln(&response)
+					if response != "y" && response != "Y" {
+						continue
+					}
+				}
+				return merged, nil
+			case "a", "abort":
+				return nil, fmt.Errorf("已中止合并，仓库恢复到拉取前的状态")
+			}
+		}
+	}
+}
+
+func showConflictDiff(lnk *core.Lnk, c *core.MergeConflict) {
+	local, remote, err := lnk.ConflictDiff(c, isTerminal())
+	if err != nil {
+		util.Log.Errorf("生成差异失败: %v\n", err)
+		return
+	}
+	if c.Base == nil {
+		util.Log.Println("(双方都新增了此文件，以下差异相对空文件)")
+	}
+	util.Log.Println("--- 本地修改 ---")
+	fmt.Print(local)
+	util.Log.Println("--- 远程修改 ---")
+	fmt.Print(remote)
+}
+
+// editMerged 使用 $VISUAL/$EDITOR 编辑带冲突标记的合并结果，返回编辑后的内容
+func editMerged(c *core.MergeConflict) ([]byte, error) {
+	f, err := os.CreateTemp("", "lnk-merge-*"+filepath.Ext(strings.TrimSuffix(c.Path, core.EncryptedSuffix)))
+	if err != nil {
+		return nil, err
+	}
+	name := f.Name()
+	defer os.Remove(name)
+
+	content := c.Merged
+	if content == nil {
+		content = c.Local
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// 编辑器可以带参数，如 "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], name)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("运行编辑器 %s 失败: %w", editor, err)
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// 结构化输出格式
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+func addOutputFlag(cmd *cobra.Command, output *string) {
+	cmd.Flags().StringVarP(output, "output", "o", "", "输出格式: json|yaml，默认输出便于阅读的文本")
+}
+
+func checkOutputFormat(format string) error {
+	if format != outputJSON && format != outputYAML {
+		return fmt.Errorf("无效的输出格式: %s，可选: json|yaml", format)
+	}
+	return nil
+}
+
+// createOutputLnkInstance 结构化输出时提示信息写到标准错误，避免混入输出的文档
+func createOutputLnkInstance(host, format string) *core.Lnk {
+	if format == "" {
+		return createLnkInstance(host)
+	}
+	return createLnkInstanceWith(host, core.WithOutput(os.Stderr))
+}
+
+type outputError struct {
+	Code       core.ErrorCode         `json:"code" yaml:"code"`
+	Severity   core.ErrorSeverity     `json:"severity" yaml:"severity"`
+	Message    string                 `json:"message" yaml:"message"`
+	Suggestion string                 `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+	Context    map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+func newOutputError(err error) outputError {
+	oe := outputError{Code: core.ErrCodeUnknown, Severity: core.SeverityError, Message: err.Error()}
+	var se core.StructuredError
+	if errors.As(err, &se) {
+		oe.Code = se.Code()
+		oe.Severity = se.Severity()
+		oe.Suggestion = se.Suggestion()
+		if ctx := se.Context(); len(ctx) > 0 {
+			oe.Context = make(map[string]interface{}, len(ctx))
+			for k, v := range ctx {
+				// error 类型无法直接序列化
+				if e, ok := v.(error); ok {
+					v = e.Error()
+				}
+				oe.Context[k] = v
+			}
+		}
+	}
+	return oe
+}
+
+func encodeOutput(w io.Writer, format string, v interface{}) error {
+	if format == outputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// writeOutput 以 json/yaml 输出 produce 的结果，出错时输出 {"error": {...}} 并返回原错误，
+// 命令仍以非零状态退出
+func writeOutput(cmd *cobra.Command, format string, produce func() (interface{}, error)) error {
+	if err := checkOutputFormat(format); err != nil {
+		return err
+	}
+	v, err := produce()
+	if err != nil {
+		cmd.SilenceErrors = true
+		if encErr := encodeOutput(cmd.OutOrStdout(), format, map[string]interface{}{"error": newOutputError(err)}); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+	return encodeOutput(cmd.OutOrStdout(), format, v)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/spf13/cobra"
+)
+
+func TestWriteOutputStructuredError(t *testing.T) {
+	cause := core.NewStructuredError(core.ErrCodeGitMergeConflict, "冲突", core.SeverityError).
+		WithContext("files", []string{"a.conf"}).
+		WithSuggestion("运行 pull")
+	wrapped := fmt.Errorf("拉取失败: %w", cause)
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+	err := writeOutput(cmd, outputJSON, func() (interface{}, error) { return nil, wrapped })
+	if !errors.Is(err, wrapped) || !cmd.SilenceErrors {
+		t.Fatalf("expected original error with silenced output, got %v", err)
+	}
+
+	var out struct {
+		Error outputError `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	if out.Error.Code != core.ErrCodeGitMergeConflict || out.Error.Suggestion != "运行 pull" ||
+		!strings.HasPrefix(out.Error.Message, "拉取失败") || out.Error.Context["files"] == nil {
+		t.Fatalf("unexpected error output: %+v", out.Error)
+	}
+
+	buf.Reset()
+	if err := writeOutput(cmd, outputYAML, func() (interface{}, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(buf.String(), "code: UNKNOWN") {
+		t.Fatalf("unexpected yaml output: %s", buf.String())
+	}
+}
+
+func TestDoctorOutputKeepsStdoutClean(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repoDir := t.TempDir()
+	oldRepo := globalRepoPath
+	globalRepoPath = repoDir
+	defer func() { globalRepoPath = oldRepo }()
+
+	for _, args := range [][]string{{"init"}, {"config", "user.email", "test@example.com"}, {"config", "user.name", "Test"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	lnk := createLnkInstance("")
+	target := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(target, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lnk.Add(target); err != nil {
+		t.Fatal(err)
+	}
+	// 链接被普通文件替换，修复时会先备份
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(format string) (string, error) {
+		stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stdout.Close()
+		oldStdout := os.Stdout
+		os.Stdout = stdout
+		cmd := newDoctorCmd()
+		cmd.SetArgs([]string{"-o", format})
+		runErr := cmd.Execute()
+		os.Stdout = oldStdout
+		data, err := os.ReadFile(stdout.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data), runErr
+	}
+
+	if _, err := run("xml"); err == nil {
+		t.Fatal("expected invalid output format error")
+	}
+	if info, err := os.Lstat(target); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Fatal("invalid output format should not repair anything")
+	}
+
+	out, err := run(outputJSON)
+	if err != nil {
+		t.Fatalf("doctor failed: %v", err)
+	}
+	var result doctorResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("stdout is not a json document %q: %v", out, err)
+	}
+	if result.TotalIssues != 1 {
+		t.Fatalf("unexpected doctor result: %s", out)
+	}
+	if info, err := os.Lstat(target); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected broken link to be repaired")
+	}
+}
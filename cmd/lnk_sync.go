@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/sohaha/zzz/util"
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd() *cobra.Command {
+	var (
+		host     string
+		watching bool
+		quiet    time.Duration
+		interval time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:          "sync [flags]",
+		Short:        "提交本地变更并与远程仓库同步",
+		Long:         "提交本地变更，从远程仓库获取并合并更新后恢复链接，再推送本地提交；使用 --watch 持续监听被管理的文件自动同步",
+		SilenceUsage: true,
+		Example: `  # 同步一次
+  zzz lnk sync
+
+  # 持续监听，变更平息 10 秒后提交，每 5 分钟拉取一次
+  zzz lnk sync --watch
+
+  # 自定义等待时间与拉取间隔
+  zzz lnk sync --watch --quiet 30s --interval 1m`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance(host)
+			if !lnk.IsInitialized() {
+				return fmt.Errorf("lnk 仓库未初始化，请先运行 'zzz lnk init'")
+			}
+
+			if !watching {
+				result, err := lnk.Sync()
+				if result != nil {
+					printSyncResult(result)
+				}
+				if err != nil {
+					return err
+				}
+				if !result.Changed() {
+					util.Log.Println("已是最新，没有需要同步的变更")
+				}
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			util.Log.Printf("开始监听 %s，按 Ctrl+C 退出\n", lnk.GetRepoPath())
+			return lnk.WatchSync(ctx, core.WatchOptions{
+				Quiet:    quiet,
+				Interval: interval,
+				OnEvent: func(e core.SyncEvent) {
+					now := time.Now().Format("15:04:05")
+					switch e.Type {
+					case core.SyncEventSynced:
+						if e.Result.Changed() || len(e.Result.CopyConflicts) > 0 {
+							util.Log.Printf("[%s] ", now)
+							printSyncResult(e.Result)
+						}
+					case core.SyncEventPaused:
+						util.Log.Errorf("[%s] 检测到合并冲突，自动同步已暂停\n", now)
+						printSyncConflict(e.Err)
+					case core.SyncEventResumed:
+						util.Log.Successf("[%s] 冲突已解决，继续自动同步\n", now)
+					case core.SyncEventError:
+						util.Log.Warnf("[%s] 同步失败: %v\n", now, e.Err)
+					}
+				},
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&watching, "watch", "w", false, "持续监听被管理的文件并自动同步")
+	cmd.Flags().DurationVar(&quiet, "quiet", 10*time.Second, "最后一次变更后等待多久再提交")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "从远程仓库拉取的间隔")
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
+
+func printSyncResult(result *core.SyncResult) {
+	if n := len(result.Committed); n > 0 {
+		util.Log.Successf("已提交 %d 个文件: %s\n", n, strings.Join(result.Committed, ", "))
+	}
+	if result.Pulled {
+		util.Log.Successf("已合并远程更新并恢复链接\n")
+	}
+	if result.Pushed {
+		util.Log.Successf("已推送到远程仓库\n")
+	}
+	if len(result.CopyConflicts) > 0 {
+		util.Log.Warnf("以下复制文件存在冲突，未同步: %s\n", strings.Join(result.CopyConflicts, ", "))
+		util.Log.Warnf("请使用 'zzz lnk resolve <file> --keep local|repo' 选择保留的版本\n")
+	}
+}
+
+func printSyncConflict(err error) {
+	var se core.StructuredError
+	if !errors.As(err, &se) {
+		util.Log.Errorf("%v\n", err)
+		return
+	}
+	if files, ok := se.Context()["files"].([]string); ok && len(files) > 0 {
+		util.Log.Println("冲突文件:")
+		for _, f := range files {
+			util.Log.Printf("  %s\n", f)
+		}
+	}
+	if s := se.Suggestion(); s != "" {
+		util.Log.Println(s)
+	}
+}
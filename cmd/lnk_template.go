@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/sohaha/zzz/app/lnk/core"
+	"github.com/sohaha/zzz/util"
+	"github.com/spf13/cobra"
+)
+
+func newRenderCmd() *cobra.Command {
+	var host string
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "重新渲染模板文件",
+		Long: `使用当前主机的变量重新渲染 --template 添加的文件，pull 后会自动渲染
+
+模板使用 Go text/template 语法，可用变量:
+  .Host .Hostname .OS .Arch .User .Home
+  .Values  来自仓库根目录的 ` + core.ValuesFilename + `，主机目录中的同名文件会覆盖公共变量（键名为小写）
+
+可用函数: env default lower upper trim replace contains hasPrefix hasSuffix`,
+		SilenceUsage: true,
+		Example: `  # 模板示例
+  [user]
+      email = {{ .Values.email }}
+  {{- if eq .OS "darwin" }}
+  [credential]
+      helper = osxkeychain
+  {{- end }}
+  {{ with index .Values "proxy" }}proxy = {{ . }}{{ end }}
+
+  # 渲染当前主机的模板
+  zzz lnk render
+
+  # 查看渲染结果与已部署文件的差异
+  zzz lnk diff`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lnk := createLnkInstance(host)
+			changed, err := lnk.RenderTemplates()
+			if err != nil {
+				return err
+			}
+			if len(changed) == 0 {
+				util.Log.Successf("所有模板均为最新\n")
+				return nil
+			}
+			for _, f := range changed {
+				util.Log.Printf("已渲染: %s\n", f)
+			}
+			util.Log.Successf("重新渲染了 %d 个模板\n", len(changed))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&host, "host", "H", "", "指定主机名（默认使用系统主机名）")
+	return cmd
+}
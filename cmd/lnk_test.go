@@ -0,0 +1,26 @@
+package cmd
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"":      0,
+		"100":   100,
+		"512k":  512 << 10,
+		"512kb": 512 << 10,
+		"1MB":   1 << 20,
+		"1mb":   1 << 20,
+		" 2G ":  2 << 30,
+		"10b":   10,
+	} {
+		got, err := parseSize(in)
+		if err != nil || got != want {
+			t.Fatalf("parseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"abc", "-1M", "1.5M"} {
+		if _, err := parseSize(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
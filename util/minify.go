@@ -1,7 +1,9 @@
 package util
 
 import (
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/sohaha/zlsgo/zjson"
 	"github.com/sohaha/zlsgo/znet"
@@ -12,15 +14,44 @@ import (
 	"github.com/tdewolff/minify/v2/js"
 )
 
-func MinifyHandle(c *znet.Context) {
+// NewMinifier 创建压缩器，0、1、2 分别对应 html、js、css，同时支持按 MIME 类型调用
+func NewMinifier() *minify.M {
 	m := minify.New()
 	m.AddFunc("2", css.Minify)
 	m.AddFunc("0", html.Minify)
 	m.AddFunc("1", js.Minify)
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("text/html", html.Minify)
+	m.AddFunc("application/javascript", js.Minify)
 
 	// m.AddFunc("s", svg.Minify)
 	// m.AddFuncRegexp(regexp.MustCompile("[/+]j$"), json.Minify)
 	// m.AddFuncRegexp(regexp.MustCompile("[/+]xml$"), xml.Minify)
+	return m
+}
+
+// MinifyFile 根据扩展名压缩 HTML/CSS/JS 内容，不支持的类型或压缩失败时返回原内容
+func MinifyFile(m *minify.M, name string, data []byte) ([]byte, bool) {
+	mediatype := ""
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		mediatype = "text/html"
+	case ".css":
+		mediatype = "text/css"
+	case ".js", ".mjs":
+		mediatype = "application/javascript"
+	default:
+		return data, false
+	}
+	out, err := m.Bytes(mediatype, data)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+func MinifyHandle(c *znet.Context) {
+	m := NewMinifier()
 
 	j, err := c.GetJSONs()
 	if err != nil {